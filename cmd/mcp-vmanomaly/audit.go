@@ -0,0 +1,23 @@
+package main
+
+import (
+	"io"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/audit"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+)
+
+// newAuditLogger opens the audit log configured in c writing "stdout" entries to stdout.
+// It returns nil if the audit log is disabled.
+func newAuditLogger(c *config.Config, stdout io.Writer) (*audit.Logger, error) {
+	if c.AuditLog() == "" {
+		return nil, nil
+	}
+	return audit.Open(audit.Options{
+		Path:         c.AuditLog(),
+		MaxSize:      int64(c.AuditLogMaxSizeMB()) << 20,
+		MaxBackups:   c.AuditLogMaxBackups(),
+		RedactKeys:   c.AuditRedactKeys(),
+		RedactValues: c.AuditRedactValues(),
+	}, stdout)
+}
@@ -0,0 +1,193 @@
+// Package audit writes an audit log of tool calls as JSON lines
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/auth"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Stdout is the audit log destination writing entries to the standard output
+const Stdout = "stdout"
+
+// Outcomes of tool calls
+const (
+	// OutcomeSuccess is a call that returned a result
+	OutcomeSuccess = "success"
+	// OutcomeToolError is a call that returned an error result, e.g. rejected arguments, denied access or a failed upstream request
+	OutcomeToolError = "tool_error"
+	// OutcomeError is a call whose handler failed or panicked
+	OutcomeError = "error"
+)
+
+// maxErrorLen limits the length of error messages in entries
+const maxErrorLen = 1024
+
+// Entry is a single tool call in the audit log
+type Entry struct {
+	Time      time.Time `json:"ts"`
+	SessionID string    `json:"session_id,omitempty"`
+	Principal string    `json:"principal,omitempty"`
+	Tool      string    `json:"tool"`
+	// Args are tool arguments with credentials redacted
+	Args            any     `json:"args,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Outcome         string  `json:"outcome"`
+	Error           string  `json:"error,omitempty"`
+	// UpstreamStatus is the HTTP status of the first failed vmanomaly request of the call,
+	// or of the last one if all succeeded, 0 if the call made no requests
+	UpstreamStatus int `json:"upstream_status,omitempty"`
+	// ResultSize is the size of the JSON-encoded result in bytes
+	ResultSize int `json:"result_size"`
+}
+
+// Options configure the audit log
+type Options struct {
+	// Path is the audit log file path or Stdout
+	Path string
+	// MaxSize is the size in bytes at which the file is rotated, 0 disables rotation
+	MaxSize int64
+	// MaxBackups is the number of rotated files to keep
+	MaxBackups int
+	// RedactKeys are patterns of argument names redacted in addition to DefaultRedactKeys
+	RedactKeys []string
+	// RedactValues are regexps of argument value parts to redact
+	RedactValues []string
+}
+
+// Logger writes tool calls to the audit log
+type Logger struct {
+	redactor *Redactor
+
+	mu sync.Mutex
+	w  io.Writer
+	// closer is nil if the logger doesn't own w
+	closer io.Closer
+}
+
+// Open opens the audit log configured by opts. stdout is used when opts.Path is Stdout.
+func Open(opts Options, stdout io.Writer) (*Logger, error) {
+	redactor, err := NewRedactor(opts.RedactKeys, opts.RedactValues)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Path == Stdout {
+		return New(stdout, redactor), nil
+	}
+	rf, err := openRotatingFile(opts.Path, opts.MaxSize, opts.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	l := New(rf, redactor)
+	l.closer = rf
+	return l, nil
+}
+
+// New returns a logger writing entries to w
+func New(w io.Writer, redactor *Redactor) *Logger {
+	return &Logger{redactor: redactor, w: w}
+}
+
+// Close closes the audit log file. It does nothing if l is nil, i.e. the audit log is disabled.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// Log writes e to the audit log. Write failures are logged, but don't fail tool calls.
+func (l *Logger) Log(e *Entry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("Failed to encode audit log entry", "tool", e.Tool, "error", err)
+		return
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(data); err != nil {
+		slog.Error("Failed to write audit log entry", "tool", e.Tool, "error", err)
+	}
+}
+
+// Middleware logs every tool call. It must wrap all other tool handler middlewares,
+// including recovery, so denied and panicked calls are logged as well.
+func (l *Logger) Middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		ctx, status := vmanomaly.ContextWithStatusRecorder(ctx)
+		result, err := next(ctx, req)
+
+		e := &Entry{
+			Time:            start.UTC(),
+			SessionID:       sessionID(ctx),
+			Tool:            req.Params.Name,
+			Args:            l.redactor.Args(req.Params.Arguments),
+			DurationSeconds: time.Since(start).Seconds(),
+			UpstreamStatus:  status.Status(),
+		}
+		if p := auth.PrincipalFromContext(ctx); p != nil {
+			e.Principal = p.Name
+		}
+		switch {
+		case err != nil:
+			e.Outcome = OutcomeError
+			e.Error = err.Error()
+		case result != nil && result.IsError:
+			e.Outcome = OutcomeToolError
+			e.Error = errorText(result)
+		default:
+			e.Outcome = OutcomeSuccess
+		}
+		e.Error = truncate(l.redactor.String(e.Error), maxErrorLen)
+		if result != nil {
+			if data, err := json.Marshal(result); err == nil {
+				e.ResultSize = len(data)
+			}
+		}
+		l.Log(e)
+
+		return result, err
+	}
+}
+
+// sessionID returns the ID of the MCP session of the call, empty for calls outside of a session
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// errorText returns the text of an error result
+func errorText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := mcp.AsTextContent(content); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces redacted values
+const Redacted = "[REDACTED]"
+
+// DefaultRedactKeys are patterns of argument names whose values are always redacted.
+// They cover bearer tokens, passwords and other credentials of datasources in vmanomaly configs.
+var DefaultRedactKeys = []string{
+	"*token*",
+	"*password*",
+	"*passwd*",
+	"*secret*",
+	"*authorization*",
+	"*api_key*",
+	"*apikey*",
+	"*credential*",
+	"*private_key*",
+	"cookie",
+	"set-cookie",
+}
+
+var (
+	// urlUserinfoRe matches credentials in URLs, e.g. datasource_url: http://user:pass@vm:8428
+	urlUserinfoRe = regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.-]*://)[^/?#@\s]+@`)
+	// authSchemeRe matches credentials of Authorization header values
+	authSchemeRe = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[a-z0-9._~+/=-]+`)
+)
+
+// Redactor removes credentials from tool arguments before they are written to the audit log
+type Redactor struct {
+	keys   []string
+	values []*regexp.Regexp
+}
+
+// NewRedactor returns a redactor replacing values of arguments whose names match DefaultRedactKeys or keys,
+// and parts of string values matching values regexps. Key patterns use path.Match syntax and are case-insensitive.
+// Credentials in URLs and Authorization header values are always redacted.
+func NewRedactor(keys, values []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, pattern := range slices.Concat(DefaultRedactKeys, keys) {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid redaction key pattern %q: %w", pattern, err)
+		}
+		r.keys = append(r.keys, pattern)
+	}
+	for _, expr := range values {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction value regexp %q: %w", expr, err)
+		}
+		r.values = append(r.values, re)
+	}
+	return r, nil
+}
+
+// Args returns a copy of tool arguments with credentials redacted, args are not modified
+func (r *Redactor) Args(args any) any {
+	switch v := args.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, value := range v {
+			if r.isSecretKey(key) {
+				redacted[key] = Redacted
+			} else {
+				redacted[key] = r.Args(value)
+			}
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, value := range v {
+			redacted[i] = r.Args(value)
+		}
+		return redacted
+	case string:
+		return r.String(v)
+	default:
+		return v
+	}
+}
+
+// String redacts credentials in s
+func (r *Redactor) String(s string) string {
+	s = urlUserinfoRe.ReplaceAllString(s, "${1}"+Redacted+"@")
+	s = authSchemeRe.ReplaceAllString(s, "${1} "+Redacted)
+	for _, re := range r.values {
+		s = re.ReplaceAllLiteralString(s, Redacted)
+	}
+	return s
+}
+
+func (r *Redactor) isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range r.keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
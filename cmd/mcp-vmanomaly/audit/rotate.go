@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// rotatingFile is a file renamed to <path>.1 once it reaches maxSize bytes, shifting older backups up to <path>.<maxBackups>.
+// It is not safe for concurrent use.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	f    *os.File
+	size int64
+}
+
+// openRotatingFile opens the file at path for appending, maxSize <= 0 disables rotation
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log file: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat audit log file: %w", err)
+	}
+	rf.f = f
+	rf.size = fi.Size()
+	return nil
+}
+
+// Write appends p to the file, rotating it first if p doesn't fit into maxSize.
+// Entries aren't split between files, so a file exceeds maxSize if a single entry does.
+// If rotation fails, p is still appended to the current file and the error is returned.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	var rotateErr error
+	if rf.f == nil {
+		// Reopening failed during the previous rotation
+		rotateErr = rf.open()
+	} else if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		rotateErr = rf.rotate()
+	}
+	if rf.f == nil {
+		return 0, rotateErr
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// rotate closes the file, shifts backups and opens a new file.
+// The file is reopened even if shifting fails, so later entries aren't lost.
+func (rf *rotatingFile) rotate() error {
+	closeErr := rf.f.Close()
+	rf.f = nil
+	shiftErr := rf.shift()
+	if err := rf.open(); err != nil {
+		return err
+	}
+	if err := errors.Join(closeErr, shiftErr); err != nil {
+		return fmt.Errorf("failed to rotate audit log file: %w", err)
+	}
+	return nil
+}
+
+// shift renames the file to the first backup and backups to the next ones, dropping the oldest one
+func (rf *rotatingFile) shift() error {
+	if rf.maxBackups == 0 {
+		return os.Remove(rf.path)
+	}
+	_ = os.Remove(rf.backup(rf.maxBackups))
+	for i := rf.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(rf.backup(i), rf.backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(rf.path, rf.backup(1))
+}
+
+func (rf *rotatingFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", rf.path, i)
+}
+
+func (rf *rotatingFile) Close() error {
+	if rf.f == nil {
+		return nil
+	}
+	return rf.f.Close()
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	rf, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rf.Close()
+
+	// Existing content counts towards the size limit
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeeeeeeeeeee\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Entries larger than the limit are written to a file of their own
+	if got := readFile(t, path); got != "eeeeeeeeeeee\n" {
+		t.Errorf("unexpected current file: %q", got)
+	}
+	if got := readFile(t, path+".1"); got != "dddd\n" {
+		t.Errorf("unexpected first backup: %q", got)
+	}
+	if got := readFile(t, path+".2"); got != "bbbb\ncccc\n" {
+		t.Errorf("unexpected second backup: %q", got)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups, got error %v", err)
+	}
+}
+
+func TestRotatingFile_NoBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	rf, err := openRotatingFile(path, 8, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rf.Close()
+
+	for _, line := range []string{"aaaa\n", "bbbb\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := readFile(t, path); got != "bbbb\n" {
+		t.Errorf("unexpected current file: %q", got)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected no backups, got %d files", len(entries))
+	}
+}
+
+func TestRotatingFile_NoRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	rf, err := openRotatingFile(path, 0, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rf.Close()
+
+	for range 10 {
+		if _, err := rf.Write([]byte("aaaa\n")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := readFile(t, path); got != strings.Repeat("aaaa\n", 10) {
+		t.Errorf("unexpected file: %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/auth"
+)
+
+// accessControl authenticates callers of sse and http transports and authorizes their tool calls
+type accessControl struct {
+	// authn is empty if inbound auth is disabled
+	authn auth.Chain
+	// policy is nil if no roles are configured
+	policy *auth.Policy
+}
+
+// newAccessControl returns authenticators and the role policy configured in c
+func newAccessControl(c *config.Config) (*accessControl, error) {
+	authn, err := newAuthenticator(c)
+	if err != nil {
+		return nil, err
+	}
+	ac := &accessControl{authn: authn}
+	if len(c.Roles()) > 0 || c.AuthDefaultRole() != "" {
+		roles := make([]auth.Role, 0, len(c.Roles()))
+		for _, r := range c.Roles() {
+			roles = append(roles, auth.Role(r))
+		}
+		ac.policy, err = auth.NewPolicy(roles, c.AuthDefaultRole())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ac, nil
+}
+
+// newAuthenticator returns authenticators of callers of sse and http transports enabled in c,
+// the chain is empty if inbound auth is disabled
+func newAuthenticator(c *config.Config) (auth.Chain, error) {
+	var chain auth.Chain
+	if tokens := c.AuthTokens(); len(tokens) > 0 {
+		chain = append(chain, auth.NewTokens(tokens))
+	}
+	if path := c.AuthHtpasswdFile(); path != "" {
+		h, err := auth.LoadHtpasswd(path)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, h)
+	}
+	if path := c.AuthJWKSFile(); path != "" {
+		j, err := auth.LoadJWT(path, auth.JWTConfig{
+			Audience:       c.AuthJWTAudience(),
+			Issuer:         c.AuthJWTIssuer(),
+			PrincipalClaim: c.AuthJWTPrincipalClaim(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, j)
+	}
+	return chain, nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Output formats of call and list-tools commands
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// callOptions are options of call and list-tools commands
+type callOptions struct {
+	args     string
+	argsFile string
+	output   string
+}
+
+// parseArgs returns tool arguments from -args or -args-file ("-" reads stdin)
+func (o *callOptions) parseArgs(stdin io.Reader) (map[string]any, error) {
+	data := []byte(o.args)
+	if o.argsFile != "" {
+		if o.args != "" {
+			return nil, fmt.Errorf("-args and -args-file are mutually exclusive")
+		}
+		var err error
+		if o.argsFile == "-" {
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(o.argsFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read -args-file: %w", err)
+		}
+	}
+
+	args := make(map[string]any)
+	if strings.TrimSpace(string(data)) == "" {
+		return args, nil
+	}
+	if err := json.Unmarshal(data, &args); err != nil {
+		return nil, fmt.Errorf("tool arguments must be a JSON object: %w", err)
+	}
+	return args, nil
+}
+
+// newInProcessServer creates the same MCP server as serve without starting any transport.
+// The returned function releases its resources.
+func newInProcessServer(load func() (*config.Config, error), stderr io.Writer) (*server.MCPServer, func(), int) {
+	c, err := load()
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid config: %v\n", err)
+		return nil, nil, exitConfigError
+	}
+	logLevel, closeLog := setupLogging(c)
+
+	// stdout carries results of commands, so audit log entries for stdout go to stderr
+	auditLog, err := newAuditLogger(c, stderr)
+	if err != nil {
+		closeLog()
+		fmt.Fprintf(stderr, "Failed to open audit log: %v\n", err)
+		return nil, nil, exitConfigError
+	}
+	ms := metrics.NewSet()
+	tracer, stopTracing := setupTracing(c, ms)
+	instances, stopInstances, err := newInstances(c, ms)
+	if err != nil {
+		stopTracing()
+		_ = auditLog.Close()
+		closeLog()
+		fmt.Fprintf(stderr, "Failed to create vmanomaly clients: %v\n", err)
+		return nil, nil, exitConfigError
+	}
+	// Inbound auth and roles only apply to sse and http transports
+	reloader := newConfigReloader(c, load, instances, stopInstances, &accessControl{}, ms)
+	cleanup := func() {
+		reloader.Stop()
+		stopTracing()
+		_ = auditLog.Close()
+		closeLog()
+	}
+	mcpServer, err := newMCPServer(c, reloader, auditLog, tracer, ms, logLevel)
+	if err != nil {
+		cleanup()
+		fmt.Fprintf(stderr, "Failed to create MCP server: %v\n", err)
+		return nil, nil, exitError
+	}
+	return mcpServer, cleanup, exitOK
+}
+
+// dispatch sends a single JSON-RPC request to the MCP server and returns its result
+func dispatch(s *server.MCPServer, method mcp.MCPMethod, params any) (any, error) {
+	msg, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	switch resp := s.HandleMessage(context.Background(), msg).(type) {
+	case mcp.JSONRPCResponse:
+		return resp.Result, nil
+	case mcp.JSONRPCError:
+		return nil, fmt.Errorf("%s", resp.Error.Message)
+	default:
+		return nil, fmt.Errorf("unexpected response %T", resp)
+	}
+}
+
+// callTool dispatches a tools/call request in-process and prints its result.
+// It returns exitError if the tool call fails or the tool returns an error result.
+func callTool(load func() (*config.Config, error), toolName string, opts *callOptions, stdin io.Reader, stdout, stderr io.Writer) int {
+	args, err := opts.parseArgs(stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	mcpServer, cleanup, code := newInProcessServer(load, stderr)
+	if code != exitOK {
+		return code
+	}
+	defer cleanup()
+
+	resp, err := dispatch(mcpServer, mcp.MethodToolsCall, mcp.CallToolParams{Name: toolName, Arguments: args})
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to call tool %q: %v\n", toolName, err)
+		return exitError
+	}
+	result, ok := resp.(mcp.CallToolResult)
+	if !ok {
+		fmt.Fprintf(stderr, "Unexpected result of tool %q: %T\n", toolName, resp)
+		return exitError
+	}
+
+	out := stdout
+	if result.IsError {
+		out = stderr
+	}
+	if opts.output == outputJSON {
+		writeJSON(out, result)
+	} else {
+		for _, content := range result.Content {
+			if tc, ok := content.(mcp.TextContent); ok {
+				fmt.Fprintln(out, tc.Text)
+			}
+		}
+	}
+
+	if result.IsError {
+		return exitError
+	}
+	return exitOK
+}
+
+// listTools dispatches a tools/list request in-process and prints the tools available with the current config
+func listTools(load func() (*config.Config, error), opts *callOptions, stdout, stderr io.Writer) int {
+	mcpServer, cleanup, code := newInProcessServer(load, stderr)
+	if code != exitOK {
+		return code
+	}
+	defer cleanup()
+
+	resp, err := dispatch(mcpServer, mcp.MethodToolsList, struct{}{})
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to list tools: %v\n", err)
+		return exitError
+	}
+	result, ok := resp.(mcp.ListToolsResult)
+	if !ok {
+		fmt.Fprintf(stderr, "Unexpected result of tools list: %T\n", resp)
+		return exitError
+	}
+	sort.Slice(result.Tools, func(i, j int) bool {
+		return result.Tools[i].Name < result.Tools[j].Name
+	})
+
+	if opts.output == outputJSON {
+		writeJSON(stdout, result.Tools)
+		return exitOK
+	}
+	for _, tool := range result.Tools {
+		title := tool.Annotations.Title
+		if title == "" {
+			title, _, _ = strings.Cut(tool.Description, ". ")
+		}
+		fmt.Fprintf(stdout, "%-36s %s\n", tool.Name, title)
+	}
+	return exitOK
+}
+
+func writeJSON(w io.Writer, v any) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRun_Call(t *testing.T) {
+	isolateEnv(t)
+
+	argsFile := filepath.Join(t.TempDir(), "args.json")
+	if err := os.WriteFile(argsFile, []byte(`{"model_class": "zscore"}`), 0o600); err != nil {
+		t.Fatalf("failed to write args file: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "text output",
+			args:       []string{"call", "vmanomaly_health_check", "-fake-backend"},
+			wantCode:   exitOK,
+			wantStdout: `"status": "ok"`,
+		},
+		{
+			name:       "tool name after flags",
+			args:       []string{"call", "-fake-backend", "-args", `{"model_class":"zscore"}`, "vmanomaly_get_model_schema"},
+			wantCode:   exitOK,
+			wantStdout: `"z_threshold"`,
+		},
+		{
+			name:       "args file",
+			args:       []string{"call", "vmanomaly_get_model_schema", "-fake-backend", "-args-file", argsFile},
+			wantCode:   exitOK,
+			wantStdout: `"z_threshold"`,
+		},
+		{
+			name:       "error result",
+			args:       []string{"call", "vmanomaly_get_task_status", "-fake-backend", "-args", `{"task_id":"missing"}`},
+			wantCode:   exitError,
+			wantStderr: "Task not found",
+		},
+		{
+			name:       "unknown tool",
+			args:       []string{"call", "bogus", "-fake-backend"},
+			wantCode:   exitError,
+			wantStderr: `Failed to call tool "bogus"`,
+		},
+		{
+			name:       "args and args file",
+			args:       []string{"call", "vmanomaly_get_model_schema", "-fake-backend", "-args", `{}`, "-args-file", argsFile},
+			wantCode:   exitUsage,
+			wantStderr: "-args and -args-file are mutually exclusive",
+		},
+		{
+			name:       "args not an object",
+			args:       []string{"call", "vmanomaly_health_check", "-fake-backend", "-args", `[1]`},
+			wantCode:   exitUsage,
+			wantStderr: "tool arguments must be a JSON object",
+		},
+		{
+			name:       "invalid config",
+			args:       []string{"call", "vmanomaly_health_check"},
+			wantCode:   exitConfigError,
+			wantStderr: "Invalid config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d\nstdout: %s\nstderr: %s", code, tt.wantCode, stdout, stderr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("stdout = %q, want containing %q", stdout, tt.wantStdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr = %q, want containing %q", stderr, tt.wantStderr)
+			}
+			// Results of failed calls go to stderr only
+			if code != exitOK && stdout != "" {
+				t.Errorf("expected empty stdout on failure, got %q", stdout)
+			}
+		})
+	}
+}
+
+func TestRun_CallJSONOutput(t *testing.T) {
+	isolateEnv(t)
+
+	parse := func(t *testing.T, out string) (result struct {
+		IsError bool `json:"isError"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}) {
+		t.Helper()
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("failed to parse JSON output %q: %v", out, err)
+		}
+		return result
+	}
+
+	code, stdout, stderr := runCLI("call", "vmanomaly_health_check", "-fake-backend", "-output", "json")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, want %d\nstderr: %s", code, exitOK, stderr)
+	}
+	result := parse(t, stdout)
+	if result.IsError || len(result.Content) != 1 || result.Content[0].Type != "text" || !strings.Contains(result.Content[0].Text, `"status": "ok"`) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	code, stdout, stderr = runCLI("call", "vmanomaly_get_task_status", "-fake-backend", "-output", "json", "-args", `{"task_id":"missing"}`)
+	if code != exitError || stdout != "" {
+		t.Fatalf("exit code = %d, stdout = %q, want %d and empty stdout", code, stdout, exitError)
+	}
+	// stderr also carries logs, the result is its last JSON object
+	result = parse(t, stderr[strings.LastIndex(stderr, "\n{")+1:])
+	if !result.IsError || len(result.Content) == 0 || !strings.Contains(result.Content[0].Text, "Task not found") {
+		t.Errorf("unexpected error result: %+v", result)
+	}
+}
+
+func TestRun_ListTools(t *testing.T) {
+	isolateEnv(t)
+
+	code, stdout, stderr := runCLI("list-tools", "-fake-backend")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, want %d\nstderr: %s", code, exitOK, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	var names []string
+	for _, line := range lines {
+		name, title, _ := strings.Cut(line, " ")
+		if strings.TrimSpace(title) == "" {
+			t.Errorf("expected tool title in line %q", line)
+		}
+		names = append(names, name)
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("expected tools sorted by name, got %v", names)
+	}
+	for _, want := range []string{"vmanomaly_health_check", "vmanomaly_list_models", "vmanomaly_create_detection_task"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("expected tool %q in %v", want, names)
+		}
+	}
+
+	code, stdout, stderr = runCLI("list-tools", "-fake-backend", "-output", "json")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, want %d\nstderr: %s", code, exitOK, stderr)
+	}
+	var tools []struct {
+		Name        string         `json:"name"`
+		InputSchema map[string]any `json:"inputSchema"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &tools); err != nil {
+		t.Fatalf("failed to parse JSON output: %v", err)
+	}
+	if len(tools) != len(names) {
+		t.Errorf("expected %d tools in JSON output, got %d", len(names), len(tools))
+	}
+	for i, tool := range tools {
+		if tool.Name != names[i] || tool.InputSchema["type"] != "object" {
+			t.Errorf("unexpected tool %d: %+v", i, tool)
+		}
+	}
+
+	// Tools hidden by the config aren't listed
+	code, stdout, _ = runCLI("list-tools", "-fake-backend", "-mcp.readOnly")
+	if code != exitOK || strings.Contains(stdout, "vmanomaly_create_detection_task") || !strings.Contains(stdout, "vmanomaly_health_check") {
+		t.Errorf("expected read-only tools only, got exit code %d:\n%s", code, stdout)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/audit"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+)
+
+// checkConfig validates config and probes health and build info of every vmanomaly instance.
+// It returns exitConfigError if config is invalid and exitUnavailable if any instance is unhealthy.
+func checkConfig(load func() (*config.Config, error), stdout, stderr io.Writer) int {
+	c, err := load()
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid config: %v\n", err)
+		return exitConfigError
+	}
+	if _, err := newAccessControl(c); err != nil {
+		fmt.Fprintf(stderr, "Invalid config: %v\n", err)
+		return exitConfigError
+	}
+	if _, err := audit.NewRedactor(c.AuditRedactKeys(), c.AuditRedactValues()); err != nil {
+		fmt.Fprintf(stderr, "Invalid config: %v\n", err)
+		return exitConfigError
+	}
+	instances, stopInstances, err := newInstances(c, metrics.NewSet())
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid config: %v\n", err)
+		return exitConfigError
+	}
+	defer stopInstances()
+
+	fmt.Fprintf(stdout, "Config is valid: %s mode, %d vmanomaly instance(s), default instance %q\n",
+		c.ServerMode(), len(instances.List()), instances.Default())
+
+	failed := 0
+	for _, inst := range instances.List() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.Timeout())
+		health, err := inst.Client.GetHealth(ctx)
+		if err != nil {
+			cancel()
+			failed++
+			fmt.Fprintf(stderr, "Instance %q (%s): health check failed: %v\n", inst.Name, inst.Endpoint, err)
+			continue
+		}
+		buildInfo, err := inst.Client.GetBuildInfo(ctx)
+		cancel()
+		if err != nil {
+			failed++
+			fmt.Fprintf(stderr, "Instance %q (%s): failed to get build info: %v\n", inst.Name, inst.Endpoint, err)
+			continue
+		}
+		version, _ := buildInfo["vmanomaly"].(string)
+		if version == "" {
+			version, _ = buildInfo["version"].(string)
+		}
+		fmt.Fprintf(stdout, "Instance %q (%s): status %v, version %s\n", inst.Name, inst.Endpoint, health["status"], version)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(stderr, "%d of %d vmanomaly instance(s) are unavailable\n", failed, len(instances.List()))
+		return exitUnavailable
+	}
+	return exitOK
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+)
+
+// Process exit codes
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitConfigError = 3
+	exitUnavailable = 4
+)
+
+const usageHeader = `Usage: mcp-vmanomaly [command] [flags]
+
+Commands:
+  serve          Run the MCP server (default)
+  check-config   Validate config and check that vmanomaly instances are healthy
+  call <tool>    Call a single tool in-process and print its result
+  list-tools     List tools available with the current config
+  version        Print version
+
+Exit codes: 0 success, 1 runtime error, 2 invalid usage, 3 invalid config, 4 vmanomaly is unavailable.
+Flags override env variables, which override the config file.
+`
+
+// run executes the command in args and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	cmd := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "version":
+		fmt.Fprintln(stdout, versionString())
+		return exitOK
+	case "help":
+		fmt.Fprint(stdout, usageHeader)
+		return exitOK
+	case "serve", "check-config", "call", "list-tools":
+	default:
+		fmt.Fprintf(stderr, "Unknown command %q\n\n%s", cmd, usageHeader)
+		return exitUsage
+	}
+
+	// The tool name of call may precede or follow flags
+	var toolName string
+	if cmd == "call" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		toolName, args = args[0], args[1:]
+	}
+
+	fs, opts := newFlagSet(cmd, stderr)
+	callOpts := &callOptions{}
+	if cmd == "call" || cmd == "list-tools" {
+		fs.StringVar(&callOpts.output, "output", outputText, "Output format: text or json")
+	}
+	if cmd == "call" {
+		fs.StringVar(&callOpts.args, "args", "", "Tool arguments as a JSON object")
+		fs.StringVar(&callOpts.argsFile, "args-file", "", "File with tool arguments as a JSON object, - reads stdin")
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	rest := fs.Args()
+	if cmd == "call" && toolName == "" && len(rest) > 0 {
+		toolName, rest = rest[0], rest[1:]
+	}
+	if len(rest) > 0 {
+		fmt.Fprintf(stderr, "Unexpected arguments: %s\n", strings.Join(rest, " "))
+		return exitUsage
+	}
+	if opts.version {
+		fmt.Fprintln(stdout, versionString())
+		return exitOK
+	}
+	if (cmd == "call" || cmd == "list-tools") && callOpts.output != outputText && callOpts.output != outputJSON {
+		fmt.Fprintf(stderr, "Unknown output format %q, must be text or json\n", callOpts.output)
+		return exitUsage
+	}
+
+	switch cmd {
+	case "check-config":
+		return checkConfig(opts.load, stdout, stderr)
+	case "call":
+		if toolName == "" {
+			fmt.Fprintf(stderr, "Tool name is required: mcp-vmanomaly call <tool> [flags]\n")
+			return exitUsage
+		}
+		return callTool(opts.load, toolName, callOpts, os.Stdin, stdout, stderr)
+	case "list-tools":
+		return listTools(opts.load, callOpts, stdout, stderr)
+	}
+	return serve(opts.load)
+}
+
+func versionString() string {
+	return fmt.Sprintf("%s v%s (date: %s)", serverName, version, date)
+}
+
+// cliOptions are options set with command-line flags
+type cliOptions struct {
+	configFile string
+	version    bool
+	// overrides holds settings set with flags keyed by env variable name
+	overrides map[string]string
+}
+
+func (o *cliOptions) load() (*config.Config, error) {
+	return config.LoadWithOverrides(o.configFile, o.overrides)
+}
+
+// newFlagSet returns flags of the command: a flag for every setting, -config and -version
+func newFlagSet(cmd string, stderr io.Writer) (*flag.FlagSet, *cliOptions) {
+	opts := &cliOptions{overrides: make(map[string]string)}
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "%s\nFlags of %s:\n", usageHeader, cmd)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&opts.configFile, "config", "", "Path to YAML or JSON config file, reloaded on SIGHUP or change")
+	fs.BoolVar(&opts.version, "version", false, "Print version and exit")
+	for _, s := range config.Settings {
+		fs.Var(&settingValue{env: s.Env, isBool: s.Bool, values: opts.overrides}, s.Flag(), fmt.Sprintf("%s (env %s)", s.Usage, s.Env))
+	}
+	fs.Var(&settingValue{env: "VMANOMALY_FAKE_BACKEND", isBool: true, values: opts.overrides}, "fake-backend", "Same as -vmanomaly.fakeBackend")
+
+	return fs, opts
+}
+
+// settingValue is a flag.Value storing the flag value as a setting override
+type settingValue struct {
+	env    string
+	isBool bool
+	values map[string]string
+}
+
+func (v *settingValue) String() string {
+	if v == nil || v.values == nil {
+		return ""
+	}
+	return v.values[v.env]
+}
+
+func (v *settingValue) Set(s string) error {
+	v.values[v.env] = s
+	return nil
+}
+
+func (v *settingValue) IsBoolFlag() bool {
+	return v.isBool
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+)
+
+// isolateEnv unsets env variables of all settings for the test,
+// and disables docs resources, which take seconds to index
+func isolateEnv(t *testing.T) {
+	t.Helper()
+	for _, s := range config.Settings {
+		t.Setenv(s.Env, "")
+	}
+	t.Setenv("MCP_DISABLE_RESOURCES", "true")
+}
+
+// runCLI runs the command and returns its exit code, stdout and stderr
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	isolateEnv(t)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "version command", args: []string{"version"}, wantCode: exitOK, wantStdout: serverName},
+		{name: "version flag", args: []string{"check-config", "-version"}, wantCode: exitOK, wantStdout: serverName},
+		{name: "help", args: []string{"help"}, wantCode: exitOK, wantStdout: "Exit codes:"},
+		{name: "help flag", args: []string{"list-tools", "-h"}, wantCode: exitOK, wantStderr: "Flags of list-tools:"},
+		{name: "unknown command", args: []string{"bogus"}, wantCode: exitUsage, wantStderr: `Unknown command "bogus"`},
+		{name: "unknown flag", args: []string{"check-config", "-bogus"}, wantCode: exitUsage, wantStderr: "flag provided but not defined: -bogus"},
+		{name: "unexpected argument", args: []string{"check-config", "extra"}, wantCode: exitUsage, wantStderr: "Unexpected arguments: extra"},
+		{name: "unexpected argument after tool name", args: []string{"call", "vmanomaly_health_check", "extra"}, wantCode: exitUsage, wantStderr: "Unexpected arguments: extra"},
+		{name: "call without tool name", args: []string{"call", "-fake-backend"}, wantCode: exitUsage, wantStderr: "Tool name is required"},
+		{name: "unknown output format", args: []string{"list-tools", "-output", "yaml"}, wantCode: exitUsage, wantStderr: `Unknown output format "yaml"`},
+		{name: "output flag of other commands", args: []string{"check-config", "-output", "json"}, wantCode: exitUsage, wantStderr: "flag provided but not defined: -output"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d\nstdout: %s\nstderr: %s", code, tt.wantCode, stdout, stderr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("stdout = %q, want containing %q", stdout, tt.wantStdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr = %q, want containing %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRun_CheckConfig(t *testing.T) {
+	isolateEnv(t)
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	content := `
+server:
+  mode: sse
+vmanomaly:
+  fake_backend: true
+`
+	if err := os.WriteFile(configFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "fake backend flag",
+			args:       []string{"check-config", "-vmanomaly.fakeBackend"},
+			wantCode:   exitOK,
+			wantStdout: `status ok, version v1.28.0-fake`,
+		},
+		{
+			name:       "fake backend alias",
+			args:       []string{"check-config", "-fake-backend"},
+			wantCode:   exitOK,
+			wantStdout: `Config is valid: stdio mode, 1 vmanomaly instance(s), default instance "default"`,
+		},
+		{
+			name:       "fake backend env",
+			env:        map[string]string{"VMANOMALY_FAKE_BACKEND": "true"},
+			args:       []string{"check-config"},
+			wantCode:   exitOK,
+			wantStdout: "Config is valid: stdio mode",
+		},
+		{
+			name:       "config file",
+			args:       []string{"check-config", "-config", configFile},
+			wantCode:   exitOK,
+			wantStdout: "Config is valid: sse mode",
+		},
+		{
+			name:       "env overrides config file",
+			env:        map[string]string{"MCP_SERVER_MODE": "http"},
+			args:       []string{"check-config", "-config", configFile},
+			wantCode:   exitOK,
+			wantStdout: "Config is valid: http mode",
+		},
+		{
+			name:       "flag overrides env and config file",
+			env:        map[string]string{"MCP_SERVER_MODE": "http"},
+			args:       []string{"check-config", "-config", configFile, "-mcp.serverMode", "stdio"},
+			wantCode:   exitOK,
+			wantStdout: "Config is valid: stdio mode",
+		},
+		{
+			name:       "flag disables fake backend of config file",
+			args:       []string{"check-config", "-config", configFile, "-fake-backend=false"},
+			wantCode:   exitConfigError,
+			wantStderr: "Invalid config",
+		},
+		{
+			name:       "invalid setting",
+			args:       []string{"check-config", "-fake-backend", "-mcp.serverMode", "bogus"},
+			wantCode:   exitConfigError,
+			wantStderr: "MCP_SERVER_MODE must be",
+		},
+		{
+			name:       "missing config file",
+			args:       []string{"check-config", "-config", filepath.Join(dir, "missing.yaml")},
+			wantCode:   exitConfigError,
+			wantStderr: "Invalid config",
+		},
+		{
+			name:       "unavailable instance",
+			args:       []string{"check-config", "-vmanomaly.endpoint", "http://127.0.0.1:1", "-vmanomaly.maxRetries", "0"},
+			wantCode:   exitUnavailable,
+			wantStderr: `Instance "default" (http://127.0.0.1:1): health check failed`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for env, value := range tt.env {
+				t.Setenv(env, value)
+			}
+			code, stdout, stderr := runCLI(tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d\nstdout: %s\nstderr: %s", code, tt.wantCode, stdout, stderr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("stdout = %q, want containing %q", stdout, tt.wantStdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr = %q, want containing %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
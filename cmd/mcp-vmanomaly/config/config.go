@@ -2,12 +2,35 @@ package config
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// Instance is a named vmanomaly backend
+type Instance struct {
+	Name          string
+	Endpoint      string
+	BearerToken   string
+	CustomHeaders map[string]string
+}
+
+// Role allows principals to call tools matching name patterns and an annotation class
+type Role struct {
+	Name       string
+	Principals []string
+	Tools      []string
+	Class      string
+}
+
+// instanceNameRe restricts instance names to ones usable in env variable names
+var instanceNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 type Config struct {
 	vmanomalyEndpoint string
 	serverMode        string
@@ -15,10 +38,72 @@ type Config struct {
 	disabledTools     map[string]bool
 	heartbeatInterval time.Duration
 	disableResources  bool
+	readOnly          bool
 	logLevel          string
 	logFile           string
 	bearerToken       string
 	customHeaders     map[string]string
+	fakeBackend       bool
+
+	timeout                 time.Duration
+	maxRetries              int
+	retryBackoff            time.Duration
+	retryMaxBackoff         time.Duration
+	circuitBreakerThreshold int
+	circuitBreakerTimeout   time.Duration
+	cacheTTL                time.Duration
+
+	readinessProbeInterval    time.Duration
+	readinessFailureThreshold int
+
+	tlsCAFile             string
+	tlsCertFile           string
+	tlsKeyFile            string
+	tlsServerName         string
+	tlsInsecureSkipVerify bool
+
+	forwardAuthHeader string
+
+	authTokens            map[string]string
+	authHtpasswdFile      string
+	authJWKSFile          string
+	authJWTAudience       string
+	authJWTIssuer         string
+	authJWTPrincipalClaim string
+	authDefaultRole       string
+	roles                 []Role
+
+	auditLog           string
+	auditLogMaxSizeMB  int
+	auditLogMaxBackups int
+	auditRedactKeys    []string
+	auditRedactValues  []string
+
+	tracingEndpoint    string
+	tracingSampleRatio float64
+
+	instances       []Instance
+	defaultInstance string
+
+	configFile   string
+	toolTimeouts map[string]time.Duration
+}
+
+// source looks up settings by env variable name.
+// Overrides (command-line flags) take precedence over env variables, which override values from the config file.
+type source struct {
+	overrides map[string]string
+	file      map[string]string
+}
+
+func (s source) get(name string) string {
+	if v := s.overrides[name]; v != "" {
+		return v
+	}
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return s.file[name]
 }
 
 func parseCustomHeaders(headersEnv string) map[string]string {
@@ -44,9 +129,143 @@ func parseCustomHeaders(headersEnv string) map[string]string {
 	return customHeadersMap
 }
 
+// instanceEnvPrefix returns the prefix of env variables with credentials of the named instance
+func instanceEnvPrefix(name string) string {
+	return "VMANOMALY_INSTANCE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+// parseInstances parses named instances from VMANOMALY_INSTANCES (comma-separated name=endpoint pairs).
+// Credentials of an instance are read from VMANOMALY_INSTANCE_<NAME>_BEARER_TOKEN and VMANOMALY_INSTANCE_<NAME>_HEADERS,
+// where <NAME> is the upper-cased instance name with dashes replaced by underscores.
+func parseInstances(src source) ([]Instance, error) {
+	var instances []Instance
+	for _, item := range strings.Split(src.get("VMANOMALY_INSTANCES"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, endpoint, ok := strings.Cut(item, "=")
+		name, endpoint = strings.TrimSpace(name), strings.TrimSpace(endpoint)
+		if !ok || name == "" || endpoint == "" {
+			return nil, fmt.Errorf("VMANOMALY_INSTANCES must contain name=endpoint pairs, got %q", item)
+		}
+		if !instanceNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid instance name %q in VMANOMALY_INSTANCES: only letters, digits, '_' and '-' are allowed", name)
+		}
+		prefix := instanceEnvPrefix(name)
+		instances = append(instances, Instance{
+			Name:          name,
+			Endpoint:      endpoint,
+			BearerToken:   src.get(prefix + "BEARER_TOKEN"),
+			CustomHeaders: parseCustomHeaders(src.get(prefix + "HEADERS")),
+		})
+	}
+	return instances, nil
+}
+
+// parseAuthTokens parses static bearer tokens from MCP_AUTH_TOKENS (comma-separated principal=token pairs)
+// and returns principal names keyed by token
+func parseAuthTokens(tokensEnv string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, item := range strings.Split(tokensEnv, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		principal, token, ok := strings.Cut(item, "=")
+		principal, token = strings.TrimSpace(principal), strings.TrimSpace(token)
+		if !ok || principal == "" || token == "" {
+			return nil, fmt.Errorf("MCP_AUTH_TOKENS must contain principal=token pairs")
+		}
+		if _, ok := tokens[token]; ok {
+			return nil, fmt.Errorf("duplicate token of principal %q in MCP_AUTH_TOKENS", principal)
+		}
+		tokens[token] = principal
+	}
+	return tokens, nil
+}
+
+// parsePositiveDuration parses a positive duration from the setting, returning def if it is unset
+func (s source) parsePositiveDuration(name string, def time.Duration) (time.Duration, error) {
+	v := s.get(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive", name)
+	}
+	return d, nil
+}
+
+// parseNonNegativeDuration parses a non-negative duration from the setting, returning def if it is unset
+func (s source) parseNonNegativeDuration(name string, def time.Duration) (time.Duration, error) {
+	v := s.get(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must be non-negative", name)
+	}
+	return d, nil
+}
+
+// parseNonNegativeInt parses a non-negative integer from the setting, returning def if it is unset
+func (s source) parseNonNegativeInt(name string, def int) (int, error) {
+	v := s.get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s must be non-negative", name)
+	}
+	return n, nil
+}
+
+// defaultInstanceName is the name of the instance configured with VMANOMALY_ENDPOINT
+const defaultInstanceName = "default"
+
+// InitConfig reads config from env variables
 func InitConfig() (*Config, error) {
+	return Load("")
+}
+
+// Load reads config from the YAML or JSON file at path, if it is not empty, and env variables.
+// Env variables override settings from the file.
+func Load(path string) (*Config, error) {
+	return LoadWithOverrides(path, nil)
+}
+
+// LoadWithOverrides is like Load, but values of overrides keyed by env variable name
+// (e.g. set with command-line flags) take precedence over env variables.
+func LoadWithOverrides(path string, overrides map[string]string) (*Config, error) {
+	fc := &fileConfig{}
+	if path != "" {
+		var err error
+		fc, err = readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	src := source{overrides: overrides, file: fc.env()}
+	toolTimeouts, err := fc.toolTimeouts()
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse disabled tools
-	disabledTools := os.Getenv("MCP_DISABLED_TOOLS")
+	disabledTools := src.get("MCP_DISABLED_TOOLS")
 	disabledToolsMap := make(map[string]bool)
 	if disabledTools != "" {
 		for _, tool := range strings.Split(disabledTools, ",") {
@@ -59,7 +278,7 @@ func InitConfig() (*Config, error) {
 
 	// Parse heartbeat interval
 	heartbeatInterval := 30 * time.Second
-	heartbeatIntervalStr := os.Getenv("MCP_HEARTBEAT_INTERVAL")
+	heartbeatIntervalStr := src.get("MCP_HEARTBEAT_INTERVAL")
 	if heartbeatIntervalStr != "" {
 		interval, err := time.ParseDuration(heartbeatIntervalStr)
 		if err != nil {
@@ -73,7 +292,7 @@ func InitConfig() (*Config, error) {
 
 	// Parse disable resources
 	disableResources := false
-	disableResourcesStr := os.Getenv("MCP_DISABLE_RESOURCES")
+	disableResourcesStr := src.get("MCP_DISABLE_RESOURCES")
 	if disableResourcesStr != "" {
 		var err error
 		disableResources, err = strconv.ParseBool(disableResourcesStr)
@@ -82,24 +301,249 @@ func InitConfig() (*Config, error) {
 		}
 	}
 
-	customHeadersMap := parseCustomHeaders(os.Getenv("VMANOMALY_HEADERS"))
+	// Parse read-only mode
+	readOnly := false
+	readOnlyStr := src.get("MCP_READ_ONLY")
+	if readOnlyStr != "" {
+		var err error
+		readOnly, err = strconv.ParseBool(readOnlyStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse MCP_READ_ONLY: %w", err)
+		}
+	}
+
+	// Parse fake backend
+	fakeBackend := false
+	fakeBackendStr := src.get("VMANOMALY_FAKE_BACKEND")
+	if fakeBackendStr != "" {
+		var err error
+		fakeBackend, err = strconv.ParseBool(fakeBackendStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse VMANOMALY_FAKE_BACKEND: %w", err)
+		}
+	}
+
+	// Parse upstream timeout, retries and circuit breaker
+	timeout, err := src.parsePositiveDuration("VMANOMALY_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	maxRetries, err := src.parseNonNegativeInt("VMANOMALY_MAX_RETRIES", 2)
+	if err != nil {
+		return nil, err
+	}
+	retryBackoff, err := src.parsePositiveDuration("VMANOMALY_RETRY_BACKOFF", 500*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	retryMaxBackoff, err := src.parsePositiveDuration("VMANOMALY_RETRY_MAX_BACKOFF", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if retryMaxBackoff < retryBackoff {
+		return nil, fmt.Errorf("VMANOMALY_RETRY_MAX_BACKOFF must not be less than VMANOMALY_RETRY_BACKOFF")
+	}
+	circuitBreakerThreshold, err := src.parseNonNegativeInt("VMANOMALY_CIRCUIT_BREAKER_THRESHOLD", 5)
+	if err != nil {
+		return nil, err
+	}
+	circuitBreakerTimeout, err := src.parsePositiveDuration("VMANOMALY_CIRCUIT_BREAKER_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cacheTTL, err := src.parseNonNegativeDuration("VMANOMALY_CACHE_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse readiness probes
+	readinessProbeInterval, err := src.parsePositiveDuration("MCP_READINESS_PROBE_INTERVAL", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	readinessFailureThreshold, err := src.parseNonNegativeInt("MCP_READINESS_FAILURE_THRESHOLD", 3)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse TLS insecure skip verify
+	tlsInsecureSkipVerify := false
+	tlsInsecureSkipVerifyStr := src.get("VMANOMALY_TLS_INSECURE_SKIP_VERIFY")
+	if tlsInsecureSkipVerifyStr != "" {
+		tlsInsecureSkipVerify, err = strconv.ParseBool(tlsInsecureSkipVerifyStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse VMANOMALY_TLS_INSECURE_SKIP_VERIFY: %w", err)
+		}
+	}
+
+	customHeadersMap := parseCustomHeaders(src.get("VMANOMALY_HEADERS"))
+
+	authTokens, err := parseAuthTokens(src.get("MCP_AUTH_TOKENS"))
+	if err != nil {
+		return nil, err
+	}
+	if len(authTokens) == 0 {
+		// Tokens of the config file are used unless overridden by env variables or flags
+		authTokens, err = fc.authTokens()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse audit log
+	auditLogMaxSizeMB, err := src.parseNonNegativeInt("MCP_AUDIT_LOG_MAX_SIZE_MB", 100)
+	if err != nil {
+		return nil, err
+	}
+	auditLogMaxBackups, err := src.parseNonNegativeInt("MCP_AUDIT_LOG_MAX_BACKUPS", 5)
+	if err != nil {
+		return nil, err
+	}
+	var auditRedactKeys []string
+	for _, key := range strings.Split(src.get("MCP_AUDIT_REDACT_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			auditRedactKeys = append(auditRedactKeys, key)
+		}
+	}
+
+	// Parse tracing
+	tracingSampleRatio := 1.0
+	if v := src.get("MCP_TRACING_SAMPLE_RATIO"); v != "" {
+		tracingSampleRatio, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse MCP_TRACING_SAMPLE_RATIO: %w", err)
+		}
+		if tracingSampleRatio < 0 || tracingSampleRatio > 1 {
+			return nil, fmt.Errorf("MCP_TRACING_SAMPLE_RATIO must be between 0 and 1")
+		}
+	}
+	tracingEndpoint := strings.TrimSpace(src.get("MCP_TRACING_ENDPOINT"))
+	if tracingEndpoint != "" {
+		u, err := url.Parse(tracingEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("MCP_TRACING_ENDPOINT must be an http or https URL, got %q", tracingEndpoint)
+		}
+	}
+
+	// Parse instances
+	var instances []Instance
+	if endpoint := src.get("VMANOMALY_ENDPOINT"); endpoint != "" {
+		instances = append(instances, Instance{
+			Name:          defaultInstanceName,
+			Endpoint:      endpoint,
+			BearerToken:   src.get("VMANOMALY_BEARER_TOKEN"),
+			CustomHeaders: customHeadersMap,
+		})
+	}
+	namedInstances, err := parseInstances(src)
+	if err != nil {
+		return nil, err
+	}
+	instances = append(instances, namedInstances...)
+	if len(instances) == 0 {
+		// Backends of the config file are used unless overridden by env variables or flags
+		instances, err = fc.instances(src, fakeBackend)
+		if err != nil {
+			return nil, err
+		}
+	}
+	seenInstances := make(map[string]bool, len(instances))
+	for _, inst := range instances {
+		if seenInstances[inst.Name] {
+			return nil, fmt.Errorf("duplicate vmanomaly instance %q, note that %q is reserved for VMANOMALY_ENDPOINT", inst.Name, defaultInstanceName)
+		}
+		seenInstances[inst.Name] = true
+	}
 
 	result := &Config{
-		vmanomalyEndpoint: os.Getenv("VMANOMALY_ENDPOINT"),
-		serverMode:        strings.ToLower(os.Getenv("MCP_SERVER_MODE")),
-		listenAddr:        os.Getenv("MCP_LISTEN_ADDR"),
+		vmanomalyEndpoint: src.get("VMANOMALY_ENDPOINT"),
+		serverMode:        strings.ToLower(src.get("MCP_SERVER_MODE")),
+		listenAddr:        src.get("MCP_LISTEN_ADDR"),
 		disabledTools:     disabledToolsMap,
 		heartbeatInterval: heartbeatInterval,
 		disableResources:  disableResources,
-		logLevel:          strings.ToLower(os.Getenv("MCP_LOG_LEVEL")),
-		logFile:           os.Getenv("MCP_LOG_FILE"),
-		bearerToken:       os.Getenv("VMANOMALY_BEARER_TOKEN"),
+		readOnly:          readOnly,
+		logLevel:          strings.ToLower(src.get("MCP_LOG_LEVEL")),
+		logFile:           src.get("MCP_LOG_FILE"),
+		bearerToken:       src.get("VMANOMALY_BEARER_TOKEN"),
 		customHeaders:     customHeadersMap,
+		fakeBackend:       fakeBackend,
+
+		timeout:                 timeout,
+		maxRetries:              maxRetries,
+		retryBackoff:            retryBackoff,
+		retryMaxBackoff:         retryMaxBackoff,
+		circuitBreakerThreshold: circuitBreakerThreshold,
+		circuitBreakerTimeout:   circuitBreakerTimeout,
+		cacheTTL:                cacheTTL,
+
+		readinessProbeInterval:    readinessProbeInterval,
+		readinessFailureThreshold: readinessFailureThreshold,
+
+		tlsCAFile:             src.get("VMANOMALY_TLS_CA_FILE"),
+		tlsCertFile:           src.get("VMANOMALY_TLS_CERT_FILE"),
+		tlsKeyFile:            src.get("VMANOMALY_TLS_KEY_FILE"),
+		tlsServerName:         src.get("VMANOMALY_TLS_SERVER_NAME"),
+		tlsInsecureSkipVerify: tlsInsecureSkipVerify,
+
+		forwardAuthHeader: http.CanonicalHeaderKey(strings.TrimSpace(src.get("MCP_FORWARD_AUTH_HEADER"))),
+
+		authTokens:            authTokens,
+		authHtpasswdFile:      src.get("MCP_AUTH_HTPASSWD_FILE"),
+		authJWKSFile:          src.get("MCP_AUTH_JWKS_FILE"),
+		authJWTAudience:       src.get("MCP_AUTH_JWT_AUDIENCE"),
+		authJWTIssuer:         src.get("MCP_AUTH_JWT_ISSUER"),
+		authJWTPrincipalClaim: src.get("MCP_AUTH_JWT_PRINCIPAL_CLAIM"),
+		authDefaultRole:       src.get("MCP_AUTH_DEFAULT_ROLE"),
+		roles:                 fc.roles(),
+
+		auditLog:           strings.TrimSpace(src.get("MCP_AUDIT_LOG")),
+		auditLogMaxSizeMB:  auditLogMaxSizeMB,
+		auditLogMaxBackups: auditLogMaxBackups,
+		auditRedactKeys:    auditRedactKeys,
+		auditRedactValues:  fc.Audit.RedactValues,
+
+		tracingEndpoint:    tracingEndpoint,
+		tracingSampleRatio: tracingSampleRatio,
+
+		instances:       instances,
+		defaultInstance: strings.TrimSpace(src.get("VMANOMALY_DEFAULT_INSTANCE")),
+
+		configFile:   path,
+		toolTimeouts: toolTimeouts,
 	}
 
 	// Validate required config
-	if result.vmanomalyEndpoint == "" {
-		return nil, fmt.Errorf("VMANOMALY_ENDPOINT is required")
+	if len(result.instances) == 0 && !result.fakeBackend {
+		return nil, fmt.Errorf("VMANOMALY_ENDPOINT, VMANOMALY_INSTANCES or backends in the config file are required")
+	}
+	if len(result.instances) == 0 {
+		// Fake backend replaces endpoints, so it only needs instance names
+		result.instances = []Instance{{Name: defaultInstanceName}}
+	}
+
+	// Validate default instance
+	if result.defaultInstance == "" {
+		result.defaultInstance = result.instances[0].Name
+	}
+	isConfigured := func(inst Instance) bool { return inst.Name == result.defaultInstance }
+	if !slices.ContainsFunc(result.instances, isConfigured) {
+		return nil, fmt.Errorf("VMANOMALY_DEFAULT_INSTANCE %q is not configured", result.defaultInstance)
+	}
+
+	// Validate TLS client certificate
+	if (result.tlsCertFile == "") != (result.tlsKeyFile == "") {
+		return nil, fmt.Errorf("VMANOMALY_TLS_CERT_FILE and VMANOMALY_TLS_KEY_FILE must be set together")
+	}
+
+	// Validate JWT auth
+	if result.authJWKSFile != "" && (result.authJWTAudience == "" || result.authJWTIssuer == "") {
+		return nil, fmt.Errorf("MCP_AUTH_JWT_AUDIENCE and MCP_AUTH_JWT_ISSUER are required with MCP_AUTH_JWKS_FILE")
+	}
+
+	// The Authorization header of callers carries their inbound credentials, which must not reach vmanomaly
+	if result.forwardAuthHeader == "Authorization" && result.IsAuthEnabled() {
+		return nil, fmt.Errorf("MCP_FORWARD_AUTH_HEADER can't be Authorization with inbound auth enabled, since it would forward MCP credentials to vmanomaly; use another header, e.g. X-Vmanomaly-Authorization")
 	}
 
 	// Validate server mode
@@ -123,6 +567,11 @@ func InitConfig() (*Config, error) {
 		result.logLevel = "info"
 	}
 
+	// stdout carries MCP messages in stdio mode
+	if result.auditLog == "stdout" && result.IsStdio() {
+		return nil, fmt.Errorf("MCP_AUDIT_LOG can't be stdout in stdio mode")
+	}
+
 	return result, nil
 }
 
@@ -162,6 +611,11 @@ func (c *Config) IsResourcesDisabled() bool {
 	return c.disableResources
 }
 
+// IsReadOnly reports whether only tools annotated as read-only can be listed and called
+func (c *Config) IsReadOnly() bool {
+	return c.readOnly
+}
+
 func (c *Config) HeartbeatInterval() time.Duration {
 	return c.heartbeatInterval
 }
@@ -181,3 +635,171 @@ func (c *Config) BearerToken() string {
 func (c *Config) CustomHeaders() map[string]string {
 	return c.customHeaders
 }
+
+func (c *Config) FakeBackend() bool {
+	return c.fakeBackend
+}
+
+func (c *Config) Timeout() time.Duration {
+	return c.timeout
+}
+
+func (c *Config) MaxRetries() int {
+	return c.maxRetries
+}
+
+func (c *Config) RetryBackoff() time.Duration {
+	return c.retryBackoff
+}
+
+func (c *Config) RetryMaxBackoff() time.Duration {
+	return c.retryMaxBackoff
+}
+
+// CircuitBreakerThreshold returns the number of consecutive upstream failures that open the circuit breaker,
+// 0 means the circuit breaker is disabled
+func (c *Config) CircuitBreakerThreshold() int {
+	return c.circuitBreakerThreshold
+}
+
+func (c *Config) CircuitBreakerTimeout() time.Duration {
+	return c.circuitBreakerTimeout
+}
+
+// CacheTTL returns how long model list, model schema and build info responses are cached, 0 if caching is disabled
+func (c *Config) CacheTTL() time.Duration {
+	return c.cacheTTL
+}
+
+// ReadinessProbeInterval returns the interval of vmanomaly health probes
+func (c *Config) ReadinessProbeInterval() time.Duration {
+	return c.readinessProbeInterval
+}
+
+// ReadinessFailureThreshold returns the number of consecutive failed probes after which an instance is unhealthy,
+// 0 means probes are disabled and readiness doesn't depend on vmanomaly
+func (c *Config) ReadinessFailureThreshold() int {
+	return c.readinessFailureThreshold
+}
+
+func (c *Config) TLSCAFile() string {
+	return c.tlsCAFile
+}
+
+func (c *Config) TLSCertFile() string {
+	return c.tlsCertFile
+}
+
+func (c *Config) TLSKeyFile() string {
+	return c.tlsKeyFile
+}
+
+func (c *Config) TLSServerName() string {
+	return c.tlsServerName
+}
+
+func (c *Config) TLSInsecureSkipVerify() bool {
+	return c.tlsInsecureSkipVerify
+}
+
+// ForwardAuthHeader returns the name of the incoming HTTP header whose value is forwarded
+// to vmanomaly as Authorization header, empty if forwarding is disabled
+func (c *Config) ForwardAuthHeader() string {
+	return c.forwardAuthHeader
+}
+
+// AuthTokens returns principal names of static bearer tokens keyed by token
+func (c *Config) AuthTokens() map[string]string {
+	return c.authTokens
+}
+
+func (c *Config) AuthHtpasswdFile() string {
+	return c.authHtpasswdFile
+}
+
+func (c *Config) AuthJWKSFile() string {
+	return c.authJWKSFile
+}
+
+func (c *Config) AuthJWTAudience() string {
+	return c.authJWTAudience
+}
+
+func (c *Config) AuthJWTIssuer() string {
+	return c.authJWTIssuer
+}
+
+// AuthJWTPrincipalClaim returns the JWT claim used as principal name, empty means "sub"
+func (c *Config) AuthJWTPrincipalClaim() string {
+	return c.authJWTPrincipalClaim
+}
+
+// AuthDefaultRole returns the role of principals without roles, empty if they can't call tools
+func (c *Config) AuthDefaultRole() string {
+	return c.authDefaultRole
+}
+
+// Roles returns roles of the config file. Tools aren't restricted by roles if there are none.
+func (c *Config) Roles() []Role {
+	return c.roles
+}
+
+// IsAuthEnabled reports whether callers of sse and http transports must authenticate
+func (c *Config) IsAuthEnabled() bool {
+	return len(c.authTokens) > 0 || c.authHtpasswdFile != "" || c.authJWKSFile != ""
+}
+
+// AuditLog returns the audit log file path or "stdout", empty if the audit log is disabled
+func (c *Config) AuditLog() string {
+	return c.auditLog
+}
+
+// AuditLogMaxSizeMB returns the size in megabytes at which the audit log file is rotated, 0 if it isn't rotated
+func (c *Config) AuditLogMaxSizeMB() int {
+	return c.auditLogMaxSizeMB
+}
+
+func (c *Config) AuditLogMaxBackups() int {
+	return c.auditLogMaxBackups
+}
+
+// AuditRedactKeys returns patterns of tool argument names redacted in the audit log in addition to built-in ones
+func (c *Config) AuditRedactKeys() []string {
+	return c.auditRedactKeys
+}
+
+// AuditRedactValues returns regexps of tool argument value parts redacted in the audit log
+func (c *Config) AuditRedactValues() []string {
+	return c.auditRedactValues
+}
+
+// TracingEndpoint returns the OTLP/HTTP endpoint receiving traces, empty if tracing is disabled
+func (c *Config) TracingEndpoint() string {
+	return c.tracingEndpoint
+}
+
+// TracingSampleRatio returns the fraction of traces started by the server that are exported
+func (c *Config) TracingSampleRatio() float64 {
+	return c.tracingSampleRatio
+}
+
+// Instances returns configured vmanomaly instances: the one from VMANOMALY_ENDPOINT named "default"
+// followed by ones from VMANOMALY_INSTANCES, or backends of the config file if neither is set
+func (c *Config) Instances() []Instance {
+	return c.instances
+}
+
+// DefaultInstance returns the name of the instance used when tools don't specify one
+func (c *Config) DefaultInstance() string {
+	return c.defaultInstance
+}
+
+// ConfigFile returns the path of the config file, empty if config is read from env variables only
+func (c *Config) ConfigFile() string {
+	return c.configFile
+}
+
+// ToolTimeout returns the call timeout of the tool set in the config file, 0 if there is none
+func (c *Config) ToolTimeout(toolName string) time.Duration {
+	return c.toolTimeouts[toolName]
+}
@@ -2,10 +2,55 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
 
+var resilienceEnvs = []string{
+	"VMANOMALY_TIMEOUT",
+	"VMANOMALY_MAX_RETRIES",
+	"VMANOMALY_RETRY_BACKOFF",
+	"VMANOMALY_RETRY_MAX_BACKOFF",
+	"VMANOMALY_CIRCUIT_BREAKER_THRESHOLD",
+	"VMANOMALY_CIRCUIT_BREAKER_TIMEOUT",
+}
+
+var tlsEnvs = []string{
+	"VMANOMALY_TLS_CA_FILE",
+	"VMANOMALY_TLS_CERT_FILE",
+	"VMANOMALY_TLS_KEY_FILE",
+	"VMANOMALY_TLS_SERVER_NAME",
+	"VMANOMALY_TLS_INSECURE_SKIP_VERIFY",
+}
+
+var authEnvs = []string{
+	"MCP_AUTH_TOKENS",
+	"MCP_AUTH_HTPASSWD_FILE",
+	"MCP_AUTH_JWKS_FILE",
+	"MCP_AUTH_JWT_AUDIENCE",
+	"MCP_AUTH_JWT_ISSUER",
+	"MCP_AUTH_JWT_PRINCIPAL_CLAIM",
+}
+
+var auditEnvs = []string{
+	"MCP_AUDIT_LOG",
+	"MCP_AUDIT_LOG_MAX_SIZE_MB",
+	"MCP_AUDIT_LOG_MAX_BACKUPS",
+	"MCP_AUDIT_REDACT_KEYS",
+}
+
+var instanceEnvs = []string{
+	"MCP_FORWARD_AUTH_HEADER",
+	"VMANOMALY_INSTANCES",
+	"VMANOMALY_DEFAULT_INSTANCE",
+	"VMANOMALY_INSTANCE_STAGING_EU_BEARER_TOKEN",
+	"VMANOMALY_INSTANCE_STAGING_EU_HEADERS",
+}
+
 func TestInitConfig(t *testing.T) {
 	// Save original environment variables
 	originalEndpoint := os.Getenv("VMANOMALY_ENDPOINT")
@@ -18,6 +63,11 @@ func TestInitConfig(t *testing.T) {
 	originalDisabledTools := os.Getenv("MCP_DISABLED_TOOLS")
 	originalHeartbeatInterval := os.Getenv("MCP_HEARTBEAT_INTERVAL")
 	originalDisableResources := os.Getenv("MCP_DISABLE_RESOURCES")
+	originalFakeBackend := os.Getenv("VMANOMALY_FAKE_BACKEND")
+	originalResilience := make(map[string]string)
+	for _, name := range slices.Concat(resilienceEnvs, tlsEnvs, instanceEnvs) {
+		originalResilience[name] = os.Getenv(name)
+	}
 
 	// Restore environment variables after test
 	defer func() {
@@ -31,6 +81,10 @@ func TestInitConfig(t *testing.T) {
 		os.Setenv("MCP_DISABLED_TOOLS", originalDisabledTools)
 		os.Setenv("MCP_HEARTBEAT_INTERVAL", originalHeartbeatInterval)
 		os.Setenv("MCP_DISABLE_RESOURCES", originalDisableResources)
+		os.Setenv("VMANOMALY_FAKE_BACKEND", originalFakeBackend)
+		for name, value := range originalResilience {
+			os.Setenv(name, value)
+		}
 	}()
 
 	// Test case 1: Valid configuration
@@ -386,6 +440,34 @@ func TestInitConfig(t *testing.T) {
 		}
 	})
 
+	// Test case 13a: Read-only mode
+	t.Run("Read-only mode", func(t *testing.T) {
+		os.Setenv("VMANOMALY_ENDPOINT", "http://localhost:8490")
+		defer os.Setenv("MCP_READ_ONLY", "")
+
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.IsReadOnly() {
+			t.Error("Expected read-only mode to be disabled by default")
+		}
+
+		os.Setenv("MCP_READ_ONLY", "true")
+		cfg, err = InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !cfg.IsReadOnly() {
+			t.Error("Expected read-only mode to be enabled")
+		}
+
+		os.Setenv("MCP_READ_ONLY", "sometimes")
+		if _, err := InitConfig(); err == nil {
+			t.Error("Expected error for invalid MCP_READ_ONLY, got nil")
+		}
+	})
+
 	// Test case 14: Server mode variations
 	t.Run("Server mode variations", func(t *testing.T) {
 		// Reset environment variables
@@ -412,4 +494,747 @@ func TestInitConfig(t *testing.T) {
 			t.Error("Expected IsHTTP() to be true")
 		}
 	})
+
+	// Test case 15: Fake backend
+	t.Run("Fake backend", func(t *testing.T) {
+		// Endpoint is not required with fake backend
+		os.Setenv("VMANOMALY_ENDPOINT", "")
+		os.Setenv("VMANOMALY_FAKE_BACKEND", "true")
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !cfg.FakeBackend() {
+			t.Error("Expected FakeBackend() to be true")
+		}
+
+		os.Setenv("VMANOMALY_FAKE_BACKEND", "maybe")
+		if _, err := InitConfig(); err == nil {
+			t.Fatal("Expected error for invalid VMANOMALY_FAKE_BACKEND, got nil")
+		}
+		os.Setenv("VMANOMALY_FAKE_BACKEND", "")
+	})
+
+	// Test case 16: Timeout, retries and circuit breaker
+	t.Run("Timeout, retries and circuit breaker", func(t *testing.T) {
+		os.Setenv("VMANOMALY_ENDPOINT", "http://localhost:8490")
+		for _, name := range resilienceEnvs {
+			os.Setenv(name, "")
+		}
+
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.Timeout() != 30*time.Second {
+			t.Errorf("Expected default timeout 30s, got: %v", cfg.Timeout())
+		}
+		if cfg.MaxRetries() != 2 {
+			t.Errorf("Expected default max retries 2, got: %d", cfg.MaxRetries())
+		}
+		if cfg.RetryBackoff() != 500*time.Millisecond || cfg.RetryMaxBackoff() != 10*time.Second {
+			t.Errorf("Expected default backoff 500ms..10s, got: %v..%v", cfg.RetryBackoff(), cfg.RetryMaxBackoff())
+		}
+		if cfg.CircuitBreakerThreshold() != 5 || cfg.CircuitBreakerTimeout() != 30*time.Second {
+			t.Errorf("Expected default circuit breaker 5/30s, got: %d/%v", cfg.CircuitBreakerThreshold(), cfg.CircuitBreakerTimeout())
+		}
+
+		os.Setenv("VMANOMALY_TIMEOUT", "5s")
+		os.Setenv("VMANOMALY_MAX_RETRIES", "0")
+		os.Setenv("VMANOMALY_CIRCUIT_BREAKER_THRESHOLD", "0")
+		cfg, err = InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.Timeout() != 5*time.Second || cfg.MaxRetries() != 0 || cfg.CircuitBreakerThreshold() != 0 {
+			t.Errorf("Unexpected overrides: timeout=%v retries=%d threshold=%d", cfg.Timeout(), cfg.MaxRetries(), cfg.CircuitBreakerThreshold())
+		}
+
+		invalid := map[string]string{
+			"VMANOMALY_TIMEOUT":                   "0s",
+			"VMANOMALY_MAX_RETRIES":               "-1",
+			"VMANOMALY_RETRY_BACKOFF":             "fast",
+			"VMANOMALY_RETRY_MAX_BACKOFF":         "100ms",
+			"VMANOMALY_CIRCUIT_BREAKER_THRESHOLD": "many",
+			"VMANOMALY_CIRCUIT_BREAKER_TIMEOUT":   "-1s",
+		}
+		for name, value := range invalid {
+			for _, n := range resilienceEnvs {
+				os.Setenv(n, "")
+			}
+			os.Setenv(name, value)
+			if _, err := InitConfig(); err == nil {
+				t.Errorf("Expected error for %s=%s, got nil", name, value)
+			}
+		}
+		for _, name := range resilienceEnvs {
+			os.Setenv(name, "")
+		}
+	})
+
+	// Test case 17: TLS
+	t.Run("TLS", func(t *testing.T) {
+		os.Setenv("VMANOMALY_ENDPOINT", "https://vmanomaly:8490")
+		os.Setenv("VMANOMALY_TLS_CA_FILE", "/etc/ssl/ca.pem")
+		os.Setenv("VMANOMALY_TLS_CERT_FILE", "/etc/ssl/client.pem")
+		os.Setenv("VMANOMALY_TLS_KEY_FILE", "/etc/ssl/client.key")
+		os.Setenv("VMANOMALY_TLS_SERVER_NAME", "vmanomaly.internal")
+		os.Setenv("VMANOMALY_TLS_INSECURE_SKIP_VERIFY", "true")
+
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.TLSCAFile() != "/etc/ssl/ca.pem" || cfg.TLSCertFile() != "/etc/ssl/client.pem" || cfg.TLSKeyFile() != "/etc/ssl/client.key" {
+			t.Errorf("Unexpected TLS files: %s, %s, %s", cfg.TLSCAFile(), cfg.TLSCertFile(), cfg.TLSKeyFile())
+		}
+		if cfg.TLSServerName() != "vmanomaly.internal" {
+			t.Errorf("Expected TLS server name 'vmanomaly.internal', got: %s", cfg.TLSServerName())
+		}
+		if !cfg.TLSInsecureSkipVerify() {
+			t.Error("Expected TLSInsecureSkipVerify() to be true")
+		}
+
+		os.Setenv("VMANOMALY_TLS_INSECURE_SKIP_VERIFY", "sometimes")
+		if _, err := InitConfig(); err == nil {
+			t.Error("Expected error for invalid VMANOMALY_TLS_INSECURE_SKIP_VERIFY, got nil")
+		}
+		os.Setenv("VMANOMALY_TLS_INSECURE_SKIP_VERIFY", "")
+
+		os.Setenv("VMANOMALY_TLS_KEY_FILE", "")
+		if _, err := InitConfig(); err == nil {
+			t.Error("Expected error for client certificate without key, got nil")
+		}
+
+		for _, name := range tlsEnvs {
+			os.Setenv(name, "")
+		}
+	})
+
+	// Test case 18: Forward auth header
+	t.Run("Forward auth header", func(t *testing.T) {
+		os.Setenv("VMANOMALY_ENDPOINT", "http://localhost:8490")
+		os.Setenv("MCP_FORWARD_AUTH_HEADER", "")
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.ForwardAuthHeader() != "" {
+			t.Errorf("Expected forwarding to be disabled by default, got: %s", cfg.ForwardAuthHeader())
+		}
+
+		os.Setenv("MCP_FORWARD_AUTH_HEADER", "x-vmanomaly-token")
+		cfg, err = InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.ForwardAuthHeader() != "X-Vmanomaly-Token" {
+			t.Errorf("Expected canonical header 'X-Vmanomaly-Token', got: %s", cfg.ForwardAuthHeader())
+		}
+
+		// Credentials of inbound auth aren't forwarded to vmanomaly
+		os.Setenv("MCP_AUTH_TOKENS", "ci=token-1")
+		if _, err := InitConfig(); err != nil {
+			t.Errorf("Expected no error forwarding another header with inbound auth, got: %v", err)
+		}
+		os.Setenv("MCP_FORWARD_AUTH_HEADER", "authorization")
+		if _, err := InitConfig(); err == nil || !strings.Contains(err.Error(), "inbound auth") {
+			t.Errorf("Expected error forwarding Authorization with inbound auth, got: %v", err)
+		}
+		os.Setenv("MCP_AUTH_TOKENS", "")
+		os.Setenv("MCP_FORWARD_AUTH_HEADER", "")
+	})
+
+	// Test case 19: Multiple instances
+	t.Run("Multiple instances", func(t *testing.T) {
+		os.Setenv("VMANOMALY_ENDPOINT", "http://localhost:8490")
+		os.Setenv("VMANOMALY_BEARER_TOKEN", "default-token")
+		os.Setenv("VMANOMALY_HEADERS", "")
+		os.Setenv("VMANOMALY_INSTANCES", "prod=http://prod:8490, staging-eu=http://staging:8490")
+		os.Setenv("VMANOMALY_INSTANCE_STAGING_EU_BEARER_TOKEN", "staging-token")
+		os.Setenv("VMANOMALY_INSTANCE_STAGING_EU_HEADERS", "X-Scope=eu")
+		defer func() {
+			os.Setenv("VMANOMALY_BEARER_TOKEN", "")
+			for _, name := range instanceEnvs {
+				os.Setenv(name, "")
+			}
+		}()
+
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		instances := cfg.Instances()
+		if len(instances) != 3 {
+			t.Fatalf("Expected 3 instances, got: %+v", instances)
+		}
+		if instances[0].Name != "default" || instances[0].Endpoint != "http://localhost:8490" || instances[0].BearerToken != "default-token" {
+			t.Errorf("Unexpected default instance: %+v", instances[0])
+		}
+		if instances[1].Name != "prod" || instances[1].Endpoint != "http://prod:8490" || instances[1].BearerToken != "" {
+			t.Errorf("Unexpected prod instance: %+v", instances[1])
+		}
+		if instances[2].Name != "staging-eu" || instances[2].BearerToken != "staging-token" || instances[2].CustomHeaders["X-Scope"] != "eu" {
+			t.Errorf("Unexpected staging-eu instance: %+v", instances[2])
+		}
+		if cfg.DefaultInstance() != "default" {
+			t.Errorf("Expected default instance 'default', got: %s", cfg.DefaultInstance())
+		}
+
+		// Endpoint is optional when instances are configured
+		os.Setenv("VMANOMALY_ENDPOINT", "")
+		os.Setenv("VMANOMALY_DEFAULT_INSTANCE", "staging-eu")
+		cfg, err = InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(cfg.Instances()) != 2 || cfg.DefaultInstance() != "staging-eu" {
+			t.Errorf("Unexpected instances %+v with default %s", cfg.Instances(), cfg.DefaultInstance())
+		}
+
+		invalid := []struct {
+			instances       string
+			defaultInstance string
+		}{
+			{instances: "prod"},
+			{instances: "prod=http://a,prod=http://b"},
+			{instances: "prod/eu=http://a"},
+			{instances: "prod=http://a", defaultInstance: "dev"},
+		}
+		for _, tt := range invalid {
+			os.Setenv("VMANOMALY_INSTANCES", tt.instances)
+			os.Setenv("VMANOMALY_DEFAULT_INSTANCE", tt.defaultInstance)
+			if _, err := InitConfig(); err == nil {
+				t.Errorf("Expected error for VMANOMALY_INSTANCES=%q VMANOMALY_DEFAULT_INSTANCE=%q, got nil", tt.instances, tt.defaultInstance)
+			}
+		}
+	})
+
+	// Test case 20: Config file
+	t.Run("Config file", func(t *testing.T) {
+		for _, name := range slices.Concat(resilienceEnvs, tlsEnvs, instanceEnvs) {
+			os.Setenv(name, "")
+		}
+		os.Setenv("VMANOMALY_ENDPOINT", "")
+		os.Setenv("MCP_SERVER_MODE", "")
+		os.Setenv("MCP_LISTEN_ADDR", "")
+		os.Setenv("MCP_DISABLED_TOOLS", "")
+		os.Setenv("VMANOMALY_FAKE_BACKEND", "")
+		defer func() {
+			os.Setenv("VMANOMALY_TIMEOUT", "")
+			os.Setenv("VMANOMALY_INSTANCE_PROD_BEARER_TOKEN", "")
+		}()
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile := func(content string) {
+			t.Helper()
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+		}
+		writeFile(`
+server:
+  mode: http
+  listen_addr: localhost:9091
+vmanomaly:
+  timeout: 10s
+  max_retries: 3
+  default_instance: staging
+  circuit_breaker:
+    threshold: 0
+backends:
+  - name: prod
+    endpoint: http://prod:8490
+    bearer_token: prod-token
+  - name: staging
+    endpoint: http://staging:8490
+    headers:
+      X-Scope-OrgID: "team-a,team-b"
+tools:
+  vmanomaly_cancel_task:
+    disabled: true
+  vmanomaly_run_detection:
+    timeout: 10m
+`)
+
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !cfg.IsHTTP() || cfg.ListenAddr() != "localhost:9091" || cfg.ConfigFile() != path {
+			t.Errorf("Unexpected server settings: mode=%s addr=%s file=%s", cfg.ServerMode(), cfg.ListenAddr(), cfg.ConfigFile())
+		}
+		if cfg.Timeout() != 10*time.Second || cfg.MaxRetries() != 3 || cfg.CircuitBreakerThreshold() != 0 {
+			t.Errorf("Unexpected vmanomaly settings: timeout=%v retries=%d threshold=%d", cfg.Timeout(), cfg.MaxRetries(), cfg.CircuitBreakerThreshold())
+		}
+		instances := cfg.Instances()
+		if len(instances) != 2 || cfg.DefaultInstance() != "staging" {
+			t.Fatalf("Unexpected instances %+v with default %s", instances, cfg.DefaultInstance())
+		}
+		if instances[0].BearerToken != "prod-token" || instances[1].CustomHeaders["X-Scope-OrgID"] != "team-a,team-b" {
+			t.Errorf("Unexpected instances: %+v", instances)
+		}
+		if !cfg.IsToolDisabled("vmanomaly_cancel_task") || cfg.IsToolDisabled("vmanomaly_run_detection") {
+			t.Error("Expected only vmanomaly_cancel_task to be disabled")
+		}
+		if cfg.ToolTimeout("vmanomaly_run_detection") != 10*time.Minute || cfg.ToolTimeout("vmanomaly_cancel_task") != 0 {
+			t.Errorf("Unexpected tool timeouts: %v, %v", cfg.ToolTimeout("vmanomaly_run_detection"), cfg.ToolTimeout("vmanomaly_cancel_task"))
+		}
+
+		// Env variables override the file
+		os.Setenv("VMANOMALY_TIMEOUT", "5s")
+		os.Setenv("VMANOMALY_INSTANCE_PROD_BEARER_TOKEN", "env-token")
+		cfg, err = Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.Timeout() != 5*time.Second || cfg.Instances()[0].BearerToken != "env-token" {
+			t.Errorf("Expected env overrides, got timeout=%v token=%s", cfg.Timeout(), cfg.Instances()[0].BearerToken)
+		}
+		// Overrides, e.g. set with flags, take precedence over env variables for backend credentials too
+		cfg, err = LoadWithOverrides(path, map[string]string{
+			"VMANOMALY_INSTANCE_PROD_BEARER_TOKEN": "flag-token",
+			"VMANOMALY_INSTANCE_STAGING_HEADERS":   "X-Scope-OrgID=team-c",
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.Instances()[0].BearerToken != "flag-token" || cfg.Instances()[1].CustomHeaders["X-Scope-OrgID"] != "team-c" {
+			t.Errorf("Expected overridden credentials, got: %+v", cfg.Instances())
+		}
+		os.Setenv("VMANOMALY_INSTANCE_PROD_BEARER_TOKEN", "")
+		os.Setenv("VMANOMALY_INSTANCES", "dev=http://dev:8490")
+		os.Setenv("VMANOMALY_DEFAULT_INSTANCE", "dev")
+		cfg, err = Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(cfg.Instances()) != 1 || cfg.Instances()[0].Name != "dev" {
+			t.Errorf("Expected VMANOMALY_INSTANCES to replace backends of the file, got: %+v", cfg.Instances())
+		}
+		os.Setenv("VMANOMALY_INSTANCES", "")
+		os.Setenv("VMANOMALY_DEFAULT_INSTANCE", "")
+
+		// JSON is accepted as well
+		writeFile(`{"backends": [{"name": "prod", "endpoint": "http://prod:8490"}], "vmanomaly": {"max_retries": 1}}`)
+		cfg, err = Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error for JSON config, got: %v", err)
+		}
+		if cfg.MaxRetries() != 1 || cfg.Instances()[0].Endpoint != "http://prod:8490" {
+			t.Errorf("Unexpected JSON config: retries=%d instances=%+v", cfg.MaxRetries(), cfg.Instances())
+		}
+
+		invalid := []string{
+			"vmanomaly:\n  timeuot: 5s\nbackends: [{name: a, endpoint: http://a}]\n",
+			"backends: [{name: a}]\n",
+			"backends: [{name: a/b, endpoint: http://a}]\n",
+			"backends: [{name: a, endpoint: http://a}]\ntools:\n  vmanomaly_query:\n    timeout: soon\n",
+			"server:\n  mode: grpc\nbackends: [{name: a, endpoint: http://a}]\n",
+		}
+		for _, content := range invalid {
+			writeFile(content)
+			if _, err := Load(path); err == nil {
+				t.Errorf("Expected error for config file %q, got nil", content)
+			}
+		}
+		if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Error("Expected error for missing config file, got nil")
+		}
+	})
+
+	// Test case 21: Inbound auth
+	t.Run("Inbound auth", func(t *testing.T) {
+		for _, name := range slices.Concat(resilienceEnvs, tlsEnvs, instanceEnvs, authEnvs) {
+			os.Setenv(name, "")
+		}
+		os.Setenv("VMANOMALY_ENDPOINT", "http://localhost:8490")
+		os.Setenv("VMANOMALY_FAKE_BACKEND", "")
+		defer func() {
+			for _, name := range authEnvs {
+				os.Setenv(name, "")
+			}
+		}()
+
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.IsAuthEnabled() {
+			t.Error("Expected auth to be disabled by default")
+		}
+
+		os.Setenv("MCP_AUTH_TOKENS", "ci=token-1, alice = token=2 ,")
+		cfg, err = InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !cfg.IsAuthEnabled() {
+			t.Error("Expected auth to be enabled with MCP_AUTH_TOKENS")
+		}
+		tokens := cfg.AuthTokens()
+		if len(tokens) != 2 || tokens["token-1"] != "ci" || tokens["token=2"] != "alice" {
+			t.Errorf("Unexpected auth tokens: %v", tokens)
+		}
+
+		os.Setenv("MCP_AUTH_TOKENS", "")
+		os.Setenv("MCP_AUTH_JWKS_FILE", "/etc/mcp/jwks.json")
+		os.Setenv("MCP_AUTH_JWT_AUDIENCE", "mcp-vmanomaly")
+		if _, err := InitConfig(); err == nil {
+			t.Error("Expected error for JWKS file without issuer, got nil")
+		}
+		os.Setenv("MCP_AUTH_JWT_ISSUER", "https://idp.example.com")
+		cfg, err = InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !cfg.IsAuthEnabled() || cfg.AuthJWTIssuer() != "https://idp.example.com" || cfg.AuthJWTAudience() != "mcp-vmanomaly" {
+			t.Errorf("Unexpected JWT settings: issuer=%s audience=%s", cfg.AuthJWTIssuer(), cfg.AuthJWTAudience())
+		}
+
+		for _, tokens := range []string{"token-only", "=token", "ci=", "ci=same,alice=same"} {
+			os.Setenv("MCP_AUTH_TOKENS", tokens)
+			if _, err := InitConfig(); err == nil {
+				t.Errorf("Expected error for MCP_AUTH_TOKENS %q, got nil", tokens)
+			}
+		}
+		os.Setenv("MCP_AUTH_TOKENS", "")
+
+		// Tokens and htpasswd file from the config file
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := `
+auth:
+  tokens:
+    - principal: ci
+      token: file-token
+    - principal: alice
+      token: "a,b=c"
+  htpasswd_file: /etc/mcp/htpasswd
+`
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		cfg, err = Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.AuthTokens()["file-token"] != "ci" || cfg.AuthTokens()["a,b=c"] != "alice" || cfg.AuthHtpasswdFile() != "/etc/mcp/htpasswd" {
+			t.Errorf("Unexpected auth settings from config file: tokens=%v htpasswd=%s", cfg.AuthTokens(), cfg.AuthHtpasswdFile())
+		}
+
+		// Env variables override tokens of the config file
+		os.Setenv("MCP_AUTH_TOKENS", "bob=env-token")
+		cfg, err = Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(cfg.AuthTokens()) != 1 || cfg.AuthTokens()["env-token"] != "bob" {
+			t.Errorf("Expected tokens of MCP_AUTH_TOKENS, got: %v", cfg.AuthTokens())
+		}
+		os.Setenv("MCP_AUTH_TOKENS", "")
+
+		for _, tokens := range []string{
+			"[{principal: ci}]",
+			"[{token: t}]",
+			"[{principal: ci, token: t}, {principal: alice, token: t}]",
+		} {
+			if err := os.WriteFile(path, []byte("auth:\n  tokens: "+tokens+"\n"), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Errorf("Expected error for auth tokens %s in config file, got nil", tokens)
+			}
+		}
+
+		// Roles from the config file
+		content = `
+auth:
+  default_role: oncall
+  roles:
+    - name: oncall
+      class: read_only
+    - name: platform
+      principals: [alice, bob]
+      tools: ["vmanomaly_*_task"]
+`
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		cfg, err = Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		wantRoles := []Role{
+			{Name: "oncall", Class: "read_only"},
+			{Name: "platform", Principals: []string{"alice", "bob"}, Tools: []string{"vmanomaly_*_task"}},
+		}
+		if cfg.AuthDefaultRole() != "oncall" || !reflect.DeepEqual(cfg.Roles(), wantRoles) {
+			t.Errorf("Unexpected roles: default=%s roles=%+v", cfg.AuthDefaultRole(), cfg.Roles())
+		}
+	})
+
+	// Test case 22: Audit log
+	t.Run("Audit log", func(t *testing.T) {
+		for _, name := range slices.Concat(resilienceEnvs, tlsEnvs, instanceEnvs, authEnvs, auditEnvs) {
+			os.Setenv(name, "")
+		}
+		os.Setenv("VMANOMALY_ENDPOINT", "http://localhost:8490")
+		os.Setenv("VMANOMALY_FAKE_BACKEND", "")
+		os.Setenv("MCP_SERVER_MODE", "")
+		defer func() {
+			for _, name := range auditEnvs {
+				os.Setenv(name, "")
+			}
+		}()
+
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.AuditLog() != "" || cfg.AuditLogMaxSizeMB() != 100 || cfg.AuditLogMaxBackups() != 5 {
+			t.Errorf("Unexpected audit log defaults: log=%q max_size_mb=%d max_backups=%d", cfg.AuditLog(), cfg.AuditLogMaxSizeMB(), cfg.AuditLogMaxBackups())
+		}
+
+		// stdout carries MCP messages in stdio mode
+		os.Setenv("MCP_AUDIT_LOG", "stdout")
+		if _, err := InitConfig(); err == nil {
+			t.Error("Expected error for audit log on stdout in stdio mode, got nil")
+		}
+		os.Setenv("MCP_SERVER_MODE", "http")
+		defer os.Setenv("MCP_SERVER_MODE", "")
+		if _, err := InitConfig(); err != nil {
+			t.Errorf("Expected no error for audit log on stdout in http mode, got: %v", err)
+		}
+
+		os.Setenv("MCP_AUDIT_LOG_MAX_BACKUPS", "-1")
+		if _, err := InitConfig(); err == nil {
+			t.Error("Expected error for negative MCP_AUDIT_LOG_MAX_BACKUPS, got nil")
+		}
+		os.Setenv("MCP_AUDIT_LOG_MAX_BACKUPS", "")
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := `
+audit:
+  log: /var/log/mcp/audit.log
+  max_size_mb: 0
+  max_backups: 3
+  redact_keys: [tenant_id, "x-*"]
+  redact_values: ["sk-[a-z0-9]+"]
+`
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		os.Setenv("MCP_AUDIT_LOG", "")
+		os.Setenv("MCP_AUDIT_REDACT_KEYS", "org_id")
+		cfg, err = Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.AuditLog() != "/var/log/mcp/audit.log" || cfg.AuditLogMaxSizeMB() != 0 || cfg.AuditLogMaxBackups() != 3 {
+			t.Errorf("Unexpected audit log settings from config file: log=%q max_size_mb=%d max_backups=%d", cfg.AuditLog(), cfg.AuditLogMaxSizeMB(), cfg.AuditLogMaxBackups())
+		}
+		if !slices.Equal(cfg.AuditRedactKeys(), []string{"org_id"}) || !slices.Equal(cfg.AuditRedactValues(), []string{"sk-[a-z0-9]+"}) {
+			t.Errorf("Unexpected redaction rules: keys=%v values=%v", cfg.AuditRedactKeys(), cfg.AuditRedactValues())
+		}
+	})
+
+	// Test case 23: Tracing
+	t.Run("Tracing", func(t *testing.T) {
+		os.Setenv("VMANOMALY_ENDPOINT", "http://localhost:8490")
+		defer os.Setenv("MCP_TRACING_ENDPOINT", "")
+		defer os.Setenv("MCP_TRACING_SAMPLE_RATIO", "")
+
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.TracingEndpoint() != "" || cfg.TracingSampleRatio() != 1 {
+			t.Errorf("Unexpected tracing defaults: endpoint=%q ratio=%v", cfg.TracingEndpoint(), cfg.TracingSampleRatio())
+		}
+
+		os.Setenv("MCP_TRACING_ENDPOINT", "http://localhost:4318/v1/traces")
+		os.Setenv("MCP_TRACING_SAMPLE_RATIO", "0.25")
+		cfg, err = InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.TracingEndpoint() != "http://localhost:4318/v1/traces" || cfg.TracingSampleRatio() != 0.25 {
+			t.Errorf("Unexpected tracing settings: endpoint=%q ratio=%v", cfg.TracingEndpoint(), cfg.TracingSampleRatio())
+		}
+
+		for _, ratio := range []string{"1.5", "-0.1", "all"} {
+			os.Setenv("MCP_TRACING_SAMPLE_RATIO", ratio)
+			if _, err := InitConfig(); err == nil {
+				t.Errorf("Expected error for MCP_TRACING_SAMPLE_RATIO %q, got nil", ratio)
+			}
+		}
+		os.Setenv("MCP_TRACING_SAMPLE_RATIO", "")
+
+		for _, endpoint := range []string{"localhost:4318", "grpc://collector:4317"} {
+			os.Setenv("MCP_TRACING_ENDPOINT", endpoint)
+			if _, err := InitConfig(); err == nil {
+				t.Errorf("Expected error for MCP_TRACING_ENDPOINT %q, got nil", endpoint)
+			}
+		}
+	})
+
+	// Test case 24: Readiness probes
+	t.Run("Readiness probes", func(t *testing.T) {
+		os.Setenv("VMANOMALY_ENDPOINT", "http://localhost:8490")
+		defer os.Setenv("MCP_READINESS_PROBE_INTERVAL", "")
+		defer os.Setenv("MCP_READINESS_FAILURE_THRESHOLD", "")
+
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.ReadinessProbeInterval() != 15*time.Second || cfg.ReadinessFailureThreshold() != 3 {
+			t.Errorf("Unexpected readiness defaults: interval=%v threshold=%d", cfg.ReadinessProbeInterval(), cfg.ReadinessFailureThreshold())
+		}
+
+		os.Setenv("MCP_READINESS_FAILURE_THRESHOLD", "0")
+		cfg, err = InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.ReadinessFailureThreshold() != 0 {
+			t.Errorf("Expected readiness probes to be disabled, got threshold %d", cfg.ReadinessFailureThreshold())
+		}
+
+		for env, value := range map[string]string{"MCP_READINESS_PROBE_INTERVAL": "0s", "MCP_READINESS_FAILURE_THRESHOLD": "-1"} {
+			os.Setenv(env, value)
+			if _, err := InitConfig(); err == nil {
+				t.Errorf("Expected error for %s=%q, got nil", env, value)
+			}
+			os.Setenv(env, "")
+		}
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := `
+server:
+  readiness:
+    probe_interval: 1m
+    failure_threshold: 5
+`
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		cfg, err = Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.ReadinessProbeInterval() != time.Minute || cfg.ReadinessFailureThreshold() != 5 {
+			t.Errorf("Unexpected readiness settings from config file: interval=%v threshold=%d", cfg.ReadinessProbeInterval(), cfg.ReadinessFailureThreshold())
+		}
+	})
+
+	// Test case 25: Response cache TTL
+	t.Run("Response cache TTL", func(t *testing.T) {
+		os.Setenv("VMANOMALY_ENDPOINT", "http://localhost:8490")
+		defer os.Setenv("VMANOMALY_CACHE_TTL", "")
+
+		cfg, err := InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.CacheTTL() != 5*time.Minute {
+			t.Errorf("Expected default cache TTL 5m, got %v", cfg.CacheTTL())
+		}
+
+		os.Setenv("VMANOMALY_CACHE_TTL", "0")
+		cfg, err = InitConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.CacheTTL() != 0 {
+			t.Errorf("Expected caching to be disabled, got TTL %v", cfg.CacheTTL())
+		}
+
+		for _, value := range []string{"-1m", "forever"} {
+			os.Setenv("VMANOMALY_CACHE_TTL", value)
+			if _, err := InitConfig(); err == nil {
+				t.Errorf("Expected error for VMANOMALY_CACHE_TTL=%q, got nil", value)
+			}
+		}
+		os.Setenv("VMANOMALY_CACHE_TTL", "")
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		content := `
+vmanomaly:
+  cache_ttl: 1h
+`
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		cfg, err = Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.CacheTTL() != time.Hour {
+			t.Errorf("Expected cache TTL 1h from config file, got %v", cfg.CacheTTL())
+		}
+	})
+}
+
+func TestSettings(t *testing.T) {
+	flags := make(map[string]bool)
+	envs := make(map[string]bool)
+	for _, s := range Settings {
+		if flags[s.Flag()] || envs[s.Env] {
+			t.Errorf("Duplicate setting %s (-%s)", s.Env, s.Flag())
+		}
+		flags[s.Flag()] = true
+		envs[s.Env] = true
+	}
+
+	// Every setting of the config file must be settable with a flag
+	for env := range (&fileConfig{}).env() {
+		if !envs[env] {
+			t.Errorf("Setting %s of the config file has no flag", env)
+		}
+	}
+
+	tests := map[string]string{
+		"VMANOMALY_ENDPOINT":                 "vmanomaly.endpoint",
+		"VMANOMALY_TLS_CA_FILE":              "vmanomaly.tlsCaFile",
+		"VMANOMALY_TLS_INSECURE_SKIP_VERIFY": "vmanomaly.tlsInsecureSkipVerify",
+		"MCP_LISTEN_ADDR":                    "mcp.listenAddr",
+	}
+	for env, want := range tests {
+		if got := (Setting{Env: env}).Flag(); got != want {
+			t.Errorf("Expected flag %q for %s, got %q", want, env, got)
+		}
+	}
+}
+
+func TestLoadWithOverrides(t *testing.T) {
+	t.Setenv("VMANOMALY_ENDPOINT", "http://env:8490")
+	t.Setenv("VMANOMALY_TIMEOUT", "5s")
+	t.Setenv("MCP_SERVER_MODE", "")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  mode: sse\nvmanomaly:\n  timeout: 10s\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	cfg, err := LoadWithOverrides(path, map[string]string{
+		"VMANOMALY_ENDPOINT": "http://flag:8490",
+		"MCP_LOG_LEVEL":      "debug",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if cfg.Instances()[0].Endpoint != "http://flag:8490" {
+		t.Errorf("Expected flag to override env, got endpoint: %s", cfg.Instances()[0].Endpoint)
+	}
+	if cfg.Timeout() != 5*time.Second {
+		t.Errorf("Expected env to override file, got timeout: %v", cfg.Timeout())
+	}
+	if !cfg.IsSSE() || cfg.LogLevel() != "debug" {
+		t.Errorf("Unexpected mode %s and log level %s", cfg.ServerMode(), cfg.LogLevel())
+	}
+
+	if _, err := LoadWithOverrides("", map[string]string{"VMANOMALY_MAX_RETRIES": "many"}); err == nil {
+		t.Error("Expected error for invalid flag value, got nil")
+	}
 }
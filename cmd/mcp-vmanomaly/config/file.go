@@ -0,0 +1,269 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the YAML (or JSON) config file passed with -config.
+// Scalar settings are kept as strings and mapped to env variable names,
+// so they are validated by the same code as env variables and env variables override them.
+type fileConfig struct {
+	Server    fileServer          `yaml:"server"`
+	Log       fileLog             `yaml:"log"`
+	Auth      fileAuth            `yaml:"auth"`
+	Audit     fileAudit           `yaml:"audit"`
+	Tracing   fileTracing         `yaml:"tracing"`
+	Vmanomaly fileVmanomaly       `yaml:"vmanomaly"`
+	Backends  []fileBackend       `yaml:"backends"`
+	Tools     map[string]fileTool `yaml:"tools"`
+}
+
+type fileServer struct {
+	Mode              string        `yaml:"mode"`
+	ListenAddr        string        `yaml:"listen_addr"`
+	HeartbeatInterval string        `yaml:"heartbeat_interval"`
+	DisableResources  string        `yaml:"disable_resources"`
+	ReadOnly          string        `yaml:"read_only"`
+	Readiness         fileReadiness `yaml:"readiness"`
+}
+
+type fileReadiness struct {
+	ProbeInterval    string `yaml:"probe_interval"`
+	FailureThreshold string `yaml:"failure_threshold"`
+}
+
+type fileLog struct {
+	Level string `yaml:"level"`
+	File  string `yaml:"file"`
+}
+
+type fileAudit struct {
+	Log          string   `yaml:"log"`
+	MaxSizeMB    string   `yaml:"max_size_mb"`
+	MaxBackups   string   `yaml:"max_backups"`
+	RedactKeys   []string `yaml:"redact_keys"`
+	RedactValues []string `yaml:"redact_values"`
+}
+
+type fileTracing struct {
+	Endpoint    string `yaml:"endpoint"`
+	SampleRatio string `yaml:"sample_ratio"`
+}
+
+type fileAuth struct {
+	ForwardHeader string          `yaml:"forward_header"`
+	Tokens        []fileAuthToken `yaml:"tokens"`
+	HtpasswdFile  string          `yaml:"htpasswd_file"`
+	JWT           fileJWT         `yaml:"jwt"`
+	DefaultRole   string          `yaml:"default_role"`
+	Roles         []fileRole      `yaml:"roles"`
+}
+
+type fileRole struct {
+	Name       string   `yaml:"name"`
+	Principals []string `yaml:"principals"`
+	Tools      []string `yaml:"tools"`
+	Class      string   `yaml:"class"`
+}
+
+type fileAuthToken struct {
+	Principal string `yaml:"principal"`
+	Token     string `yaml:"token"`
+}
+
+type fileJWT struct {
+	JWKSFile       string `yaml:"jwks_file"`
+	Audience       string `yaml:"audience"`
+	Issuer         string `yaml:"issuer"`
+	PrincipalClaim string `yaml:"principal_claim"`
+}
+
+type fileVmanomaly struct {
+	FakeBackend     string             `yaml:"fake_backend"`
+	DefaultInstance string             `yaml:"default_instance"`
+	Timeout         string             `yaml:"timeout"`
+	MaxRetries      string             `yaml:"max_retries"`
+	RetryBackoff    string             `yaml:"retry_backoff"`
+	RetryMaxBackoff string             `yaml:"retry_max_backoff"`
+	CircuitBreaker  fileCircuitBreaker `yaml:"circuit_breaker"`
+	CacheTTL        string             `yaml:"cache_ttl"`
+	TLS             fileTLS            `yaml:"tls"`
+}
+
+type fileCircuitBreaker struct {
+	Threshold string `yaml:"threshold"`
+	Timeout   string `yaml:"timeout"`
+}
+
+type fileTLS struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify string `yaml:"insecure_skip_verify"`
+}
+
+type fileBackend struct {
+	Name        string            `yaml:"name"`
+	Endpoint    string            `yaml:"endpoint"`
+	BearerToken string            `yaml:"bearer_token"`
+	Headers     map[string]string `yaml:"headers"`
+}
+
+type fileTool struct {
+	Disabled bool   `yaml:"disabled"`
+	Timeout  string `yaml:"timeout"`
+}
+
+// readConfigFile parses the config file at path. Unknown fields are rejected to catch typos.
+func readConfigFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var fc fileConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %q: %w", path, err)
+	}
+	return &fc, nil
+}
+
+// env returns scalar settings of the file keyed by the env variables they correspond to
+func (fc *fileConfig) env() map[string]string {
+	env := map[string]string{
+		"MCP_SERVER_MODE":                     fc.Server.Mode,
+		"MCP_LISTEN_ADDR":                     fc.Server.ListenAddr,
+		"MCP_HEARTBEAT_INTERVAL":              fc.Server.HeartbeatInterval,
+		"MCP_DISABLE_RESOURCES":               fc.Server.DisableResources,
+		"MCP_READ_ONLY":                       fc.Server.ReadOnly,
+		"MCP_READINESS_PROBE_INTERVAL":        fc.Server.Readiness.ProbeInterval,
+		"MCP_READINESS_FAILURE_THRESHOLD":     fc.Server.Readiness.FailureThreshold,
+		"MCP_LOG_LEVEL":                       fc.Log.Level,
+		"MCP_LOG_FILE":                        fc.Log.File,
+		"MCP_AUDIT_LOG":                       fc.Audit.Log,
+		"MCP_AUDIT_LOG_MAX_SIZE_MB":           fc.Audit.MaxSizeMB,
+		"MCP_AUDIT_LOG_MAX_BACKUPS":           fc.Audit.MaxBackups,
+		"MCP_AUDIT_REDACT_KEYS":               strings.Join(fc.Audit.RedactKeys, ","),
+		"MCP_TRACING_ENDPOINT":                fc.Tracing.Endpoint,
+		"MCP_TRACING_SAMPLE_RATIO":            fc.Tracing.SampleRatio,
+		"MCP_FORWARD_AUTH_HEADER":             fc.Auth.ForwardHeader,
+		"MCP_AUTH_HTPASSWD_FILE":              fc.Auth.HtpasswdFile,
+		"MCP_AUTH_JWKS_FILE":                  fc.Auth.JWT.JWKSFile,
+		"MCP_AUTH_JWT_AUDIENCE":               fc.Auth.JWT.Audience,
+		"MCP_AUTH_JWT_ISSUER":                 fc.Auth.JWT.Issuer,
+		"MCP_AUTH_JWT_PRINCIPAL_CLAIM":        fc.Auth.JWT.PrincipalClaim,
+		"MCP_AUTH_DEFAULT_ROLE":               fc.Auth.DefaultRole,
+		"VMANOMALY_FAKE_BACKEND":              fc.Vmanomaly.FakeBackend,
+		"VMANOMALY_DEFAULT_INSTANCE":          fc.Vmanomaly.DefaultInstance,
+		"VMANOMALY_TIMEOUT":                   fc.Vmanomaly.Timeout,
+		"VMANOMALY_MAX_RETRIES":               fc.Vmanomaly.MaxRetries,
+		"VMANOMALY_RETRY_BACKOFF":             fc.Vmanomaly.RetryBackoff,
+		"VMANOMALY_RETRY_MAX_BACKOFF":         fc.Vmanomaly.RetryMaxBackoff,
+		"VMANOMALY_CIRCUIT_BREAKER_THRESHOLD": fc.Vmanomaly.CircuitBreaker.Threshold,
+		"VMANOMALY_CIRCUIT_BREAKER_TIMEOUT":   fc.Vmanomaly.CircuitBreaker.Timeout,
+		"VMANOMALY_CACHE_TTL":                 fc.Vmanomaly.CacheTTL,
+		"VMANOMALY_TLS_CA_FILE":               fc.Vmanomaly.TLS.CAFile,
+		"VMANOMALY_TLS_CERT_FILE":             fc.Vmanomaly.TLS.CertFile,
+		"VMANOMALY_TLS_KEY_FILE":              fc.Vmanomaly.TLS.KeyFile,
+		"VMANOMALY_TLS_SERVER_NAME":           fc.Vmanomaly.TLS.ServerName,
+		"VMANOMALY_TLS_INSECURE_SKIP_VERIFY":  fc.Vmanomaly.TLS.InsecureSkipVerify,
+	}
+
+	var disabledTools []string
+	for name, tool := range fc.Tools {
+		if tool.Disabled {
+			disabledTools = append(disabledTools, name)
+		}
+	}
+	sort.Strings(disabledTools)
+	env["MCP_DISABLED_TOOLS"] = strings.Join(disabledTools, ",")
+
+	return env
+}
+
+// authTokens returns static bearer tokens of the file keyed by token, with values naming their principals.
+// Unlike MCP_AUTH_TOKENS, tokens may contain any characters.
+func (fc *fileConfig) authTokens() (map[string]string, error) {
+	tokens := make(map[string]string, len(fc.Auth.Tokens))
+	for i, t := range fc.Auth.Tokens {
+		if strings.TrimSpace(t.Principal) == "" || strings.TrimSpace(t.Token) == "" {
+			return nil, fmt.Errorf("principal and token of auth token #%d in config file are required", i+1)
+		}
+		if _, ok := tokens[t.Token]; ok {
+			return nil, fmt.Errorf("duplicate token of principal %q in config file", t.Principal)
+		}
+		tokens[t.Token] = t.Principal
+	}
+	return tokens, nil
+}
+
+// instances returns backends of the file. Credentials can be overridden with
+// VMANOMALY_INSTANCE_<NAME>_BEARER_TOKEN and VMANOMALY_INSTANCE_<NAME>_HEADERS settings of src.
+func (fc *fileConfig) instances(src source, fakeBackend bool) ([]Instance, error) {
+	instances := make([]Instance, 0, len(fc.Backends))
+	for i, b := range fc.Backends {
+		if !instanceNameRe.MatchString(b.Name) {
+			return nil, fmt.Errorf("invalid name %q of backend #%d in config file: only letters, digits, '_' and '-' are allowed", b.Name, i+1)
+		}
+		if b.Endpoint == "" && !fakeBackend {
+			return nil, fmt.Errorf("endpoint of backend %q is required in config file", b.Name)
+		}
+		inst := Instance{
+			Name:          b.Name,
+			Endpoint:      b.Endpoint,
+			BearerToken:   b.BearerToken,
+			CustomHeaders: b.Headers,
+		}
+		if inst.CustomHeaders == nil {
+			inst.CustomHeaders = make(map[string]string)
+		}
+		prefix := instanceEnvPrefix(b.Name)
+		if v := src.get(prefix + "BEARER_TOKEN"); v != "" {
+			inst.BearerToken = v
+		}
+		if v := src.get(prefix + "HEADERS"); v != "" {
+			inst.CustomHeaders = parseCustomHeaders(v)
+		}
+		instances = append(instances, inst)
+	}
+	return instances, nil
+}
+
+// roles returns roles of the file
+func (fc *fileConfig) roles() []Role {
+	roles := make([]Role, 0, len(fc.Auth.Roles))
+	for _, r := range fc.Auth.Roles {
+		roles = append(roles, Role(r))
+	}
+	return roles
+}
+
+// toolTimeouts returns per-tool call timeouts of the file
+func (fc *fileConfig) toolTimeouts() (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for name, tool := range fc.Tools {
+		if tool.Timeout == "" {
+			continue
+		}
+		d, err := time.ParseDuration(tool.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timeout of tool %q in config file: %w", name, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("timeout of tool %q in config file must be positive", name)
+		}
+		timeouts[name] = d
+	}
+	return timeouts, nil
+}
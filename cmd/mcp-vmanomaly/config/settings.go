@@ -0,0 +1,77 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Setting is a scalar setting configurable with an env variable, a command-line flag or the config file.
+// Flags override env variables, which override the config file.
+type Setting struct {
+	Env   string
+	Usage string
+	Bool  bool
+}
+
+// Flag returns the command-line flag name of the setting, e.g. -vmanomaly.tlsCaFile for VMANOMALY_TLS_CA_FILE
+func (s Setting) Flag() string {
+	prefix, rest, _ := strings.Cut(s.Env, "_")
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(prefix))
+	sb.WriteByte('.')
+	for i, word := range strings.Split(strings.ToLower(rest), "_") {
+		if i > 0 && word != "" {
+			r := []rune(word)
+			r[0] = unicode.ToUpper(r[0])
+			word = string(r)
+		}
+		sb.WriteString(word)
+	}
+	return sb.String()
+}
+
+// Settings lists all settings read from env variables, except per-instance credentials
+var Settings = []Setting{
+	{Env: "VMANOMALY_ENDPOINT", Usage: "vmanomaly server endpoint URL, configures the instance named \"default\""},
+	{Env: "VMANOMALY_BEARER_TOKEN", Usage: "Bearer token for the default vmanomaly instance"},
+	{Env: "VMANOMALY_HEADERS", Usage: "Comma-separated key=value headers sent to the default vmanomaly instance"},
+	{Env: "VMANOMALY_INSTANCES", Usage: "Comma-separated name=endpoint pairs of additional vmanomaly instances"},
+	{Env: "VMANOMALY_DEFAULT_INSTANCE", Usage: "Instance used when tools don't specify one (default: the first instance)"},
+	{Env: "VMANOMALY_FAKE_BACKEND", Usage: "Use an in-process fake vmanomaly backend with synthetic data instead of real endpoints", Bool: true},
+	{Env: "VMANOMALY_TIMEOUT", Usage: "Timeout of a single request to vmanomaly (default: 30s)"},
+	{Env: "VMANOMALY_MAX_RETRIES", Usage: "Retries of idempotent requests to vmanomaly on network errors, 429 and 502-504 (default: 2)"},
+	{Env: "VMANOMALY_RETRY_BACKOFF", Usage: "Initial retry backoff (default: 500ms)"},
+	{Env: "VMANOMALY_RETRY_MAX_BACKOFF", Usage: "Maximum retry backoff (default: 10s)"},
+	{Env: "VMANOMALY_CIRCUIT_BREAKER_THRESHOLD", Usage: "Consecutive failures that open the circuit breaker, 0 disables it (default: 5)"},
+	{Env: "VMANOMALY_CIRCUIT_BREAKER_TIMEOUT", Usage: "How long the circuit breaker stays open (default: 30s)"},
+	{Env: "VMANOMALY_CACHE_TTL", Usage: "How long model list, model schema and build info responses are cached, 0 disables caching (default: 5m)"},
+	{Env: "VMANOMALY_TLS_CA_FILE", Usage: "CA certificate file to verify vmanomaly server certificate"},
+	{Env: "VMANOMALY_TLS_CERT_FILE", Usage: "Client certificate file for mutual TLS"},
+	{Env: "VMANOMALY_TLS_KEY_FILE", Usage: "Client key file for mutual TLS"},
+	{Env: "VMANOMALY_TLS_SERVER_NAME", Usage: "Server name to verify vmanomaly server certificate against"},
+	{Env: "VMANOMALY_TLS_INSECURE_SKIP_VERIFY", Usage: "Skip verification of vmanomaly server certificate", Bool: true},
+	{Env: "MCP_SERVER_MODE", Usage: "Server mode: stdio, sse or http (default: stdio)"},
+	{Env: "MCP_LISTEN_ADDR", Usage: "Address to listen on in sse and http modes (default: localhost:8080)"},
+	{Env: "MCP_DISABLED_TOOLS", Usage: "Comma-separated list of tools to disable"},
+	{Env: "MCP_READ_ONLY", Usage: "Hide and reject tools not annotated as read-only", Bool: true},
+	{Env: "MCP_DISABLE_RESOURCES", Usage: "Disable documentation resources", Bool: true},
+	{Env: "MCP_HEARTBEAT_INTERVAL", Usage: "Heartbeat interval of the streamable-http transport (default: 30s)"},
+	{Env: "MCP_READINESS_PROBE_INTERVAL", Usage: "Interval of vmanomaly health probes reflected by /health/readiness in sse and http modes (default: 15s)"},
+	{Env: "MCP_READINESS_FAILURE_THRESHOLD", Usage: "Consecutive failed probes after which a vmanomaly instance is unhealthy, 0 disables probes (default: 3)"},
+	{Env: "MCP_FORWARD_AUTH_HEADER", Usage: "Incoming HTTP header forwarded to vmanomaly as Authorization header in sse and http modes"},
+	{Env: "MCP_AUTH_TOKENS", Usage: "Comma-separated principal=token pairs of static bearer tokens accepted in sse and http modes"},
+	{Env: "MCP_AUTH_HTPASSWD_FILE", Usage: "htpasswd file with bcrypt or SHA-1 hashes of basic auth users accepted in sse and http modes"},
+	{Env: "MCP_AUTH_JWKS_FILE", Usage: "JWKS file with keys verifying bearer JWTs accepted in sse and http modes"},
+	{Env: "MCP_AUTH_JWT_AUDIENCE", Usage: "Audience JWTs must be issued for, required with MCP_AUTH_JWKS_FILE"},
+	{Env: "MCP_AUTH_JWT_ISSUER", Usage: "Issuer of JWTs, required with MCP_AUTH_JWKS_FILE"},
+	{Env: "MCP_AUTH_JWT_PRINCIPAL_CLAIM", Usage: "JWT claim used as principal name (default: sub)"},
+	{Env: "MCP_AUTH_DEFAULT_ROLE", Usage: "Role of authenticated principals without roles in the config file (default: none, such principals can't call tools)"},
+	{Env: "MCP_AUDIT_LOG", Usage: "Audit log of tool calls: a JSON lines file path or stdout (default: disabled)"},
+	{Env: "MCP_AUDIT_LOG_MAX_SIZE_MB", Usage: "Size in megabytes at which the audit log file is rotated, 0 disables rotation (default: 100)"},
+	{Env: "MCP_AUDIT_LOG_MAX_BACKUPS", Usage: "Rotated audit log files to keep (default: 5)"},
+	{Env: "MCP_AUDIT_REDACT_KEYS", Usage: "Comma-separated patterns of tool argument names redacted in the audit log in addition to built-in ones"},
+	{Env: "MCP_TRACING_ENDPOINT", Usage: "OTLP/HTTP endpoint receiving traces of tool calls, e.g. http://localhost:4318/v1/traces (default: tracing disabled)"},
+	{Env: "MCP_TRACING_SAMPLE_RATIO", Usage: "Fraction of traces started by the server that are exported (default: 1)"},
+	{Env: "MCP_LOG_LEVEL", Usage: "Log level: debug, info, warn or error (default: info)"},
+	{Env: "MCP_LOG_FILE", Usage: "Log file path (default: stderr)"},
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly/fake"
+)
+
+// startFakeBackend serves the fake vmanomaly API on a random local port
+// and returns its URL together with a function that stops it
+func startFakeBackend() (string, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	srv := &http.Server{Handler: fake.New()}
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Fake vmanomaly backend stopped", "error", err)
+		}
+	}()
+
+	return "http://" + listener.Addr().String(), func() { _ = srv.Close() }, nil
+}
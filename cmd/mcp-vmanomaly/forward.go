@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+)
+
+// forwardAuthContextFunc returns an HTTP context func that puts the value of the incoming header
+// into the request context, so the vmanomaly client uses the caller's credentials.
+// Forwarding is disabled if header is empty.
+func forwardAuthContextFunc(header string) func(ctx context.Context, r *http.Request) context.Context {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		if header == "" {
+			return ctx
+		}
+		return vmanomaly.ContextWithAuthorization(ctx, r.Header.Get(header))
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+)
+
+// newProber returns a prober of vmanomaly instances, nil if readiness probes are disabled
+func newProber(c *config.Config, instances *vmanomaly.Instances, ms *metrics.Set) *vmanomaly.Prober {
+	if c.ReadinessFailureThreshold() == 0 {
+		return nil
+	}
+	return vmanomaly.NewProber(instances, vmanomaly.ProberConfig{
+		Interval:         c.ReadinessProbeInterval(),
+		Timeout:          c.Timeout(),
+		FailureThreshold: c.ReadinessFailureThreshold(),
+	}, ms)
+}
+
+// healthDetails is the response of /health/details
+type healthDetails struct {
+	// Status is not_ready while the server starts or shuts down, otherwise the readiness state of vmanomaly instances
+	Status    string                  `json:"status"`
+	Ready     bool                    `json:"ready"`
+	Instances []vmanomaly.ProbeResult `json:"instances"`
+}
+
+// readiness returns the state reported by health endpoints. prober may be nil if probes are disabled.
+func readiness(isReady *atomic.Bool, prober *vmanomaly.Prober) healthDetails {
+	if !isReady.Load() {
+		return healthDetails{Status: "not_ready", Instances: []vmanomaly.ProbeResult{}}
+	}
+	if prober == nil {
+		return healthDetails{Status: vmanomaly.ReadinessReady, Ready: true, Instances: []vmanomaly.ProbeResult{}}
+	}
+	rd := prober.Readiness()
+	return healthDetails{
+		Status:    rd.State,
+		Ready:     rd.State != vmanomaly.ReadinessUnavailable,
+		Instances: rd.Instances,
+	}
+}
+
+// readinessHandler reports the server as not ready while it starts or shuts down and when all vmanomaly instances
+// are unhealthy. It stays ready while some instances are healthy, reporting the degraded state.
+func readinessHandler(isReady *atomic.Bool, prober *vmanomaly.Prober) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		h := readiness(isReady, prober)
+		switch {
+		case h.Status == "not_ready":
+			http.Error(w, "Not ready", http.StatusServiceUnavailable)
+			return
+		case !h.Ready:
+			http.Error(w, "Unavailable: all vmanomaly instances failed health probes, see /health/details", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		if h.Status == vmanomaly.ReadinessDegraded {
+			_, _ = w.Write([]byte("Degraded: some vmanomaly instances failed health probes, see /health/details\n"))
+			return
+		}
+		_, _ = w.Write([]byte("Ready\n"))
+	}
+}
+
+// healthDetailsHandler responds with the readiness state and the last probe results of vmanomaly instances as JSON
+func healthDetailsHandler(isReady *atomic.Bool, prober *vmanomaly.Prober) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		h := readiness(isReady, prober)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if !h.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(h)
+	}
+}
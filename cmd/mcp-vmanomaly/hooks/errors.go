@@ -0,0 +1,64 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Error classes used as the error label of metrics. Error messages contain tool names, URLs and
+// upstream responses, so they are mapped to this bounded set to keep the number of series low.
+const (
+	errorClassTimeout        = "timeout"
+	errorClassCanceled       = "canceled"
+	errorClassNotFound       = "not_found"
+	errorClassUnsupported    = "unsupported"
+	errorClassInvalidRequest = "invalid_request"
+	errorClassSession        = "session"
+	errorClassCircuitOpen    = "circuit_open"
+	errorClassUpstream       = "upstream"
+	errorClassNetwork        = "network"
+	errorClassPanic          = "panic"
+	errorClassInternal       = "internal"
+)
+
+// errorClass returns the class of err
+func errorClass(err error) string {
+	var unparsable *server.UnparsableMessageError
+	var apiErr *vmanomaly.APIError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return errorClassCanceled
+	case errors.Is(err, server.ErrToolNotFound), errors.Is(err, server.ErrResourceNotFound), errors.Is(err, server.ErrPromptNotFound):
+		return errorClassNotFound
+	case errors.Is(err, server.ErrUnsupported):
+		return errorClassUnsupported
+	case errors.As(err, &unparsable):
+		return errorClassInvalidRequest
+	case errors.Is(err, server.ErrSessionNotFound), errors.Is(err, server.ErrSessionNotInitialized):
+		return errorClassSession
+	case errors.Is(err, vmanomaly.ErrCircuitOpen):
+		return errorClassCircuitOpen
+	case errors.As(err, &apiErr):
+		return errorClassUpstream
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return errorClassTimeout
+		}
+		return errorClassNetwork
+	}
+
+	// Recovered panics aren't typed, so they are recognized by the message
+	if strings.Contains(err.Error(), "panic recovered in ") {
+		return errorClassPanic
+	}
+	return errorClassInternal
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/auth"
 
 	"github.com/VictoriaMetrics/metrics"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -13,6 +17,21 @@ import (
 func New(ms *metrics.Set) *server.Hooks {
 	hooks := &server.Hooks{}
 
+	// Start times of tool calls in progress. The server passes the same request to the before hook
+	// and to the after or error hook, so it identifies the call.
+	var callStarts sync.Map
+	observeCallDuration := func(message *mcp.CallToolRequest, isError bool) {
+		start, ok := callStarts.LoadAndDelete(message)
+		if !ok {
+			return
+		}
+		ms.GetOrCreateHistogram(fmt.Sprintf(
+			`mcp_vmanomaly_call_tool_duration_seconds{name="%s",is_error="%t"}`,
+			message.Params.Name,
+			isError,
+		)).UpdateDuration(start.(time.Time))
+	}
+
 	hooks.AddAfterInitialize(func(_ context.Context, _ any, message *mcp.InitializeRequest, _ *mcp.InitializeResult) {
 		ms.GetOrCreateCounter(fmt.Sprintf(
 			`mcp_vmanomaly_initialize_total{client_name="%s",client_version="%s"}`,
@@ -33,19 +52,25 @@ func New(ms *metrics.Set) *server.Hooks {
 		ms.GetOrCreateCounter(`mcp_vmanomaly_list_prompts_total`).Inc()
 	})
 
-	hooks.AddAfterCallTool(func(_ context.Context, _ any, message *mcp.CallToolRequest, result *mcp.CallToolResult) {
+	hooks.AddBeforeCallTool(func(_ context.Context, _ any, message *mcp.CallToolRequest) {
+		callStarts.Store(message, time.Now())
+	})
+
+	hooks.AddAfterCallTool(func(ctx context.Context, _ any, message *mcp.CallToolRequest, result *mcp.CallToolResult) {
+		observeCallDuration(message, result.IsError)
 		ms.GetOrCreateCounter(fmt.Sprintf(
 			`mcp_vmanomaly_call_tool_total{name="%s",is_error="%t"}`,
 			message.Params.Name,
 			result.IsError,
 		)).Inc()
 
+		// Arguments may contain credentials, they are written redacted to the audit log and raw only at debug level
 		if result.IsError {
-			slog.Error("Tool call failed", "tool", message.Params.Name)
+			slog.Error("Tool call failed", "tool", message.Params.Name, "principal", principalName(ctx))
 		} else {
-			slog.Info("Tool called", "tool", message.Params.Name, "args", message.Params.Arguments)
-			slog.Debug("Tool response", "tool", message.Params.Name, "content", result.Content)
+			slog.Info("Tool called", "tool", message.Params.Name, "principal", principalName(ctx))
 		}
+		slog.Debug("Tool response", "tool", message.Params.Name, "args", message.Params.Arguments, "content", result.Content)
 	})
 
 	hooks.AddAfterGetPrompt(func(_ context.Context, _ any, message *mcp.GetPromptRequest, _ *mcp.GetPromptResult) {
@@ -62,11 +87,20 @@ func New(ms *metrics.Set) *server.Hooks {
 		)).Inc()
 	})
 
-	hooks.AddOnError(func(_ context.Context, _ any, method mcp.MCPMethod, _ any, err error) {
+	hooks.AddOnError(func(_ context.Context, _ any, method mcp.MCPMethod, message any, err error) {
+		class := errorClass(err)
+		if req, ok := message.(*mcp.CallToolRequest); ok && method == mcp.MethodToolsCall {
+			if class == errorClassNotFound {
+				// Names of unknown tools come from clients, they aren't used as labels
+				callStarts.Delete(req)
+			} else {
+				observeCallDuration(req, true)
+			}
+		}
 		ms.GetOrCreateCounter(fmt.Sprintf(
 			`mcp_vmanomaly_error_total{method="%s",error="%s"}`,
 			method,
-			err,
+			class,
 		)).Inc()
 
 		slog.Error("MCP operation error", "method", method, "error", err)
@@ -74,3 +108,11 @@ func New(ms *metrics.Set) *server.Hooks {
 
 	return hooks
 }
+
+// principalName returns the name of the authenticated caller, empty if inbound auth is disabled
+func principalName(ctx context.Context) string {
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		return p.Name
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("request failed: %w", context.DeadlineExceeded), errorClassTimeout},
+		{fmt.Errorf("request failed: %w", context.Canceled), errorClassCanceled},
+		{fmt.Errorf("tool 'foo' not found: %w", server.ErrToolNotFound), errorClassNotFound},
+		{fmt.Errorf("resource 'bar' not found: %w", server.ErrResourceNotFound), errorClassNotFound},
+		{fmt.Errorf("tools %w", server.ErrUnsupported), errorClassUnsupported},
+		{server.ErrSessionNotInitialized, errorClassSession},
+		{fmt.Errorf("failed to list models: %w", vmanomaly.ErrCircuitOpen), errorClassCircuitOpen},
+		{fmt.Errorf("request failed: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), errorClassNetwork},
+		{fmt.Errorf("failed to list models: %w", &vmanomaly.APIError{StatusCode: 503, Detail: "unavailable"}), errorClassUpstream},
+		{errors.New("panic recovered in vmanomaly_list_models tool handler: boom"), errorClassPanic},
+		{errors.New("something else"), errorClassInternal},
+	}
+	for _, tt := range tests {
+		if got := errorClass(tt.err); got != tt.want {
+			t.Errorf("errorClass(%q) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestNew_CallToolMetrics(t *testing.T) {
+	ms := metrics.NewSet()
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(false), server.WithHooks(New(ms)))
+	s.AddTool(mcp.NewTool("ok"), func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	s.AddTool(mcp.NewTool("fail"), func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, &vmanomaly.APIError{StatusCode: 503, Detail: "unavailable"}
+	})
+
+	for i, name := range []string{"ok", "ok", "fail", "unknown"} {
+		msg := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":%q}}`, i, name)
+		s.HandleMessage(context.Background(), []byte(msg))
+	}
+
+	var buf bytes.Buffer
+	ms.WritePrometheus(&buf)
+	got := buf.String()
+	for _, want := range []string{
+		`mcp_vmanomaly_call_tool_duration_seconds_count{name="ok",is_error="false"} 2`,
+		`mcp_vmanomaly_call_tool_duration_seconds_count{name="fail",is_error="true"} 1`,
+		`mcp_vmanomaly_error_total{method="tools/call",error="upstream"} 1`,
+		`mcp_vmanomaly_error_total{method="tools/call",error="not_found"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %s in metrics:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `name="unknown"`) || strings.Contains(got, "unavailable") {
+		t.Errorf("unexpected unbounded labels in metrics:\n%s", got)
+	}
+}
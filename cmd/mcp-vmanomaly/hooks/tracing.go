@@ -0,0 +1,43 @@
+package hooks
+
+import (
+	"context"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/tracing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolTracing returns a tool handler middleware recording a span of every tool call with t.
+// Requests to vmanomaly made by the tool become child spans. Server hooks can't pass the span
+// to tool handlers, so tool calls are traced with a middleware.
+func ToolTracing(t *tracing.Tracer) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, span := t.Start(ctx, string(mcp.MethodToolsCall)+" "+req.Params.Name, tracing.SpanKindServer,
+				tracing.String("mcp.method.name", string(mcp.MethodToolsCall)),
+				tracing.String("gen_ai.tool.name", req.Params.Name),
+			)
+			defer span.End()
+			if session := server.ClientSessionFromContext(ctx); session != nil {
+				span.SetAttributes(tracing.String("mcp.session.id", session.SessionID()))
+			}
+			if name := principalName(ctx); name != "" {
+				span.SetAttributes(tracing.String("enduser.id", name))
+			}
+
+			result, err := next(ctx, req)
+			switch {
+			case err != nil:
+				span.SetAttributes(tracing.String("error.type", "handler_error"))
+				span.SetError(err.Error())
+			case result != nil && result.IsError:
+				// Error results may contain arguments, so their text isn't recorded
+				span.SetAttributes(tracing.String("error.type", "tool_error"))
+				span.SetError("tool returned an error")
+			}
+			return result, err
+		}
+	}
+}
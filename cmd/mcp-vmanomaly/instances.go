@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+)
+
+// newInstances creates clients for all configured vmanomaly instances.
+// In fake backend mode every instance gets its own fake backend; the returned function stops them.
+func newInstances(c *config.Config, ms *metrics.Set) (*vmanomaly.Instances, func(), error) {
+	var stops []func()
+	stop := func() {
+		for _, s := range stops {
+			s()
+		}
+	}
+
+	commonOpts := []vmanomaly.ClientOption{
+		vmanomaly.WithTimeout(c.Timeout()),
+		vmanomaly.WithRetry(vmanomaly.RetryConfig{
+			MaxRetries:     c.MaxRetries(),
+			InitialBackoff: c.RetryBackoff(),
+			MaxBackoff:     c.RetryMaxBackoff(),
+		}),
+		vmanomaly.WithMetricsSet(ms),
+		vmanomaly.WithCache(c.CacheTTL()),
+	}
+	tlsOpts := vmanomaly.TLSOptions{
+		CAFile:             c.TLSCAFile(),
+		CertFile:           c.TLSCertFile(),
+		KeyFile:            c.TLSKeyFile(),
+		ServerName:         c.TLSServerName(),
+		InsecureSkipVerify: c.TLSInsecureSkipVerify(),
+	}
+
+	instances := make([]vmanomaly.Instance, 0, len(c.Instances()))
+	for _, inst := range c.Instances() {
+		endpoint := inst.Endpoint
+		if c.FakeBackend() {
+			fakeURL, stopFake, err := startFakeBackend()
+			if err != nil {
+				stop()
+				return nil, nil, fmt.Errorf("failed to start fake vmanomaly backend: %w", err)
+			}
+			stops = append(stops, stopFake)
+			endpoint = fakeURL
+			slog.Warn("Using fake vmanomaly backend with synthetic data", "instance", inst.Name, "url", fakeURL)
+		}
+
+		opts := append([]vmanomaly.ClientOption{vmanomaly.WithInstanceName(inst.Name)}, commonOpts...)
+		if tlsOpts.IsSet() {
+			// Certificates are verified against the host of each endpoint
+			tlsConfig, err := vmanomaly.NewTLSConfig(endpoint, tlsOpts)
+			if err != nil {
+				stop()
+				return nil, nil, fmt.Errorf("failed to configure TLS for vmanomaly instance %q: %w", inst.Name, err)
+			}
+			opts = append(opts, vmanomaly.WithTLSConfig(tlsConfig))
+		}
+		if c.CircuitBreakerThreshold() > 0 {
+			breaker := vmanomaly.NewCircuitBreaker(vmanomaly.CircuitBreakerConfig{
+				Instance:         inst.Name,
+				FailureThreshold: c.CircuitBreakerThreshold(),
+				OpenTimeout:      c.CircuitBreakerTimeout(),
+			}, ms)
+			opts = append(opts, vmanomaly.WithCircuitBreaker(breaker))
+		}
+
+		instances = append(instances, vmanomaly.Instance{
+			Name:     inst.Name,
+			Endpoint: endpoint,
+			Client:   vmanomaly.NewClient(endpoint, inst.BearerToken, inst.CustomHeaders, opts...),
+		})
+	}
+
+	is, err := vmanomaly.NewInstances(instances, c.DefaultInstance())
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+	return is, stop, nil
+}
@@ -14,14 +14,10 @@ import (
 	"time"
 
 	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
-	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/hooks"
-	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/promts"
-	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/resources"
-	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/tools"
-	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/auth"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/tracing"
 
 	"github.com/VictoriaMetrics/metrics"
-	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
@@ -39,107 +35,85 @@ const (
 )
 
 func main() {
-	c, err := config.InitConfig()
-	if err != nil {
-		fmt.Printf("Error initializing config: %v\n", err)
-		return
-	}
-
-	var logOutput = os.Stderr
-	if c.LogFile() != "" {
-		f, err := os.OpenFile(c.LogFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to open log file, using stderr: %v\n", err)
-		} else {
-			logOutput = f
-			defer f.Close()
-		}
-	}
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	var logLevel slog.Level
-	switch c.LogLevel() {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
+// serve runs the MCP server until it is stopped and returns the process exit code.
+// load is called again on config reload.
+func serve(load func() (*config.Config, error)) int {
+	c, err := load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
+		return exitConfigError
 	}
 
-	logger := slog.New(slog.NewTextHandler(logOutput, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
-	slog.SetDefault(logger)
+	logLevel, closeLog := setupLogging(c)
+	defer closeLog()
 
 	if !c.IsStdio() {
 		slog.Info("Starting server", "name", serverName, "version", version, "date", date)
 	}
 
+	access, err := newAccessControl(c)
+	if err != nil {
+		slog.Error("Failed to create access control", "error", err)
+		return exitConfigError
+	}
+	auditLog, err := newAuditLogger(c, os.Stdout)
+	if err != nil {
+		slog.Error("Failed to open audit log", "error", err)
+		return exitConfigError
+	}
+	defer func() { _ = auditLog.Close() }()
 	ms := metrics.NewSet()
-	client := vmanomaly.NewClient(c.VmanomalyEndpoint(), c.BearerToken(), c.CustomHeaders())
-
-	// Create tool filter that checks disabled tools from config
-	toolFilter := server.WithToolFilter(func(_ context.Context, toolsList []mcp.Tool) []mcp.Tool {
-		filtered := make([]mcp.Tool, 0, len(toolsList))
-		for _, tool := range toolsList {
-			if !c.IsToolDisabled(tool.Name) {
-				filtered = append(filtered, tool)
-			}
-		}
-		return filtered
-	})
-
-	var mcpServer *server.MCPServer
-	if logLevel <= slog.LevelDebug {
-		mcpServer = server.NewMCPServer(
-			serverName,
-			fmt.Sprintf("v%s (date: %s)", version, date),
-			server.WithRecovery(),
-			server.WithLogging(),
-			server.WithToolCapabilities(true),
-			server.WithResourceCapabilities(!c.IsResourcesDisabled(), false),
-			server.WithPromptCapabilities(false),
-			server.WithHooks(hooks.New(ms)),
-			toolFilter,
-		)
-	} else {
-		mcpServer = server.NewMCPServer(
-			serverName,
-			fmt.Sprintf("v%s (date: %s)", version, date),
-			server.WithRecovery(),
-			server.WithToolCapabilities(true),
-			server.WithResourceCapabilities(!c.IsResourcesDisabled(), false),
-			server.WithPromptCapabilities(false),
-			server.WithHooks(hooks.New(ms)),
-			toolFilter,
-		)
+	tracer, stopTracing := setupTracing(c, ms)
+	defer stopTracing()
+	instances, stopInstances, err := newInstances(c, ms)
+	if err != nil {
+		slog.Error("Failed to create vmanomaly clients", "error", err)
+		return exitError
 	}
+	reloader := newConfigReloader(c, load, instances, stopInstances, access, ms)
+	defer reloader.Stop()
 
-	tools.RegisterTools(mcpServer, client)
-
-	if !c.IsResourcesDisabled() {
-		resources.RegisterDocsResources(mcpServer)
+	mcpServer, err := newMCPServer(c, reloader, auditLog, tracer, ms, logLevel)
+	if err != nil {
+		slog.Error("Failed to create MCP server", "error", err)
+		return exitError
 	}
-
-	prompts.RegisterPromptConfigRecommendation(mcpServer)
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	go reloader.Run(reloadCtx)
 
 	// Stdio mode - simple execution
 	if c.IsStdio() {
+		if c.ForwardAuthHeader() != "" {
+			slog.Warn("MCP_FORWARD_AUTH_HEADER is ignored in stdio mode")
+		}
+		if c.IsAuthEnabled() {
+			slog.Warn("Inbound auth settings are ignored in stdio mode")
+		}
 		if err := server.ServeStdio(mcpServer); err != nil {
 			slog.Error("failed to start server in stdio mode", "error", err)
-			os.Exit(1)
+			return exitError
 		}
-		return
+		return exitOK
 	}
 
 	// SSE/HTTP mode - full server with graceful shutdown
+	if access.policy != nil && len(access.authn) == 0 {
+		slog.Warn("Roles are configured, but inbound auth is disabled, so roles are not enforced")
+	}
 	var isReady atomic.Bool
 
 	rootCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	prober := newProber(c, instances, ms)
+	if prober != nil {
+		go prober.Run(rootCtx)
+	}
+
 	mux := http.NewServeMux()
 
 	// Metrics endpoint
@@ -155,32 +129,25 @@ func main() {
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 		_, _ = w.Write([]byte("OK\n"))
 	})
-	mux.HandleFunc("/health/readiness", func(w http.ResponseWriter, _ *http.Request) {
-		if !isReady.Load() {
-			http.Error(w, "Not ready", http.StatusServiceUnavailable)
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		w.Header().Set("X-Content-Type-Options", "nosniff")
-		_, _ = w.Write([]byte("Ready\n"))
-	})
+	mux.HandleFunc("/health/readiness", readinessHandler(&isReady, prober))
+	mux.HandleFunc("/health/details", healthDetailsHandler(&isReady, prober))
 
 	// Server mode-specific handlers
 	switch c.ServerMode() {
 	case "sse":
 		slog.Info("Starting server in SSE mode", "addr", c.ListenAddr())
-		srv := server.NewSSEServer(mcpServer)
-		mux.Handle(srv.CompleteSsePath(), srv.SSEHandler())
-		mux.Handle(srv.CompleteMessagePath(), srv.MessageHandler())
+		srv := server.NewSSEServer(mcpServer, server.WithSSEContextFunc(forwardAuthContextFunc(c.ForwardAuthHeader())))
+		mux.Handle(srv.CompleteSsePath(), tracing.Middleware(auth.Middleware(reloader, srv.SSEHandler())))
+		mux.Handle(srv.CompleteMessagePath(), tracing.Middleware(auth.Middleware(reloader, srv.MessageHandler())))
 	case "http":
 		slog.Info("Starting server in HTTP mode", "addr", c.ListenAddr())
 		heartBeatOption := server.WithHeartbeatInterval(c.HeartbeatInterval())
-		srv := server.NewStreamableHTTPServer(mcpServer, heartBeatOption)
-		mux.Handle("/mcp", srv)
+		contextOption := server.WithHTTPContextFunc(forwardAuthContextFunc(c.ForwardAuthHeader()))
+		srv := server.NewStreamableHTTPServer(mcpServer, heartBeatOption, contextOption)
+		mux.Handle("/mcp", tracing.Middleware(auth.Middleware(reloader, srv)))
 	default:
 		slog.Error("Unknown server mode", "mode", c.ServerMode())
-		os.Exit(1)
+		return exitError
 	}
 
 	ongoingCtx, stopOngoingGracefully := context.WithCancel(context.Background())
@@ -195,14 +162,15 @@ func main() {
 	listener, err := net.Listen("tcp", c.ListenAddr())
 	if err != nil {
 		slog.Error("Failed to listen", "addr", c.ListenAddr(), "error", err)
-		os.Exit(1)
+		stopOngoingGracefully()
+		return exitError
 	}
 	slog.Info("Server is listening", "addr", c.ListenAddr())
 
 	go func() {
 		if err := hs.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			slog.Error("Failed to start server", "error", err)
-			os.Exit(1)
+			os.Exit(exitError)
 		}
 	}()
 
@@ -226,4 +194,5 @@ func main() {
 	}
 
 	slog.Info("Server stopped")
+	return exitOK
 }
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/auth"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+)
+
+// configCheckInterval is how often the config file is checked for changes
+const configCheckInterval = 5 * time.Second
+
+// configReloader holds the current config and applies the config file on SIGHUP or file change.
+// vmanomaly instances, disabled tools, tool timeouts, inbound auth and roles are swapped atomically without dropping sessions,
+// other settings require a restart.
+type configReloader struct {
+	cfg       atomic.Pointer[config.Config]
+	access    atomic.Pointer[accessControl]
+	load      func() (*config.Config, error)
+	instances *vmanomaly.Instances
+	ms        *metrics.Set
+
+	// mcpServer is notified about tool list changes, it is set once the server is created
+	mcpServer *server.MCPServer
+
+	mu            sync.Mutex
+	stopInstances func()
+	lastModTime   time.Time
+
+	reloads             *metrics.Counter
+	reloadErrors        *metrics.Counter
+	lastReloadOK        *metrics.Gauge
+	lastReloadOKSeconds *metrics.Gauge
+}
+
+// newConfigReloader returns a reloader of c loading new config with load and swapping clients of instances
+// and access control on reload. stopInstances stops fake backends of instances.
+func newConfigReloader(c *config.Config, load func() (*config.Config, error), instances *vmanomaly.Instances, stopInstances func(), access *accessControl, ms *metrics.Set) *configReloader {
+	r := &configReloader{
+		load:          load,
+		instances:     instances,
+		ms:            ms,
+		stopInstances: stopInstances,
+
+		reloads:             ms.GetOrCreateCounter(`mcp_vmanomaly_config_reloads_total`),
+		reloadErrors:        ms.GetOrCreateCounter(`mcp_vmanomaly_config_reload_errors_total`),
+		lastReloadOK:        ms.GetOrCreateGauge(`mcp_vmanomaly_config_last_reload_successful`, nil),
+		lastReloadOKSeconds: ms.GetOrCreateGauge(`mcp_vmanomaly_config_last_reload_success_timestamp_seconds`, nil),
+	}
+	r.cfg.Store(c)
+	r.access.Store(access)
+	r.lastReloadOK.Set(1)
+	r.lastReloadOKSeconds.Set(float64(time.Now().Unix()))
+	if fi, err := os.Stat(c.ConfigFile()); err == nil {
+		r.lastModTime = fi.ModTime()
+	}
+	return r
+}
+
+// Config returns the current config
+func (r *configReloader) Config() *config.Config {
+	return r.cfg.Load()
+}
+
+// Stop stops fake backends of the current instances
+func (r *configReloader) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopInstances()
+}
+
+// Authenticate authenticates callers with authenticators of the current config.
+// Requests pass through unauthenticated if inbound auth is disabled.
+func (r *configReloader) Authenticate(req *http.Request) (*auth.Principal, error) {
+	authn := r.access.Load().authn
+	if len(authn) == 0 {
+		return nil, nil
+	}
+	return authn.Authenticate(req)
+}
+
+// ToolFilter hides tools disabled in the current config, tools not marked read-only in read-only mode
+// and tools the caller isn't allowed to call by roles
+func (r *configReloader) ToolFilter(ctx context.Context, toolsList []mcp.Tool) []mcp.Tool {
+	c := r.Config()
+	policy := r.access.Load().policy
+	principal := auth.PrincipalFromContext(ctx)
+	filtered := make([]mcp.Tool, 0, len(toolsList))
+	for _, tool := range toolsList {
+		if !isHidden(c, tool) && policy.Allowed(principal, tool) {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+// isHidden reports whether tool is disabled or not read-only in read-only mode of c
+func isHidden(c *config.Config, tool mcp.Tool) bool {
+	return c.IsToolDisabled(tool.Name) || (c.IsReadOnly() && !auth.MatchesClass(tool, auth.ClassReadOnly))
+}
+
+// ToolAccess rejects calls of tools hidden by ToolFilter, so they can't be called by name
+func (r *configReloader) ToolAccess(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := req.Params.Name
+		c := r.Config()
+		if c.IsToolDisabled(name) {
+			return mcp.NewToolResultError(fmt.Sprintf("tool %q is disabled", name)), nil
+		}
+		tool := r.mcpServer.GetTool(name)
+		if tool == nil {
+			return next(ctx, req)
+		}
+		if c.IsReadOnly() && !auth.MatchesClass(tool.Tool, auth.ClassReadOnly) {
+			return mcp.NewToolResultError(fmt.Sprintf("tool %q is not read-only and the server runs in read-only mode", name)), nil
+		}
+		policy := r.access.Load().policy
+		principal := auth.PrincipalFromContext(ctx)
+		if !policy.Allowed(principal, tool.Tool) {
+			slog.Warn("Tool call denied by roles", "tool", name, "principal", principal.Name, "roles", policy.Roles(principal))
+			return mcp.NewToolResultError(fmt.Sprintf("principal %q is not allowed to call tool %q", principal.Name, name)), nil
+		}
+		return next(ctx, req)
+	}
+}
+
+// ToolTimeout applies per-tool timeouts of the current config
+func (r *configReloader) ToolTimeout(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if timeout := r.Config().ToolTimeout(req.Params.Name); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return next(ctx, req)
+	}
+}
+
+// Run reloads the config file on SIGHUP or when its modification time changes, until ctx is done
+func (r *configReloader) Run(ctx context.Context) {
+	path := r.Config().ConfigFile()
+	if path == "" {
+		return
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	ticker := time.NewTicker(configCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			slog.Info("Received SIGHUP, reloading config", "file", path)
+		case <-ticker.C:
+			fi, err := os.Stat(path)
+			if err != nil || fi.ModTime().Equal(r.lastModTime) {
+				continue
+			}
+			slog.Info("Config file changed, reloading config", "file", path)
+		}
+		if err := r.Reload(); err != nil {
+			slog.Error("Failed to reload config, keeping the previous one", "file", path, "error", err)
+		}
+	}
+}
+
+// Reload reads the config file and swaps vmanomaly instances, the tool filter and access control.
+// htpasswd and JWKS files are re-read as well. The previous config stays in effect if the new one is invalid.
+func (r *configReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reloads.Inc()
+	old := r.Config()
+	path := old.ConfigFile()
+	if fi, err := os.Stat(path); err == nil {
+		r.lastModTime = fi.ModTime()
+	}
+
+	c, err := r.load()
+	if err != nil {
+		r.reloadFailed()
+		return err
+	}
+	access, err := newAccessControl(c)
+	if err != nil {
+		r.reloadFailed()
+		return fmt.Errorf("failed to create access control: %w", err)
+	}
+	next, stopNext, err := newInstances(c, r.ms)
+	if err != nil {
+		r.reloadFailed()
+		return fmt.Errorf("failed to create vmanomaly clients: %w", err)
+	}
+
+	r.instances.Replace(next)
+	r.cfg.Store(c)
+	r.access.Store(access)
+	stopPrev := r.stopInstances
+	r.stopInstances = stopNext
+	// Let calls to the previous instances complete before stopping their fake backends
+	time.AfterFunc(c.Timeout(), stopPrev)
+
+	r.lastReloadOK.Set(1)
+	r.lastReloadOKSeconds.Set(float64(time.Now().Unix()))
+	warnRestartRequired(old, c)
+	if r.mcpServer != nil && (r.toolsChanged(old, c) || rolesChanged(old, c)) {
+		r.mcpServer.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+	}
+	slog.Info("Config reloaded", "file", path, "instances", len(c.Instances()), "default_instance", c.DefaultInstance())
+	return nil
+}
+
+func (r *configReloader) reloadFailed() {
+	r.reloadErrors.Inc()
+	r.lastReloadOK.Set(0)
+}
+
+// toolsChanged reports whether the set of listed tools differs between old and c
+func (r *configReloader) toolsChanged(old, c *config.Config) bool {
+	for _, tool := range r.mcpServer.ListTools() {
+		if isHidden(old, tool.Tool) != isHidden(c, tool.Tool) {
+			return true
+		}
+	}
+	return false
+}
+
+// rolesChanged reports whether roles differ between old and c, so tools listed to principals may change
+func rolesChanged(old, c *config.Config) bool {
+	return old.AuthDefaultRole() != c.AuthDefaultRole() || !reflect.DeepEqual(old.Roles(), c.Roles())
+}
+
+// warnRestartRequired logs settings that changed but can't be applied without a restart
+func warnRestartRequired(old, c *config.Config) {
+	changed := map[string]bool{
+		"MCP_SERVER_MODE":                 old.ServerMode() != c.ServerMode(),
+		"MCP_LISTEN_ADDR":                 old.ListenAddr() != c.ListenAddr(),
+		"MCP_HEARTBEAT_INTERVAL":          old.HeartbeatInterval() != c.HeartbeatInterval(),
+		"MCP_READINESS_PROBE_INTERVAL":    old.ReadinessProbeInterval() != c.ReadinessProbeInterval(),
+		"MCP_READINESS_FAILURE_THRESHOLD": old.ReadinessFailureThreshold() != c.ReadinessFailureThreshold(),
+		"MCP_DISABLE_RESOURCES":           old.IsResourcesDisabled() != c.IsResourcesDisabled(),
+		"MCP_LOG_LEVEL":                   old.LogLevel() != c.LogLevel(),
+		"MCP_LOG_FILE":                    old.LogFile() != c.LogFile(),
+		"MCP_FORWARD_AUTH_HEADER":         old.ForwardAuthHeader() != c.ForwardAuthHeader(),
+		"MCP_AUDIT_LOG":                   old.AuditLog() != c.AuditLog(),
+		"MCP_AUDIT_LOG_MAX_SIZE_MB":       old.AuditLogMaxSizeMB() != c.AuditLogMaxSizeMB(),
+		"MCP_AUDIT_LOG_MAX_BACKUPS":       old.AuditLogMaxBackups() != c.AuditLogMaxBackups(),
+		"MCP_AUDIT_REDACT_KEYS":           !slices.Equal(old.AuditRedactKeys(), c.AuditRedactKeys()),
+		"audit.redact_values":             !slices.Equal(old.AuditRedactValues(), c.AuditRedactValues()),
+		"MCP_TRACING_ENDPOINT":            old.TracingEndpoint() != c.TracingEndpoint(),
+		"MCP_TRACING_SAMPLE_RATIO":        old.TracingSampleRatio() != c.TracingSampleRatio(),
+	}
+	for name, ok := range changed {
+		if ok {
+			slog.Warn("Setting changed in config file requires a restart to take effect", "setting", name)
+		}
+	}
+}
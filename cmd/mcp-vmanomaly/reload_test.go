@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/auth"
+)
+
+// newAccessTestServer returns the MCP server of the config file content with access control applied
+// as in sse and http modes, env variables are overridden with overrides
+func newAccessTestServer(t *testing.T, content string, overrides map[string]string) *server.MCPServer {
+	t.Helper()
+	isolateEnv(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	load := func() (*config.Config, error) { return config.LoadWithOverrides(path, overrides) }
+	c, err := load()
+	if err != nil {
+		t.Fatalf("unexpected config error: %v", err)
+	}
+	access, err := newAccessControl(c)
+	if err != nil {
+		t.Fatalf("failed to create access control: %v", err)
+	}
+	ms := metrics.NewSet()
+	instances, stopInstances, err := newInstances(c, ms)
+	if err != nil {
+		t.Fatalf("failed to create vmanomaly clients: %v", err)
+	}
+	reloader := newConfigReloader(c, load, instances, stopInstances, access, ms)
+	t.Cleanup(reloader.Stop)
+	s, err := newMCPServer(c, reloader, nil, nil, ms, slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("failed to create MCP server: %v", err)
+	}
+	return s
+}
+
+// handleAs sends a JSON-RPC request to s on behalf of principal and unmarshals its result into result
+func handleAs(t *testing.T, s *server.MCPServer, principal string, method mcp.MCPMethod, params, result any) {
+	t.Helper()
+	msg, err := json.Marshal(map[string]any{"jsonrpc": mcp.JSONRPC_VERSION, "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+	ctx := auth.ContextWithPrincipal(context.Background(), &auth.Principal{Name: principal, Method: auth.MethodToken})
+	resp, ok := s.HandleMessage(ctx, msg).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("unexpected response to %s: %+v", method, resp)
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatalf("failed to marshal result: %v", err)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+}
+
+// listedTools returns names of tools listed to principal
+func listedTools(t *testing.T, s *server.MCPServer, principal string) map[string]bool {
+	t.Helper()
+	var result struct {
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	}
+	handleAs(t, s, principal, mcp.MethodToolsList, map[string]any{}, &result)
+	names := make(map[string]bool, len(result.Tools))
+	for _, tool := range result.Tools {
+		names[tool.Name] = true
+	}
+	return names
+}
+
+// callAs calls the tool by name on behalf of principal and returns the text of its result
+func callAs(t *testing.T, s *server.MCPServer, principal, tool string, args map[string]any) (string, bool) {
+	t.Helper()
+	var result struct {
+		IsError bool `json:"isError"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	handleAs(t, s, principal, mcp.MethodToolsCall, map[string]any{"name": tool, "arguments": args}, &result)
+	if len(result.Content) == 0 {
+		t.Fatalf("empty result of tool %q", tool)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func TestConfigReloader_ToolAccess(t *testing.T) {
+	const writeTool = "vmanomaly_create_detection_task"
+	const readTool = "vmanomaly_health_check"
+
+	t.Run("roles", func(t *testing.T) {
+		s := newAccessTestServer(t, `
+auth:
+  default_role: oncall
+  roles:
+    - name: oncall
+      class: read_only
+    - name: platform
+      principals: [alice]
+vmanomaly:
+  fake_backend: true
+`, nil)
+
+		listed := listedTools(t, s, "bob")
+		if listed[writeTool] || !listed[readTool] {
+			t.Fatalf("expected only read-only tools listed to bob, got %v", listed)
+		}
+		text, isError := callAs(t, s, "bob", writeTool, map[string]any{})
+		if !isError || !strings.Contains(text, `principal "bob" is not allowed to call tool "`+writeTool+`"`) {
+			t.Errorf("expected call of hidden tool denied by roles, got %q", text)
+		}
+		if text, isError := callAs(t, s, "bob", readTool, nil); isError {
+			t.Errorf("unexpected error calling allowed tool: %s", text)
+		}
+
+		// The same call reaches the tool for principals allowed by roles
+		if !listedTools(t, s, "alice")[writeTool] {
+			t.Errorf("expected %s listed to alice", writeTool)
+		}
+		if text, _ := callAs(t, s, "alice", writeTool, map[string]any{}); strings.Contains(text, "is not allowed") {
+			t.Errorf("unexpected roles denial for alice: %q", text)
+		}
+	})
+
+	t.Run("read-only mode", func(t *testing.T) {
+		s := newAccessTestServer(t, `
+vmanomaly:
+  fake_backend: true
+`, map[string]string{"MCP_READ_ONLY": "true"})
+
+		listed := listedTools(t, s, "alice")
+		if listed[writeTool] || !listed[readTool] {
+			t.Fatalf("expected only read-only tools listed in read-only mode, got %v", listed)
+		}
+		text, isError := callAs(t, s, "alice", writeTool, map[string]any{})
+		if !isError || !strings.Contains(text, `tool "`+writeTool+`" is not read-only and the server runs in read-only mode`) {
+			t.Errorf("expected call of hidden tool rejected in read-only mode, got %q", text)
+		}
+		if text, isError := callAs(t, s, "alice", readTool, nil); isError {
+			t.Errorf("unexpected error calling read-only tool: %s", text)
+		}
+	})
+
+	t.Run("disabled tool", func(t *testing.T) {
+		s := newAccessTestServer(t, `
+vmanomaly:
+  fake_backend: true
+`, map[string]string{"MCP_DISABLED_TOOLS": readTool})
+
+		if listedTools(t, s, "alice")[readTool] {
+			t.Fatalf("expected disabled tool %s not listed", readTool)
+		}
+		text, isError := callAs(t, s, "alice", readTool, nil)
+		if !isError || !strings.Contains(text, `tool "`+readTool+`" is disabled`) {
+			t.Errorf("expected call of disabled tool rejected, got %q", text)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/audit"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/config"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/cmd/mcp-vmanomaly/hooks"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/promts"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/resources"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/tools"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/tracing"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// setupLogging configures the default logger and returns its level and a function closing the log file
+func setupLogging(c *config.Config) (slog.Level, func()) {
+	var logOutput = os.Stderr
+	closeLog := func() {}
+	if c.LogFile() != "" {
+		f, err := os.OpenFile(c.LogFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open log file, using stderr: %v\n", err)
+		} else {
+			logOutput = f
+			closeLog = func() { _ = f.Close() }
+		}
+	}
+
+	var logLevel slog.Level
+	switch c.LogLevel() {
+	case "debug":
+		logLevel = slog.LevelDebug
+	case "warn":
+		logLevel = slog.LevelWarn
+	case "error":
+		logLevel = slog.LevelError
+	default:
+		logLevel = slog.LevelInfo
+	}
+
+	logger := slog.New(slog.NewTextHandler(logOutput, &slog.HandlerOptions{
+		Level: logLevel,
+	}))
+	slog.SetDefault(logger)
+
+	return logLevel, closeLog
+}
+
+// tracingShutdownTimeout limits exporting the remaining spans on exit
+const tracingShutdownTimeout = 5 * time.Second
+
+// setupTracing returns a tracer exporting spans to the endpoint of c, nil if tracing is disabled.
+// The returned function exports the remaining spans.
+func setupTracing(c *config.Config, ms *metrics.Set) (*tracing.Tracer, func()) {
+	if c.TracingEndpoint() == "" {
+		return nil, func() {}
+	}
+	tracer := tracing.NewTracer(tracing.Config{
+		Endpoint:       c.TracingEndpoint(),
+		ServiceName:    serverName,
+		ServiceVersion: version,
+		SampleRatio:    c.TracingSampleRatio(),
+	}, ms)
+	return tracer, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := tracer.Shutdown(ctx); err != nil {
+			slog.Warn("Failed to export spans on exit", "error", err)
+		}
+	}
+}
+
+// newMCPServer creates the MCP server with all tools, resources and prompts registered.
+// Tools use vmanomaly instances of the reloader and follow its current config. Tool calls are written
+// to auditLog and traced with tracer unless they are nil.
+// It fails if any tool lacks annotations, since read-only mode and roles rely on them.
+func newMCPServer(c *config.Config, reloader *configReloader, auditLog *audit.Logger, tracer *tracing.Tracer, ms *metrics.Set, logLevel slog.Level) (*server.MCPServer, error) {
+	var opts []server.ServerOption
+	if auditLog != nil {
+		// The audit log wraps all other middlewares, so denied and panicked calls are logged
+		opts = append(opts, server.WithToolHandlerMiddleware(auditLog.Middleware))
+	}
+	if tracer != nil {
+		opts = append(opts, server.WithToolHandlerMiddleware(hooks.ToolTracing(tracer)))
+	}
+	opts = append(opts, server.WithRecovery())
+	if logLevel <= slog.LevelDebug {
+		opts = append(opts, server.WithLogging())
+	}
+	opts = append(opts,
+		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(!c.IsResourcesDisabled(), false),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(hooks.New(ms)),
+		// Tool filter, access checks and timeouts follow disabled tools, roles and tool timeouts of the current config
+		server.WithToolFilter(reloader.ToolFilter),
+		server.WithToolHandlerMiddleware(reloader.ToolAccess),
+		server.WithToolHandlerMiddleware(reloader.ToolTimeout),
+	)
+	mcpServer := server.NewMCPServer(serverName, fmt.Sprintf("v%s (date: %s)", version, date), opts...)
+
+	tools.RegisterTools(mcpServer, reloader.instances)
+	tools.RegisterInstanceTools(mcpServer, reloader.instances)
+	if err := tools.CheckAnnotations(mcpServer); err != nil {
+		return nil, err
+	}
+
+	if !c.IsResourcesDisabled() {
+		resources.RegisterDocsResources(mcpServer)
+	}
+
+	prompts.RegisterPromptConfigRecommendation(mcpServer)
+
+	reloader.mcpServer = mcpServer
+	return mcpServer, nil
+}
@@ -7,6 +7,8 @@ require (
 	github.com/blevesearch/bleve/v2 v2.5.5
 	github.com/mark3labs/mcp-go v0.43.0
 	github.com/tmc/langchaingo v0.1.14
+	golang.org/x/crypto v0.41.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -55,5 +57,4 @@ require (
 	golang.org/x/sys v0.35.0 // indirect
 	golang.org/x/text v0.28.0 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,102 @@
+// Package auth authenticates callers of HTTP and SSE transports
+// with static bearer tokens, htpasswd basic auth or JWTs signed with keys of a local JWKS.
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Authentication methods of a principal
+const (
+	MethodToken = "token"
+	MethodBasic = "basic"
+	MethodJWT   = "jwt"
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	// Name identifies the caller: token name, htpasswd user or the principal claim of the JWT
+	Name string
+	// Method is the authentication method: token, basic or jwt
+	Method string
+	// Claims are claims of the JWT, nil for other methods
+	Claims map[string]any
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns ctx carrying the authenticated principal
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal set by ContextWithPrincipal or nil if the caller isn't authenticated
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// ErrNoCredentials is returned by authenticators if the request has no credentials they support
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticator authenticates HTTP requests
+type Authenticator interface {
+	// Authenticate returns the principal of r.
+	// It returns ErrNoCredentials if r has no credentials supported by the authenticator.
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Chain tries authenticators in order until one of them finds credentials it supports
+type Chain []Authenticator
+
+// Authenticate implements Authenticator
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// Middleware rejects requests that a fails to authenticate with 401 and puts the principal into the request context.
+// Requests are passed through unauthenticated if a returns a nil principal without error, e.g. if auth is disabled.
+func Middleware(a Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r)
+		if err != nil {
+			if !errors.Is(err, ErrNoCredentials) {
+				slog.Warn("Authentication failed", "remote_addr", r.RemoteAddr, "path", r.URL.Path, "error", err)
+			}
+			w.Header().Add("WWW-Authenticate", `Bearer realm="mcp-vmanomaly"`)
+			w.Header().Add("WWW-Authenticate", `Basic realm="mcp-vmanomaly"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if p != nil {
+			r = r.WithContext(ContextWithPrincipal(r.Context(), p))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerToken returns the token of the Bearer Authorization header of r
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// looksLikeJWT reports whether token has the three dot-separated parts of a compact JWT
+func looksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
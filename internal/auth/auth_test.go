@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newRequest(authorization string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	return r
+}
+
+func TestTokens(t *testing.T) {
+	a := NewTokens(map[string]string{"secret-1": "ci", "secret-2": "alice"})
+
+	p, err := a.Authenticate(newRequest("Bearer secret-2"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name != "alice" || p.Method != MethodToken {
+		t.Errorf("unexpected principal %+v", p)
+	}
+
+	if _, err := a.Authenticate(newRequest("Bearer wrong")); err == nil || err == ErrNoCredentials {
+		t.Errorf("expected invalid token error, got %v", err)
+	}
+	if _, err := a.Authenticate(newRequest("Bearer header.payload.signature")); err != ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials for unknown JWT, got %v", err)
+	}
+	if _, err := a.Authenticate(newRequest("")); err != ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+	if _, err := a.Authenticate(newRequest("Basic dXNlcjpwYXNz")); err != ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials for basic auth, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestHtpasswd(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	// {SHA} hash of "sha-pass"
+	path := writeFile(t, "htpasswd", "# users\nalice:"+string(hash)+"\n\nbob:{SHA}xO2etOilyqtV8o1RvvnmkeBx7QI=\n")
+	a, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		wantErr  bool
+	}{
+		{name: "bcrypt", user: "alice", password: "bcrypt-pass"},
+		{name: "sha", user: "bob", password: "sha-pass"},
+		{name: "wrong password", user: "alice", password: "sha-pass", wantErr: true},
+		{name: "unknown user", user: "carol", password: "bcrypt-pass", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRequest("")
+			r.SetBasicAuth(tt.user, tt.password)
+			p, err := a.Authenticate(r)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got principal %+v", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Name != tt.user || p.Method != MethodBasic {
+				t.Errorf("unexpected principal %+v", p)
+			}
+		})
+	}
+
+	if _, err := a.Authenticate(newRequest("Bearer token")); err != ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials for bearer token, got %v", err)
+	}
+}
+
+func TestLoadHtpasswd_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"no hash":     "alice\n",
+		"md5 hash":    "alice:$apr1$x7kPz1Yb$Wl5ZvcYmNtZ0yTzOgBU4C/\n",
+		"crypt hash":  "alice:rqXexS6ZhobKA\n",
+		"empty user":  ":{SHA}xO2etOilyqtV8o1RvvnmkeBx7QI=\n",
+		"empty value": "alice:\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadHtpasswd(writeFile(t, "htpasswd", content)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+	if _, err := LoadHtpasswd(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var got *Principal
+	next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = PrincipalFromContext(r.Context())
+	})
+	h := Middleware(Chain{NewTokens(map[string]string{"secret": "ci"})}, next)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantPrincipal string
+	}{
+		{name: "valid token", authorization: "Bearer secret", wantStatus: http.StatusOK, wantPrincipal: "ci"},
+		{name: "invalid token", authorization: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+		{name: "no credentials", wantStatus: http.StatusUnauthorized},
+		{name: "unsupported scheme", authorization: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, newRequest(tt.authorization))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if len(w.Header().Values("WWW-Authenticate")) == 0 {
+					t.Errorf("expected WWW-Authenticate header")
+				}
+				return
+			}
+			if got == nil || got.Name != tt.wantPrincipal {
+				t.Errorf("expected principal %q, got %+v", tt.wantPrincipal, got)
+			}
+		})
+	}
+}
+
+type anonymous struct{}
+
+func (anonymous) Authenticate(_ *http.Request) (*Principal, error) {
+	return nil, nil
+}
+
+func TestMiddleware_Anonymous(t *testing.T) {
+	called := false
+	h := Middleware(anonymous{}, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		called = true
+		if p := PrincipalFromContext(r.Context()); p != nil {
+			t.Errorf("expected no principal, got %+v", p)
+		}
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest(""))
+	if !called || w.Code != http.StatusOK {
+		t.Fatalf("expected request to pass through, got status %d", w.Code)
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	if p := PrincipalFromContext(context.Background()); p != nil {
+		t.Fatalf("expected nil principal, got %+v", p)
+	}
+	want := &Principal{Name: "alice", Method: MethodBasic}
+	if p := PrincipalFromContext(ContextWithPrincipal(context.Background(), want)); p != want {
+		t.Fatalf("expected %+v, got %+v", want, p)
+	}
+}
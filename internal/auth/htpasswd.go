@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Htpasswd authenticates requests with basic auth against users of an htpasswd file
+type Htpasswd struct {
+	hashes map[string]string
+}
+
+// LoadHtpasswd reads the htpasswd file at path.
+// Only bcrypt (htpasswd -B) and SHA-1 (htpasswd -s) hashes are supported.
+func LoadHtpasswd(path string) (*Htpasswd, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open htpasswd file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := &Htpasswd{hashes: make(map[string]string)}
+	sc := bufio.NewScanner(f)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" || hash == "" {
+			return nil, fmt.Errorf("invalid line %d of htpasswd file %q: user:hash expected", lineNum, path)
+		}
+		if !isBcrypt(hash) && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("unsupported hash of user %q in htpasswd file %q: use bcrypt (htpasswd -B)", user, path)
+		}
+		h.hashes[user] = hash
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read htpasswd file %q: %w", path, err)
+	}
+	return h, nil
+}
+
+// Authenticate implements Authenticator
+func (h *Htpasswd) Authenticate(r *http.Request) (*Principal, error) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	hash, ok := h.hashes[user]
+	if !ok || !checkPassword(hash, password) {
+		return nil, fmt.Errorf("invalid password of user %q", user)
+	}
+	return &Principal{Name: user, Method: MethodBasic}, nil
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func checkPassword(hash, password string) bool {
+	if isBcrypt(hash) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+	sum := sha1.Sum([]byte(password))
+	want := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(want)) == 1
+}
@@ -0,0 +1,319 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// jwtLeeway is the allowed clock skew when checking exp and nbf claims
+const jwtLeeway = time.Minute
+
+// JWTConfig configures validation of JWTs
+type JWTConfig struct {
+	// Audience must be one of aud claim values
+	Audience string
+	// Issuer must match the iss claim
+	Issuer string
+	// PrincipalClaim names the claim used as principal name, "sub" if empty
+	PrincipalClaim string
+}
+
+// JWT authenticates requests with bearer JWTs signed with keys of a JWKS.
+// RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512 and EdDSA algorithms are supported.
+type JWT struct {
+	keys []jwk
+	cfg  JWTConfig
+
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// jwk is a parsed JSON Web Key
+type jwk struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// LoadJWT reads the JWKS file at path and returns an authenticator validating JWTs with cfg
+func LoadJWT(path string, cfg JWTConfig) (*JWT, error) {
+	if cfg.Audience == "" || cfg.Issuer == "" {
+		return nil, fmt.Errorf("JWT audience and issuer are required")
+	}
+	if cfg.PrincipalClaim == "" {
+		cfg.PrincipalClaim = "sub"
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file %q: %w", path, err)
+	}
+	return &JWT{keys: keys, cfg: cfg, now: time.Now}, nil
+}
+
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses public signing keys of a JWKS, skipping encryption keys
+func parseJWKS(data []byte) ([]jwk, error) {
+	var set struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var keys []jwk
+	for i, raw := range set.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key #%d (kid %q): %w", i+1, raw.Kid, err)
+		}
+		keys = append(keys, jwk{kid: raw.Kid, alg: raw.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing keys found")
+	}
+	return keys, nil
+}
+
+func (raw rawJWK) publicKey() (crypto.PublicKey, error) {
+	switch raw.Kty {
+	case "RSA":
+		n, err := decodeBigInt(raw.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := decodeBigInt(raw.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid e")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch raw.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		// Coordinates are padded to the curve size, as required by RFC 7518
+		size := (curve.Params().BitSize + 7) / 8
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil || len(x) != size {
+			return nil, fmt.Errorf("invalid x")
+		}
+		y, err := base64.RawURLEncoding.DecodeString(raw.Y)
+		if err != nil || len(y) != size {
+			return nil, fmt.Errorf("invalid y")
+		}
+		key, err := ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, fmt.Errorf("invalid point: %w", err)
+		}
+		return key, nil
+	case "OKP":
+		if raw.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid x")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", raw.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Authenticate implements Authenticator. Bearer tokens that don't look like JWTs are left to other authenticators.
+func (j *JWT) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok || !looksLikeJWT(token) {
+		return nil, ErrNoCredentials
+	}
+	claims, err := j.verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT: %w", err)
+	}
+	name, _ := claims[j.cfg.PrincipalClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("invalid JWT: %q claim is missing", j.cfg.PrincipalClaim)
+	}
+	return &Principal{Name: name, Method: MethodJWT, Claims: claims}, nil
+}
+
+// verify checks the signature and registered claims of token and returns its claims
+func (j *JWT) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("failed to decode header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, k := range j.keys {
+		if header.Kid != "" && k.kid != "" && header.Kid != k.kid {
+			continue
+		}
+		if k.alg != "" && k.alg != header.Alg {
+			continue
+		}
+		if verifySignature(header.Alg, k.key, signed, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("signature verification failed (alg %q, kid %q)", header.Alg, header.Kid)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("failed to decode claims: %w", err)
+	}
+	if err := j.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkClaims checks expiration, issuer and audience of claims
+func (j *JWT) checkClaims(claims map[string]any) error {
+	now := j.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("exp claim is missing")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return fmt.Errorf("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("token is not valid yet")
+	}
+	if iss, _ := claims["iss"].(string); iss != j.cfg.Issuer {
+		return fmt.Errorf("unexpected issuer %q", iss)
+	}
+	var audience []string
+	switch aud := claims["aud"].(type) {
+	case string:
+		audience = []string{aud}
+	case []any:
+		for _, v := range aud {
+			if s, ok := v.(string); ok {
+				audience = append(audience, s)
+			}
+		}
+	}
+	if !slices.Contains(audience, j.cfg.Audience) {
+		return fmt.Errorf("unexpected audience %q", audience)
+	}
+	return nil
+}
+
+func decodeSegment(s string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifySignature reports whether sig is a valid signature of signed with key using the JWS algorithm alg
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) bool {
+	if len(alg) < 5 {
+		return false
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if hash == 0 {
+			return false
+		}
+		h := hash.New()
+		h.Write(signed)
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(k, hash, h.Sum(nil), sig) == nil
+		case "PS":
+			return rsa.VerifyPSS(k, hash, h.Sum(nil), sig, nil) == nil
+		}
+	case *ecdsa.PublicKey:
+		// ES512 uses P-521 and SHA-512
+		curves := map[string]elliptic.Curve{"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521()}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if curves[alg] != k.Curve || len(sig) != 2*size {
+			return false
+		}
+		h := hash.New()
+		h.Write(signed)
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k, h.Sum(nil), r, s)
+	case ed25519.PublicKey:
+		return alg == "EdDSA" && ed25519.Verify(k, signed, sig)
+	}
+	return false
+}
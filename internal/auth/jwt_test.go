@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ec      *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	return &testKeys{rsa: rsaKey, ec: ecKey, ed25519: edKey}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (k *testKeys) jwks(t *testing.T) string {
+	t.Helper()
+	ecPub, err := k.ec.PublicKey.Bytes()
+	if err != nil {
+		t.Fatalf("failed to encode EC key: %v", err)
+	}
+	keys := []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecPub[1:33]), "y": b64(ecPub[33:])},
+		{"kty": "OKP", "kid": "ed", "alg": "EdDSA", "crv": "Ed25519", "x": b64(k.ed25519.Public().(ed25519.PublicKey))},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "unused", "e": "unused"},
+	}
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatalf("failed to marshal JWKS: %v", err)
+	}
+	return string(data)
+}
+
+// sign returns a JWT with claims signed with the key of kid using alg
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+
+	var sig []byte
+	var err error
+	switch alg {
+	case "RS256":
+		h := crypto.SHA256.New()
+		h.Write([]byte(signed))
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, h.Sum(nil))
+	case "PS384":
+		h := crypto.SHA384.New()
+		h.Write([]byte(signed))
+		sig, err = rsa.SignPSS(rand.Reader, k.rsa, crypto.SHA384, h.Sum(nil), nil)
+	case "ES256":
+		h := crypto.SHA256.New()
+		h.Write([]byte(signed))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k.ec, h.Sum(nil))
+		if err == nil {
+			sig = make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+		}
+	case "EdDSA":
+		sig = ed25519.Sign(k.ed25519, []byte(signed))
+	case "none":
+	}
+	if err != nil {
+		t.Fatalf("failed to sign JWT: %v", err)
+	}
+	return signed + "." + b64(sig)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":    "alice",
+		"iss":    "https://idp.example.com",
+		"aud":    []string{"other", "mcp-vmanomaly"},
+		"exp":    testNow.Add(time.Hour).Unix(),
+		"nbf":    testNow.Add(-time.Hour).Unix(),
+		"groups": []string{"sre"},
+	}
+}
+
+func withClaim(name string, value any) map[string]any {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, name)
+	} else {
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestJWT(t *testing.T) {
+	keys := newTestKeys(t)
+	a, err := LoadJWT(writeFile(t, "jwks.json", keys.jwks(t)), JWTConfig{
+		Audience: "mcp-vmanomaly",
+		Issuer:   "https://idp.example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a.now = func() time.Time { return testNow }
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{name: "RS256", token: keys.sign(t, "RS256", "rsa", validClaims())},
+		{name: "PS384", token: keys.sign(t, "PS384", "rsa", validClaims())},
+		{name: "ES256", token: keys.sign(t, "ES256", "ec", validClaims())},
+		{name: "EdDSA", token: keys.sign(t, "EdDSA", "ed", validClaims())},
+		{name: "without kid", token: keys.sign(t, "ES256", "", validClaims())},
+		{name: "string audience", token: keys.sign(t, "RS256", "rsa", withClaim("aud", "mcp-vmanomaly"))},
+		{name: "clock skew", token: keys.sign(t, "RS256", "rsa", withClaim("exp", testNow.Add(-30*time.Second).Unix()))},
+		{name: "wrong key", token: keys.sign(t, "RS256", "ec", validClaims()), wantErr: "signature verification failed"},
+		{name: "alg none", token: keys.sign(t, "none", "rsa", validClaims()), wantErr: "signature verification failed"},
+		{name: "alg mismatch", token: keys.sign(t, "ES256", "ed", validClaims()), wantErr: "signature verification failed"},
+		{name: "tampered", token: tamper(keys.sign(t, "RS256", "rsa", validClaims())), wantErr: "signature verification failed"},
+		{name: "expired", token: keys.sign(t, "RS256", "rsa", withClaim("exp", testNow.Add(-time.Hour).Unix())), wantErr: "token is expired"},
+		{name: "no exp", token: keys.sign(t, "RS256", "rsa", withClaim("exp", nil)), wantErr: "exp claim is missing"},
+		{name: "not valid yet", token: keys.sign(t, "RS256", "rsa", withClaim("nbf", testNow.Add(time.Hour).Unix())), wantErr: "not valid yet"},
+		{name: "wrong issuer", token: keys.sign(t, "RS256", "rsa", withClaim("iss", "https://evil.example.com")), wantErr: "unexpected issuer"},
+		{name: "wrong audience", token: keys.sign(t, "RS256", "rsa", withClaim("aud", "other")), wantErr: "unexpected audience"},
+		{name: "no audience", token: keys.sign(t, "RS256", "rsa", withClaim("aud", nil)), wantErr: "unexpected audience"},
+		{name: "no subject", token: keys.sign(t, "RS256", "rsa", withClaim("sub", nil)), wantErr: `"sub" claim is missing`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := a.Authenticate(newRequest("Bearer " + tt.token))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Name != "alice" || p.Method != MethodJWT || p.Claims["groups"] == nil {
+				t.Errorf("unexpected principal %+v", p)
+			}
+		})
+	}
+
+	if _, err := a.Authenticate(newRequest("Bearer static-token")); err != ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials for non-JWT token, got %v", err)
+	}
+}
+
+func TestChain_TokensAndJWT(t *testing.T) {
+	keys := newTestKeys(t)
+	j, err := LoadJWT(writeFile(t, "jwks.json", keys.jwks(t)), JWTConfig{
+		Audience: "mcp-vmanomaly",
+		Issuer:   "https://idp.example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j.now = func() time.Time { return testNow }
+	// Static tokens come first, as configured by the server
+	chain := Chain{NewTokens(map[string]string{"secret": "ci"}), j}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantName   string
+		wantMethod string
+		wantErr    string
+	}{
+		{name: "static token", token: "secret", wantName: "ci", wantMethod: MethodToken},
+		{name: "JWT", token: keys.sign(t, "RS256", "rsa", validClaims()), wantName: "alice", wantMethod: MethodJWT},
+		{name: "unknown static token", token: "wrong", wantErr: "unknown bearer token"},
+		{name: "invalid JWT", token: keys.sign(t, "RS256", "rsa", withClaim("aud", "other")), wantErr: "unexpected audience"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := chain.Authenticate(newRequest("Bearer " + tt.token))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Name != tt.wantName || p.Method != tt.wantMethod {
+				t.Errorf("unexpected principal %+v", p)
+			}
+		})
+	}
+}
+
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	claims := validClaims()
+	claims["sub"] = "admin"
+	payload, _ := json.Marshal(claims)
+	return parts[0] + "." + b64(payload) + "." + parts[2]
+}
+
+func TestJWT_PrincipalClaim(t *testing.T) {
+	keys := newTestKeys(t)
+	a, err := LoadJWT(writeFile(t, "jwks.json", keys.jwks(t)), JWTConfig{
+		Audience:       "mcp-vmanomaly",
+		Issuer:         "https://idp.example.com",
+		PrincipalClaim: "email",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a.now = func() time.Time { return testNow }
+
+	p, err := a.Authenticate(newRequest("Bearer " + keys.sign(t, "EdDSA", "ed", withClaim("email", "alice@example.com"))))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name != "alice@example.com" {
+		t.Errorf("expected principal from email claim, got %q", p.Name)
+	}
+}
+
+func TestLoadJWT_Invalid(t *testing.T) {
+	keys := newTestKeys(t)
+	cfg := JWTConfig{Audience: "mcp-vmanomaly", Issuer: "https://idp.example.com"}
+	tests := []struct {
+		name string
+		jwks string
+		cfg  JWTConfig
+	}{
+		{name: "no audience", jwks: keys.jwks(t), cfg: JWTConfig{Issuer: cfg.Issuer}},
+		{name: "no issuer", jwks: keys.jwks(t), cfg: JWTConfig{Audience: cfg.Audience}},
+		{name: "invalid JSON", jwks: "{", cfg: cfg},
+		{name: "no keys", jwks: `{"keys":[]}`, cfg: cfg},
+		{name: "unsupported key type", jwks: `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`, cfg: cfg},
+		{name: "unsupported curve", jwks: `{"keys":[{"kty":"EC","crv":"P-224","x":"AA","y":"AA"}]}`, cfg: cfg},
+		{name: "invalid point", jwks: `{"keys":[{"kty":"EC","crv":"P-256","x":"` + b64(make([]byte, 32)) + `","y":"` + b64(make([]byte, 32)) + `"}]}`, cfg: cfg},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadJWT(writeFile(t, "jwks.json", tt.jwks), tt.cfg); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
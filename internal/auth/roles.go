@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tool classes derived from tool annotations
+const (
+	// ClassReadOnly matches tools annotated with ReadOnlyHint
+	ClassReadOnly = "read_only"
+	// ClassNonDestructive matches read-only tools and tools annotated with DestructiveHint=false
+	ClassNonDestructive = "non_destructive"
+)
+
+// Role allows its principals to call tools matching Tools patterns and Class
+type Role struct {
+	Name string
+	// Principals are names of principals having the role
+	Principals []string
+	// Tools are tool names or patterns in path.Match syntax, e.g. vmanomaly_get_*. Empty means all tools.
+	Tools []string
+	// Class restricts tools to an annotation class: read_only or non_destructive. Empty means any class.
+	Class string
+}
+
+// allows reports whether the role allows calling tool
+func (r *Role) allows(tool mcp.Tool) bool {
+	if !MatchesClass(tool, r.Class) {
+		return false
+	}
+	if len(r.Tools) == 0 {
+		return true
+	}
+	for _, pattern := range r.Tools {
+		if ok, _ := path.Match(pattern, tool.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesClass reports whether annotations of tool belong to class, empty class matches all tools.
+// Hints that aren't set are treated as defined by MCP: tools aren't read-only and are destructive.
+func MatchesClass(tool mcp.Tool, class string) bool {
+	readOnly := tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint
+	switch class {
+	case "":
+		return true
+	case ClassReadOnly:
+		return readOnly
+	case ClassNonDestructive:
+		return readOnly || (tool.Annotations.DestructiveHint != nil && !*tool.Annotations.DestructiveHint)
+	default:
+		return false
+	}
+}
+
+// Policy maps principals to roles
+type Policy struct {
+	roles       map[string][]*Role
+	defaultRole *Role
+}
+
+// NewPolicy returns a policy of roles. Principals without roles get defaultRole,
+// or aren't allowed to call any tool if it is empty.
+func NewPolicy(roles []Role, defaultRole string) (*Policy, error) {
+	p := &Policy{roles: make(map[string][]*Role)}
+	byName := make(map[string]*Role, len(roles))
+	for i := range roles {
+		r := &roles[i]
+		if r.Name == "" {
+			return nil, fmt.Errorf("name of role #%d is required", i+1)
+		}
+		if byName[r.Name] != nil {
+			return nil, fmt.Errorf("duplicate role %q", r.Name)
+		}
+		if r.Class != "" && r.Class != ClassReadOnly && r.Class != ClassNonDestructive {
+			return nil, fmt.Errorf("class of role %q must be %q or %q, got %q", r.Name, ClassReadOnly, ClassNonDestructive, r.Class)
+		}
+		for _, pattern := range r.Tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid tool pattern %q of role %q: %w", pattern, r.Name, err)
+			}
+		}
+		byName[r.Name] = r
+		for _, principal := range r.Principals {
+			p.roles[principal] = append(p.roles[principal], r)
+		}
+	}
+	if defaultRole != "" {
+		p.defaultRole = byName[defaultRole]
+		if p.defaultRole == nil {
+			return nil, fmt.Errorf("default role %q is not defined", defaultRole)
+		}
+	}
+	return p, nil
+}
+
+// Roles returns names of roles of the principal
+func (p *Policy) Roles(principal *Principal) []string {
+	if p == nil || principal == nil {
+		return nil
+	}
+	var names []string
+	for _, r := range p.rolesOf(principal) {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func (p *Policy) rolesOf(principal *Principal) []*Role {
+	if roles := p.roles[principal.Name]; len(roles) > 0 {
+		return roles
+	}
+	if p.defaultRole != nil {
+		return []*Role{p.defaultRole}
+	}
+	return nil
+}
+
+// Allowed reports whether principal may call tool.
+// Everything is allowed if the policy is nil or the principal is nil, i.e. the caller isn't authenticated.
+func (p *Policy) Allowed(principal *Principal, tool mcp.Tool) bool {
+	if p == nil || principal == nil {
+		return true
+	}
+	for _, r := range p.rolesOf(principal) {
+		if r.allows(tool) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newTool(name string, readOnly, destructive *bool) mcp.Tool {
+	return mcp.NewTool(name, mcp.WithToolAnnotation(mcp.ToolAnnotation{
+		ReadOnlyHint:    readOnly,
+		DestructiveHint: destructive,
+	}))
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func TestMatchesClass(t *testing.T) {
+	readOnly := newTool("vmanomaly_list_models", boolPtr(true), boolPtr(false))
+	creating := newTool("vmanomaly_run_detection", boolPtr(false), boolPtr(false))
+	destructive := newTool("vmanomaly_cancel_task", boolPtr(false), boolPtr(true))
+	unannotated := newTool("vmanomaly_unknown", nil, nil)
+
+	tests := []struct {
+		class string
+		tool  mcp.Tool
+		want  bool
+	}{
+		{class: "", tool: unannotated, want: true},
+		{class: ClassReadOnly, tool: readOnly, want: true},
+		{class: ClassReadOnly, tool: creating, want: false},
+		{class: ClassReadOnly, tool: unannotated, want: false},
+		{class: ClassNonDestructive, tool: readOnly, want: true},
+		{class: ClassNonDestructive, tool: creating, want: true},
+		{class: ClassNonDestructive, tool: destructive, want: false},
+		{class: ClassNonDestructive, tool: unannotated, want: false},
+		{class: "unknown", tool: readOnly, want: false},
+	}
+	for _, tt := range tests {
+		if got := MatchesClass(tt.tool, tt.class); got != tt.want {
+			t.Errorf("MatchesClass(%s, %q) = %v, want %v", tt.tool.Name, tt.class, got, tt.want)
+		}
+	}
+}
+
+func TestPolicy_Allowed(t *testing.T) {
+	policy, err := NewPolicy([]Role{
+		{Name: "oncall", Class: ClassReadOnly},
+		{Name: "platform", Principals: []string{"alice"}},
+		{Name: "ci", Principals: []string{"ci", "alice"}, Tools: []string{"vmanomaly_validate_config", "vmanomaly_get_*"}},
+		{Name: "tasks", Principals: []string{"bob"}, Tools: []string{"vmanomaly_*_task*"}, Class: ClassNonDestructive},
+	}, "oncall")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	listModels := newTool("vmanomaly_list_models", boolPtr(true), boolPtr(false))
+	getSchema := newTool("vmanomaly_get_model_schema", boolPtr(true), boolPtr(false))
+	validate := newTool("vmanomaly_validate_config", boolPtr(true), boolPtr(false))
+	createTask := newTool("vmanomaly_create_task", boolPtr(false), boolPtr(false))
+	cancelTask := newTool("vmanomaly_cancel_task", boolPtr(false), boolPtr(true))
+
+	tests := []struct {
+		principal *Principal
+		tool      mcp.Tool
+		want      bool
+	}{
+		// Unauthenticated callers aren't restricted
+		{principal: nil, tool: cancelTask, want: true},
+		// Principals without roles get the default role
+		{principal: &Principal{Name: "carol"}, tool: listModels, want: true},
+		{principal: &Principal{Name: "carol"}, tool: createTask, want: false},
+		// Any role of the principal allows the tool
+		{principal: &Principal{Name: "alice"}, tool: cancelTask, want: true},
+		{principal: &Principal{Name: "ci"}, tool: validate, want: true},
+		{principal: &Principal{Name: "ci"}, tool: getSchema, want: true},
+		{principal: &Principal{Name: "ci"}, tool: listModels, want: false},
+		// Both patterns and class must match
+		{principal: &Principal{Name: "bob"}, tool: createTask, want: true},
+		{principal: &Principal{Name: "bob"}, tool: cancelTask, want: false},
+		{principal: &Principal{Name: "bob"}, tool: listModels, want: false},
+	}
+	for _, tt := range tests {
+		name := "<nil>"
+		if tt.principal != nil {
+			name = tt.principal.Name
+		}
+		if got := policy.Allowed(tt.principal, tt.tool); got != tt.want {
+			t.Errorf("Allowed(%s, %s) = %v, want %v", name, tt.tool.Name, got, tt.want)
+		}
+	}
+
+	if got := policy.Roles(&Principal{Name: "alice"}); !slices.Equal(got, []string{"platform", "ci"}) {
+		t.Errorf("unexpected roles of alice: %v", got)
+	}
+	if got := policy.Roles(&Principal{Name: "carol"}); !slices.Equal(got, []string{"oncall"}) {
+		t.Errorf("unexpected roles of carol: %v", got)
+	}
+}
+
+func TestPolicy_NoDefaultRole(t *testing.T) {
+	policy, err := NewPolicy([]Role{{Name: "platform", Principals: []string{"alice"}}}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tool := newTool("vmanomaly_list_models", boolPtr(true), boolPtr(false))
+	if policy.Allowed(&Principal{Name: "carol"}, tool) {
+		t.Errorf("expected principal without roles to be denied")
+	}
+
+	var nilPolicy *Policy
+	if !nilPolicy.Allowed(&Principal{Name: "carol"}, tool) {
+		t.Errorf("expected nil policy to allow everything")
+	}
+}
+
+func TestNewPolicy_Invalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		roles       []Role
+		defaultRole string
+	}{
+		{name: "no name", roles: []Role{{Principals: []string{"alice"}}}},
+		{name: "duplicate", roles: []Role{{Name: "a"}, {Name: "a"}}},
+		{name: "unknown class", roles: []Role{{Name: "a", Class: "readonly"}}},
+		{name: "invalid pattern", roles: []Role{{Name: "a", Tools: []string{"vmanomaly_["}}}},
+		{name: "undefined default role", roles: []Role{{Name: "a"}}, defaultRole: "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPolicy(tt.roles, tt.defaultRole); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
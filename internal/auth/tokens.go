@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+)
+
+// Tokens authenticates requests with static bearer tokens
+type Tokens struct {
+	digests [][sha256.Size]byte
+	names   []string
+}
+
+// NewTokens returns an authenticator accepting tokens, keyed by token, with values naming their principals
+func NewTokens(tokens map[string]string) *Tokens {
+	t := &Tokens{}
+	for token, name := range tokens {
+		t.digests = append(t.digests, sha256.Sum256([]byte(token)))
+		t.names = append(t.names, name)
+	}
+	return t
+}
+
+// Authenticate implements Authenticator
+func (t *Tokens) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	// Compare digests of all tokens in constant time, so response time doesn't reveal tokens
+	digest := sha256.Sum256([]byte(token))
+	match := -1
+	for i := range t.digests {
+		if subtle.ConstantTimeCompare(digest[:], t.digests[i][:]) == 1 {
+			match = i
+		}
+	}
+	if match < 0 {
+		if looksLikeJWT(token) {
+			// Leave JWTs to the JWT authenticator further in the chain
+			return nil, ErrNoCredentials
+		}
+		return nil, fmt.Errorf("unknown bearer token")
+	}
+	return &Principal{Name: t.names[match], Method: MethodToken}, nil
+}
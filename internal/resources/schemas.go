@@ -0,0 +1,48 @@
+package resources
+
+import (
+	_ "embed"
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// modelSchemasJSON is generated by scripts/update-model-schemas.sh
+//
+//go:embed model_schemas.json
+var modelSchemasJSON []byte
+
+// ModelSchemaSnapshot is a snapshot of available models and their JSON schemas taken from a vmanomaly version.
+// It's served by model tools when vmanomaly is unreachable.
+type ModelSchemaSnapshot struct {
+	// Version is the vmanomaly version the snapshot was taken from
+	Version     string                    `json:"version"`
+	GeneratedAt string                    `json:"generated_at"`
+	Models      []string                  `json:"models"`
+	Schemas     map[string]map[string]any `json:"schemas"`
+}
+
+var modelSchemas = sync.OnceValue(func() *ModelSchemaSnapshot {
+	snapshot, err := parseModelSchemas(modelSchemasJSON)
+	if err != nil {
+		log.Printf("failed to parse embedded model schemas: %v", err)
+		return nil
+	}
+	return snapshot
+})
+
+// ModelSchemas returns the embedded model schema snapshot, nil if it hasn't been generated
+func ModelSchemas() *ModelSchemaSnapshot {
+	return modelSchemas()
+}
+
+func parseModelSchemas(data []byte) (*ModelSchemaSnapshot, error) {
+	var snapshot ModelSchemaSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	if snapshot.Version == "" {
+		return nil, nil
+	}
+	return &snapshot, nil
+}
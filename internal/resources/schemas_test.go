@@ -0,0 +1,49 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+)
+
+func TestParseModelSchemas(t *testing.T) {
+	if _, err := parseModelSchemas(modelSchemasJSON); err != nil {
+		t.Fatalf("failed to parse embedded model schemas: %v", err)
+	}
+	if snapshot := ModelSchemas(); snapshot != nil {
+		for _, model := range snapshot.Models {
+			if _, ok := snapshot.Schemas[model]; !ok {
+				t.Errorf("embedded snapshot of vmanomaly %s has no schema of model %q", snapshot.Version, model)
+			}
+		}
+	}
+
+	snapshot, err := parseModelSchemas([]byte(`{"version":"","models":[],"schemas":{}}`))
+	if err != nil || snapshot != nil {
+		t.Errorf("expected no snapshot without version, got %v, %v", snapshot, err)
+	}
+
+	snapshot, err = parseModelSchemas([]byte(`{"version":"v1.28.0","generated_at":"2026-10-17T00:00:00Z","models":["zscore"],"schemas":{"zscore":{"title":"ZScoreModel"}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if snapshot.Version != "v1.28.0" || snapshot.Schemas["zscore"]["title"] != "ZScoreModel" {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+
+	if _, err := parseModelSchemas([]byte(`{"version":`)); err == nil {
+		t.Errorf("expected error for malformed snapshot")
+	}
+}
+
+func TestModelSchemas_CoverModelClasses(t *testing.T) {
+	snapshot := ModelSchemas()
+	if snapshot == nil {
+		t.Fatal("embedded model schema snapshot is empty, so model tools have no offline fallback; run scripts/update-model-schemas.sh against vmanomaly")
+	}
+	for _, class := range vmanomaly.ModelClasses {
+		if len(snapshot.Schemas[string(class)]) == 0 {
+			t.Errorf("embedded snapshot of vmanomaly %s has no schema of model class %q", snapshot.Version, class)
+		}
+	}
+}
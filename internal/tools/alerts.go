@@ -20,7 +20,7 @@ type GenerateAlertRuleArgs struct {
 	InferEvery       string  `json:"infer_every,omitempty" jsonschema:"description=Inference cadence (defaults to step value)"`
 }
 
-func RegisterAlertTools(s *server.MCPServer, client *vmanomaly.Client) {
+func RegisterAlertTools(s *server.MCPServer, client vmanomaly.API) {
 	generateAlertRuleTool := mcp.NewTool(
 		"vmanomaly_generate_alert_rule",
 		mcp.WithDescription("Generate a VMAlert rule YAML configuration for anomaly score alerting. Creates a production-ready vmalert rule that triggers when anomaly_score exceeds the threshold. Use this to set up alerting for anomalies detected by vmanomaly."),
@@ -32,10 +32,10 @@ func RegisterAlertTools(s *server.MCPServer, client *vmanomaly.Client) {
 		}),
 		mcp.WithInputSchema[GenerateAlertRuleArgs](),
 	)
-	s.AddTool(generateAlertRuleTool, mcp.NewTypedToolHandler(handleGenerateAlertRule(client)))
+	s.AddTool(withInstance(generateAlertRuleTool, mcp.NewTypedToolHandler(handleGenerateAlertRule(client))))
 }
 
-func handleGenerateAlertRule(client *vmanomaly.Client) func(ctx context.Context, req mcp.CallToolRequest, args GenerateAlertRuleArgs) (*mcp.CallToolResult, error) {
+func handleGenerateAlertRule(client vmanomaly.API) func(ctx context.Context, req mcp.CallToolRequest, args GenerateAlertRuleArgs) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest, args GenerateAlertRuleArgs) (*mcp.CallToolResult, error) {
 		alertReq := &vmanomaly.AlertRuleRequest{
 			Step:  args.Step,
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+)
+
+func TestGenerateAlertRule(t *testing.T) {
+	var receivedReq *vmanomaly.AlertRuleRequest
+	mock := &MockClient{
+		GenerateAlertRuleFunc: func(ctx context.Context, req *vmanomaly.AlertRuleRequest) (string, error) {
+			receivedReq = req
+			return "groups:\n  - name: VMAnomalyAlerts\n", nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_generate_alert_rule", map[string]any{
+		"step":              "1m",
+		"query":             "up",
+		"anomaly_threshold": 1.5,
+	})
+
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", resultText(result))
+	}
+	if receivedReq.AnomalyThreshold == nil || *receivedReq.AnomalyThreshold != 1.5 {
+		t.Errorf("expected anomaly_threshold=1.5, got %v", receivedReq.AnomalyThreshold)
+	}
+	if receivedReq.RuleName != nil || receivedReq.GroupName != nil {
+		t.Error("expected optional params to be nil")
+	}
+	if !strings.Contains(resultText(result), "VMAnomalyAlerts") {
+		t.Errorf("expected rule YAML in result, got %q", resultText(result))
+	}
+}
+
+func TestGenerateAlertRule_Error(t *testing.T) {
+	mock := &MockClient{
+		GenerateAlertRuleFunc: func(ctx context.Context, req *vmanomaly.AlertRuleRequest) (string, error) {
+			return "", errors.New("API error")
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_generate_alert_rule", map[string]any{"step": "1m", "query": "up"})
+
+	if !result.IsError {
+		t.Error("expected error result")
+	}
+}
@@ -30,7 +30,7 @@ type CheckCompatibilityResponse struct {
 	Reason          *string  `json:"reason,omitempty" jsonschema_description:"Explanation of incompatibility"`
 }
 
-func RegisterCompatibilityTools(s *server.MCPServer, client *vmanomaly.Client) {
+func RegisterCompatibilityTools(s *server.MCPServer, client vmanomaly.API) {
 	checkCompatibilityTool := mcp.NewTool(
 		"vmanomaly_check_compatibility",
 		mcp.WithDescription("Check if persisted vmanomaly state is compatible with the current or target runtime version. Returns compatibility status and required migration actions."),
@@ -43,10 +43,10 @@ func RegisterCompatibilityTools(s *server.MCPServer, client *vmanomaly.Client) {
 		mcp.WithInputSchema[CheckCompatibilityArgs](),
 		mcp.WithOutputSchema[CheckCompatibilityResponse](),
 	)
-	s.AddTool(checkCompatibilityTool, mcp.NewStructuredToolHandler(handleCheckCompatibility(client)))
+	s.AddTool(withInstance(checkCompatibilityTool, mcp.NewStructuredToolHandler(handleCheckCompatibility(client))))
 }
 
-func handleCheckCompatibility(client *vmanomaly.Client) mcp.StructuredToolHandlerFunc[CheckCompatibilityArgs, CheckCompatibilityResponse] {
+func handleCheckCompatibility(client vmanomaly.API) mcp.StructuredToolHandlerFunc[CheckCompatibilityArgs, CheckCompatibilityResponse] {
 	return func(ctx context.Context, req mcp.CallToolRequest, args CheckCompatibilityArgs) (CheckCompatibilityResponse, error) {
 		var versionTo *string
 		if args.VersionTo != "" {
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+)
+
+func TestCheckCompatibility_Incompatible(t *testing.T) {
+	var receivedVersion *string
+	reason := "model format changed"
+	mock := &MockClient{
+		CompatibilityFunc: func(ctx context.Context, versionTo *string) (*vmanomaly.CompatibilityCheckResponse, error) {
+			receivedVersion = versionTo
+			return &vmanomaly.CompatibilityCheckResponse{
+				RuntimeVersion: "v1.26.0",
+				GlobalCheck: vmanomaly.GlobalCompatibilityCheck{
+					HasState:     true,
+					IsCompatible: false,
+					Reason:       &reason,
+				},
+				ComponentAssessment: &vmanomaly.ComponentCompatibilityAssessment{
+					ModelsToPurge: []string{"prophet_model"},
+				},
+			}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_check_compatibility", map[string]any{"version_to": "v1.26.0"})
+	resp := structuredResult[CheckCompatibilityResponse](t, result)
+
+	if receivedVersion == nil || *receivedVersion != "v1.26.0" {
+		t.Errorf("expected version_to=v1.26.0, got %v", receivedVersion)
+	}
+	if resp.Status != "incompatible" {
+		t.Errorf("expected status=incompatible, got %s", resp.Status)
+	}
+	if !strings.Contains(resp.Summary, "purge models: prophet_model") {
+		t.Errorf("unexpected summary: %s", resp.Summary)
+	}
+}
+
+func TestCheckCompatibility_NoState(t *testing.T) {
+	mock := &MockClient{
+		CompatibilityFunc: func(ctx context.Context, versionTo *string) (*vmanomaly.CompatibilityCheckResponse, error) {
+			if versionTo != nil {
+				t.Errorf("expected version_to to be unset, got %s", *versionTo)
+			}
+			return &vmanomaly.CompatibilityCheckResponse{RuntimeVersion: "v1.26.0"}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_check_compatibility", nil)
+	resp := structuredResult[CheckCompatibilityResponse](t, result)
+
+	if resp.Status != "no_state" {
+		t.Errorf("expected status=no_state, got %s", resp.Status)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 
 	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
 
@@ -11,6 +14,11 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
+const (
+	defaultConfigFitWindow = "1d"
+	defaultConfigFitEvery  = "1d"
+)
+
 // ============================================================================
 // Configuration Tool Arguments (Struct-based schemas)
 // ============================================================================
@@ -37,26 +45,119 @@ type ValidateConfigArgs struct {
 // ============================================================================
 
 // RegisterConfigTools registers all configuration-related tools
-func RegisterConfigTools(s *server.MCPServer, client *vmanomaly.Client) {
+func RegisterConfigTools(s *server.MCPServer, client vmanomaly.API) {
+	generateConfigTool := mcp.NewTool(
+		"vmanomaly_generate_config",
+		mcp.WithDescription("Generate a complete vmanomaly YAML configuration (reader, scheduler, model, writer) for a single query and model. The generated config is validated by the server before it is returned, so the result is ready to deploy. Use vmanomaly_get_model_schema to build a valid model_spec first."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Generate vmanomaly Config",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[GenerateConfigArgs](),
+	)
+	s.AddTool(withInstance(generateConfigTool, mcp.NewTypedToolHandler(handleGenerateConfig(client))))
+
 	validateConfigTool := mcp.NewTool(
 		"vmanomaly_validate_config",
 		mcp.WithDescription("Validate a complete vmanomaly YAML configuration. Takes a full configuration object (with reader, scheduler, model, writer sections) and returns validation result with normalized config or error details. Use this to verify a complete config before deployment."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Validate vmanomaly Config",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
 		mcp.WithInputSchema[ValidateConfigArgs](),
 	)
-	s.AddTool(validateConfigTool, mcp.NewTypedToolHandler(handleValidateConfig(client)))
+	s.AddTool(withInstance(validateConfigTool, mcp.NewTypedToolHandler(handleValidateConfig(client))))
 }
 
 // ============================================================================
 // Tool Handlers
 // ============================================================================
 
+// handleGenerateConfig handles the generate_config tool
+func handleGenerateConfig(client vmanomaly.API) func(ctx context.Context, req mcp.CallToolRequest, args GenerateConfigArgs) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest, args GenerateConfigArgs) (*mcp.CallToolResult, error) {
+		configReq, err := buildConfigGenerationRequest(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		yamlConfig, err := client.GenerateConfig(ctx, configReq)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to generate config: %v", err)), nil
+		}
+
+		// Validate generated config before handing it out
+		var config map[string]any
+		if err := yaml.Unmarshal([]byte(yamlConfig), &config); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Generated config is not valid YAML: %v\n\n```yaml\n%s\n```", err, yamlConfig)), nil
+		}
+		validation, err := client.ValidateConfig(ctx, config)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Generated config failed validation: %s\n\n```yaml\n%s\n```\n\nAdjust model_spec or scheduler parameters and try again.", formatValidationError(err), yamlConfig)), nil
+		}
+		if !validation.IsValid {
+			validationJSON, err := json.MarshalIndent(validation, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to format validation result: %v", err)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("Generated config is invalid according to the server.\n\nValidation Result:\n%s\n\n```yaml\n%s\n```\n\nAdjust model_spec or scheduler parameters and try again.", validationJSON, yamlConfig)), nil
+		}
+
+		resultMsg := fmt.Sprintf("Generated vmanomaly configuration (validated):\n\n```yaml\n%s\n```\n\nSave this to a .yaml file and pass it to vmanomaly.", strings.TrimRight(yamlConfig, "\n"))
+		return mcp.NewToolResultText(resultMsg), nil
+	}
+}
+
+// buildConfigGenerationRequest checks required arguments and applies defaults
+func buildConfigGenerationRequest(args GenerateConfigArgs) (*vmanomaly.ConfigGenerationRequest, error) {
+	if args.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if args.Step == "" {
+		return nil, fmt.Errorf("step is required")
+	}
+	if args.DatasourceURL == "" {
+		return nil, fmt.Errorf("datasource_url is required")
+	}
+	if _, ok := args.ModelSpec["class"]; !ok {
+		return nil, fmt.Errorf("model_spec must include 'class' field")
+	}
+
+	configReq := &vmanomaly.ConfigGenerationRequest{
+		Query:         args.Query,
+		Step:          args.Step,
+		DatasourceURL: args.DatasourceURL,
+		ModelSpec:     args.ModelSpec,
+		FitWindow:     args.FitWindow,
+		FitEvery:      args.FitEvery,
+	}
+	if configReq.FitWindow == "" {
+		configReq.FitWindow = defaultConfigFitWindow
+	}
+	if configReq.FitEvery == "" {
+		configReq.FitEvery = defaultConfigFitEvery
+	}
+	if args.TenantID != "" {
+		configReq.TenantID = &args.TenantID
+	}
+	if args.InferEvery != "" {
+		configReq.InferEvery = &args.InferEvery
+	}
+
+	return configReq, nil
+}
+
 // handleValidateConfig handles the validate_config tool
-func handleValidateConfig(client *vmanomaly.Client) func(ctx context.Context, req mcp.CallToolRequest, args ValidateConfigArgs) (*mcp.CallToolResult, error) {
+func handleValidateConfig(client vmanomaly.API) func(ctx context.Context, req mcp.CallToolRequest, args ValidateConfigArgs) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest, args ValidateConfigArgs) (*mcp.CallToolResult, error) {
 		// Call API
 		validation, err := client.ValidateConfig(ctx, args.Config)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Config validation failed: %v", err)), nil
+			return mcp.NewToolResultError(fmt.Sprintf("Config validation failed: %s", formatValidationError(err))), nil
 		}
 
 		// Format response
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		isValid bool
+		want    string
+	}{
+		{name: "valid", isValid: true, want: "Configuration is valid"},
+		{name: "invalid", isValid: false, want: "Configuration is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockClient{
+				ValidateConfigFunc: func(ctx context.Context, config map[string]any) (*vmanomaly.OkValidationResponse, error) {
+					if _, ok := config["reader"]; !ok {
+						t.Errorf("expected config to be passed through, got %v", config)
+					}
+					return &vmanomaly.OkValidationResponse{IsValid: tt.isValid}, nil
+				},
+			}
+
+			result := callTool(t, mock, "vmanomaly_validate_config", map[string]any{
+				"config": map[string]any{"reader": map[string]any{"class": "vm"}},
+			})
+
+			if result.IsError {
+				t.Fatalf("unexpected error result: %s", resultText(result))
+			}
+			if !strings.Contains(resultText(result), tt.want) {
+				t.Errorf("expected %q in result, got %q", tt.want, resultText(result))
+			}
+		})
+	}
+}
+
+func TestValidateConfig_ValidationErrors(t *testing.T) {
+	mock := &MockClient{
+		ValidateConfigFunc: func(ctx context.Context, config map[string]any) (*vmanomaly.OkValidationResponse, error) {
+			return nil, &vmanomaly.APIError{StatusCode: 422, ValidationErrors: []vmanomaly.ValidationError{
+				{Loc: []any{"body", "reader"}, Msg: "Field required", Type: "missing"},
+				{Loc: []any{"body", "models", "m1", "z_threshold"}, Msg: "Input should be greater than 0", Type: "greater_than"},
+			}}
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_validate_config", map[string]any{
+		"config": map[string]any{"models": map[string]any{"m1": map[string]any{"class": "zscore", "z_threshold": -1}}},
+	})
+
+	if !result.IsError {
+		t.Fatal("expected error result")
+	}
+	for _, want := range []string{
+		"vmanomaly rejected 2 invalid field(s) (status 422)",
+		"- reader: Field required (missing)",
+		"- models.m1.z_threshold: Input should be greater than 0 (greater_than)",
+	} {
+		if !strings.Contains(resultText(result), want) {
+			t.Errorf("expected %q in result, got %q", want, resultText(result))
+		}
+	}
+}
+
+func TestBuildConfigGenerationRequest(t *testing.T) {
+	req, err := buildConfigGenerationRequest(GenerateConfigArgs{
+		Query:         "up",
+		Step:          "1m",
+		DatasourceURL: "http://vm:8428",
+		ModelSpec:     map[string]any{"class": "zscore"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.FitWindow != "1d" || req.FitEvery != "1d" {
+		t.Errorf("expected default fit_window/fit_every=1d, got %s/%s", req.FitWindow, req.FitEvery)
+	}
+	if req.TenantID != nil || req.InferEvery != nil {
+		t.Error("expected optional params to be nil")
+	}
+
+	_, err = buildConfigGenerationRequest(GenerateConfigArgs{
+		Query:         "up",
+		Step:          "1m",
+		DatasourceURL: "http://vm:8428",
+		ModelSpec:     map[string]any{"z_threshold": 2.5},
+	})
+	if err == nil {
+		t.Error("expected error for model_spec without class")
+	}
+}
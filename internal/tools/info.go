@@ -16,7 +16,7 @@ import (
 // ============================================================================
 
 // RegisterInfoTools registers all query and utility tools
-func RegisterInfoTools(s *server.MCPServer, client *vmanomaly.Client) {
+func RegisterInfoTools(s *server.MCPServer, client vmanomaly.API) {
 	// get_buildinfo tool
 	getBuildinfoTool := mcp.NewTool(
 		"vmanomaly_get_buildinfo",
@@ -28,7 +28,7 @@ func RegisterInfoTools(s *server.MCPServer, client *vmanomaly.Client) {
 			OpenWorldHint:   ptr(false),
 		}),
 	)
-	s.AddTool(getBuildinfoTool, handleGetBuildinfo(client))
+	s.AddTool(withInstance(withNoCache(getBuildinfoTool, handleGetBuildinfo(client))))
 
 	getQueriesTool := mcp.NewTool(
 		"vmanomaly_get_server_queries",
@@ -40,7 +40,7 @@ func RegisterInfoTools(s *server.MCPServer, client *vmanomaly.Client) {
 			OpenWorldHint:   ptr(false),
 		}),
 	)
-	s.AddTool(getQueriesTool, handleGetServerQueries(client))
+	s.AddTool(withInstance(getQueriesTool, handleGetServerQueries(client)))
 
 	getMetricsTool := mcp.NewTool(
 		"vmanomaly_get_metrics",
@@ -52,14 +52,14 @@ func RegisterInfoTools(s *server.MCPServer, client *vmanomaly.Client) {
 			OpenWorldHint:   ptr(false),
 		}),
 	)
-	s.AddTool(getMetricsTool, handleGetMetrics(client))
+	s.AddTool(withInstance(getMetricsTool, handleGetMetrics(client)))
 }
 
 // ============================================================================
 // Tool Handlers
 // ============================================================================
 
-func handleGetBuildinfo(client *vmanomaly.Client) server.ToolHandlerFunc {
+func handleGetBuildinfo(client vmanomaly.API) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		buildInfo, err := client.GetBuildInfo(ctx)
 		if err != nil {
@@ -76,7 +76,7 @@ func handleGetBuildinfo(client *vmanomaly.Client) server.ToolHandlerFunc {
 	}
 }
 
-func handleGetServerQueries(client *vmanomaly.Client) server.ToolHandlerFunc {
+func handleGetServerQueries(client vmanomaly.API) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		queries, err := client.GetServerQueries(ctx)
 		if err != nil {
@@ -93,7 +93,7 @@ func handleGetServerQueries(client *vmanomaly.Client) server.ToolHandlerFunc {
 	}
 }
 
-func handleGetMetrics(client *vmanomaly.Client) server.ToolHandlerFunc {
+func handleGetMetrics(client vmanomaly.API) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		metrics, err := client.Metrics(ctx, nil)
 		if err != nil {
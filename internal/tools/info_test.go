@@ -3,7 +3,10 @@ package tools
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
 )
 
 func TestGetBuildinfo_Error(t *testing.T) {
@@ -13,9 +16,63 @@ func TestGetBuildinfo_Error(t *testing.T) {
 		},
 	}
 
-	_, err := mock.GetBuildInfo(context.Background())
+	result := callTool(t, mock, "vmanomaly_get_buildinfo", nil)
+
+	if !result.IsError {
+		t.Error("expected error result")
+	}
+	if !strings.Contains(resultText(result), "API error") {
+		t.Errorf("expected API error in result, got %q", resultText(result))
+	}
+}
+
+func TestGetBuildinfo_Success(t *testing.T) {
+	mock := &MockClient{
+		GetBuildInfoFunc: func(ctx context.Context) (map[string]any, error) {
+			return map[string]any{"version": "v1.26.0"}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_get_buildinfo", nil)
+
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", resultText(result))
+	}
+	if !strings.Contains(resultText(result), "v1.26.0") {
+		t.Errorf("expected version in result, got %q", resultText(result))
+	}
+}
+
+func TestGetServerQueries(t *testing.T) {
+	mock := &MockClient{
+		GetServerQueriesFunc: func(ctx context.Context) (vmanomaly.ServerQueriesResponse, error) {
+			return vmanomaly.ServerQueriesResponse{"cpu": "rate(node_cpu_seconds_total[5m])"}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_get_server_queries", nil)
 
-	if err == nil {
-		t.Error("expected error from API")
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", resultText(result))
+	}
+	if !strings.Contains(resultText(result), "node_cpu_seconds_total") {
+		t.Errorf("expected query in result, got %q", resultText(result))
+	}
+}
+
+func TestGetMetrics(t *testing.T) {
+	mock := &MockClient{
+		MetricsFunc: func(ctx context.Context, config map[string]any) (string, error) {
+			return "vmanomaly_reader_responses_total 42\n", nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_get_metrics", nil)
+
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", resultText(result))
+	}
+	if !strings.Contains(resultText(result), "vmanomaly_reader_responses_total 42") {
+		t.Errorf("expected metrics in result, got %q", resultText(result))
 	}
 }
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// instanceArg is the optional argument of every vmanomaly-backed tool selecting the backend
+const instanceArg = "instance"
+
+// instanceArgSchema is the JSON schema of instanceArg
+var instanceArgSchema = map[string]any{
+	"type":        "string",
+	"description": "Name of the vmanomaly instance to use (see vmanomaly_list_instances). Defaults to the default instance. Use the same instance for all calls related to one detection task.",
+}
+
+// ============================================================================
+// Instance Tool Responses
+// ============================================================================
+
+// ListInstancesResponse is the structured output of list instances tool
+type ListInstancesResponse struct {
+	Summary   string         `json:"summary" jsonschema_description:"Human-readable summary of instances health"`
+	Default   string         `json:"default" jsonschema_description:"Name of the instance used when the instance argument is omitted"`
+	Instances []InstanceInfo `json:"instances" jsonschema_description:"Configured vmanomaly instances"`
+}
+
+// InstanceInfo describes health and build info of a vmanomaly instance
+type InstanceInfo struct {
+	Name      string         `json:"name" jsonschema_description:"Instance name to pass as the instance argument"`
+	Endpoint  string         `json:"endpoint" jsonschema_description:"vmanomaly endpoint URL"`
+	Default   bool           `json:"default" jsonschema_description:"Whether this is the default instance"`
+	Healthy   bool           `json:"healthy" jsonschema_description:"Whether the health check succeeded"`
+	Status    string         `json:"status,omitempty" jsonschema_description:"Status reported by the health check"`
+	Version   string         `json:"version,omitempty" jsonschema_description:"vmanomaly version"`
+	BuildInfo map[string]any `json:"build_info,omitempty" jsonschema_description:"Build information reported by the instance"`
+	Error     string         `json:"error,omitempty" jsonschema_description:"Error of the health check or build info request"`
+}
+
+// ============================================================================
+// Tool Registration Functions
+// ============================================================================
+
+// RegisterInstanceTools registers tools describing and aggregating configured vmanomaly instances
+func RegisterInstanceTools(s *server.MCPServer, instances *vmanomaly.Instances) {
+	listInstancesTool := mcp.NewTool(
+		"vmanomaly_list_instances",
+		mcp.WithDescription("List configured vmanomaly instances (e.g. per environment or shard) with their health and build info. Pass an instance name as the 'instance' argument of other tools to target it."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "List vmanomaly Instances",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithOutputSchema[ListInstancesResponse](),
+	)
+	s.AddTool(listInstancesTool, mcp.NewStructuredToolHandler(handleListInstances(instances)))
+
+	RegisterShardTools(s, instances)
+}
+
+// withInstance adds the optional instance argument to the tool input schema,
+// and makes the handler route vmanomaly API calls to the selected instance
+func withInstance(tool mcp.Tool, handler server.ToolHandlerFunc) (mcp.Tool, server.ToolHandlerFunc) {
+	tool = withArgument(tool, instanceArg, instanceArgSchema)
+	return tool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if name, ok := req.GetArguments()[instanceArg].(string); ok {
+			ctx = vmanomaly.ContextWithInstance(ctx, name)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ============================================================================
+// Tool Handlers
+// ============================================================================
+
+func handleListInstances(instances *vmanomaly.Instances) mcp.StructuredToolHandlerFunc[struct{}, ListInstancesResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, _ struct{}) (ListInstancesResponse, error) {
+		list := instances.List()
+		infos := make([]InstanceInfo, len(list))
+
+		var wg sync.WaitGroup
+		for i, inst := range list {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				infos[i] = instanceInfo(ctx, inst, inst.Name == instances.Default())
+			}()
+		}
+		wg.Wait()
+
+		healthy := 0
+		for _, info := range infos {
+			if info.Healthy {
+				healthy++
+			}
+		}
+
+		return ListInstancesResponse{
+			Summary:   fmt.Sprintf("%d of %d vmanomaly instances are healthy, default instance is %q.", healthy, len(infos), instances.Default()),
+			Default:   instances.Default(),
+			Instances: infos,
+		}, nil
+	}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+func instanceInfo(ctx context.Context, inst vmanomaly.Instance, isDefault bool) InstanceInfo {
+	info := InstanceInfo{
+		Name:     inst.Name,
+		Endpoint: inst.Endpoint,
+		Default:  isDefault,
+	}
+
+	health, err := inst.Client.GetHealth(ctx)
+	if err != nil {
+		info.Error = fmt.Sprintf("health check failed: %v", err)
+		return info
+	}
+	info.Healthy = true
+	info.Status, _ = health["status"].(string)
+
+	buildInfo, err := inst.Client.GetBuildInfo(ctx)
+	if err != nil {
+		info.Error = fmt.Sprintf("failed to get build info: %v", err)
+		return info
+	}
+	info.BuildInfo = buildInfo
+	info.Version = buildVersion(buildInfo)
+	return info
+}
+
+// buildVersion extracts vmanomaly version from build info
+func buildVersion(buildInfo map[string]any) string {
+	for _, key := range []string{"vmanomaly", "version"} {
+		if v, ok := buildInfo[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
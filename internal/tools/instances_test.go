@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestTools_InstanceArgument(t *testing.T) {
+	s := server.NewMCPServer("test", "0.0.0", server.WithToolCapabilities(true))
+	RegisterTools(s, &MockClient{})
+
+	for name, tool := range s.ListTools() {
+		data, err := json.Marshal(tool.Tool)
+		if err != nil {
+			t.Fatalf("failed to marshal tool %q: %v", name, err)
+		}
+		var parsed struct {
+			InputSchema struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"inputSchema"`
+		}
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			t.Fatalf("failed to parse tool %q: %v", name, err)
+		}
+		_, hasInstance := parsed.InputSchema.Properties[instanceArg]
+		if wantInstance := name != "vmanomaly_search_docs"; hasInstance != wantInstance {
+			t.Errorf("tool %q: has instance argument = %v, want %v", name, hasInstance, wantInstance)
+		}
+	}
+}
+
+func TestTools_InstanceRouting(t *testing.T) {
+	newMock := func(status string) *MockClient {
+		return &MockClient{
+			GetHealthFunc: func(ctx context.Context) (map[string]any, error) {
+				return map[string]any{"status": status}, nil
+			},
+		}
+	}
+	instances, err := vmanomaly.NewInstances([]vmanomaly.Instance{
+		{Name: "prod", Client: newMock("prod-ok")},
+		{Name: "staging", Client: newMock("staging-ok")},
+	}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		args    map[string]any
+		want    string
+		isError bool
+	}{
+		{args: map[string]any{}, want: "prod-ok"},
+		{args: map[string]any{"instance": "staging"}, want: "staging-ok"},
+		{args: map[string]any{"instance": "dev"}, want: `unknown vmanomaly instance "dev"`, isError: true},
+	}
+	for _, tt := range tests {
+		result := callTool(t, instances, "vmanomaly_health_check", tt.args)
+		if result.IsError != tt.isError {
+			t.Errorf("args %v: isError = %v, want %v", tt.args, result.IsError, tt.isError)
+		}
+		if text := resultText(result); !strings.Contains(text, tt.want) {
+			t.Errorf("args %v: result %q should contain %q", tt.args, text, tt.want)
+		}
+	}
+}
+
+func TestListInstances(t *testing.T) {
+	instances, err := vmanomaly.NewInstances([]vmanomaly.Instance{
+		{Name: "prod", Endpoint: "http://prod:8490", Client: &MockClient{
+			GetHealthFunc: func(ctx context.Context) (map[string]any, error) {
+				return map[string]any{"status": "ok"}, nil
+			},
+			GetBuildInfoFunc: func(ctx context.Context) (map[string]any, error) {
+				return map[string]any{"vmanomaly": "v1.28.0"}, nil
+			},
+		}},
+		{Name: "staging", Endpoint: "http://staging:8490", Client: &MockClient{
+			GetHealthFunc: func(ctx context.Context) (map[string]any, error) {
+				return nil, errors.New("connection refused")
+			},
+		}},
+	}, "staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := server.NewMCPServer("test", "0.0.0", server.WithToolCapabilities(true))
+	RegisterInstanceTools(s, instances)
+	var req mcp.CallToolRequest
+	req.Params.Name = "vmanomaly_list_instances"
+	result, err := s.GetTool("vmanomaly_list_instances").Handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp := structuredResult[ListInstancesResponse](t, result)
+
+	if resp.Default != "staging" || len(resp.Instances) != 2 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	prod, staging := resp.Instances[0], resp.Instances[1]
+	if !prod.Healthy || prod.Version != "v1.28.0" || prod.Status != "ok" || prod.Default {
+		t.Errorf("unexpected prod info: %+v", prod)
+	}
+	if staging.Healthy || !staging.Default || !strings.Contains(staging.Error, "connection refused") {
+		t.Errorf("unexpected staging info: %+v", staging)
+	}
+	if !strings.Contains(resp.Summary, "1 of 2") {
+		t.Errorf("unexpected summary: %s", resp.Summary)
+	}
+}
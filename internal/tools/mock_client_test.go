@@ -3,11 +3,18 @@ package tools
 import (
 	"context"
 	"errors"
+	"strings"
+	"testing"
 
 	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 )
 
-// MockClient is a mock implementation of vmanomaly.Client for testing
+var _ vmanomaly.API = (*MockClient)(nil)
+
+// MockClient is a mock implementation of vmanomaly.API for testing
 type MockClient struct {
 	GetHealthFunc           func(ctx context.Context) (map[string]any, error)
 	GetBuildInfoFunc        func(ctx context.Context) (map[string]any, error)
@@ -22,6 +29,11 @@ type MockClient struct {
 	CancelTaskFunc          func(ctx context.Context, taskID string) (map[string]bool, error)
 	GetDetectionLimitsFunc  func(ctx context.Context) (*vmanomaly.AnomalyDetectionLimitsResponse, error)
 	QueryFunc               func(ctx context.Context, req *vmanomaly.QueryRequest) (map[string]any, error)
+	GetServerQueriesFunc    func(ctx context.Context) (vmanomaly.ServerQueriesResponse, error)
+	ValidateConfigFunc      func(ctx context.Context, config map[string]any) (*vmanomaly.OkValidationResponse, error)
+	MetricsFunc             func(ctx context.Context, config map[string]any) (string, error)
+	CompatibilityFunc       func(ctx context.Context, versionTo *string) (*vmanomaly.CompatibilityCheckResponse, error)
+	GenerateAlertRuleFunc   func(ctx context.Context, req *vmanomaly.AlertRuleRequest) (string, error)
 }
 
 func (m *MockClient) GetHealth(ctx context.Context) (map[string]any, error) {
@@ -114,3 +126,96 @@ func (m *MockClient) Query(ctx context.Context, req *vmanomaly.QueryRequest) (ma
 	}
 	return nil, errors.New("not implemented")
 }
+
+func (m *MockClient) GetServerQueries(ctx context.Context) (vmanomaly.ServerQueriesResponse, error) {
+	if m.GetServerQueriesFunc != nil {
+		return m.GetServerQueriesFunc(ctx)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockClient) ValidateConfig(ctx context.Context, config map[string]any) (*vmanomaly.OkValidationResponse, error) {
+	if m.ValidateConfigFunc != nil {
+		return m.ValidateConfigFunc(ctx, config)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockClient) Metrics(ctx context.Context, config map[string]any) (string, error) {
+	if m.MetricsFunc != nil {
+		return m.MetricsFunc(ctx, config)
+	}
+	return "", errors.New("not implemented")
+}
+
+func (m *MockClient) Compatibility(ctx context.Context, versionTo *string) (*vmanomaly.CompatibilityCheckResponse, error) {
+	if m.CompatibilityFunc != nil {
+		return m.CompatibilityFunc(ctx, versionTo)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockClient) GenerateAlertRule(ctx context.Context, req *vmanomaly.AlertRuleRequest) (string, error) {
+	if m.GenerateAlertRuleFunc != nil {
+		return m.GenerateAlertRuleFunc(ctx, req)
+	}
+	return "", errors.New("not implemented")
+}
+
+// callTool registers all tools against client and invokes the named tool handler
+// the same way the MCP server does for a tools/call request
+func callTool(t *testing.T, client vmanomaly.API, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	return callToolContext(t, context.Background(), client, name, args)
+}
+
+// callToolContext is like callTool but passes ctx to the tool handler
+func callToolContext(t *testing.T, ctx context.Context, client vmanomaly.API, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	s := server.NewMCPServer("test", "0.0.0", server.WithToolCapabilities(true))
+	RegisterTools(s, client)
+
+	tool := s.GetTool(name)
+	if tool == nil {
+		t.Fatalf("tool %q is not registered", name)
+	}
+
+	var req mcp.CallToolRequest
+	req.Params.Name = name
+	req.Params.Arguments = args
+
+	result, err := tool.Handler(ctx, req)
+	if err != nil {
+		t.Fatalf("tool %q returned error: %v", name, err)
+	}
+	if result == nil {
+		t.Fatalf("tool %q returned nil result", name)
+	}
+	return result
+}
+
+// resultText joins all text contents of a tool result
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if tc, ok := c.(mcp.TextContent); ok {
+			parts = append(parts, tc.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// structuredResult returns structured content of a tool result created by a structured handler
+func structuredResult[T any](t *testing.T, result *mcp.CallToolResult) T {
+	t.Helper()
+
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", resultText(result))
+	}
+	v, ok := result.StructuredContent.(T)
+	if !ok {
+		t.Fatalf("unexpected structured content type %T", result.StructuredContent)
+	}
+	return v
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/resources"
 	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -30,7 +31,9 @@ type ValidateModelConfigArgs struct {
 // ============================================================================
 
 // RegisterModelTools registers all model configuration tools
-func RegisterModelTools(s *server.MCPServer, client *vmanomaly.Client) {
+func RegisterModelTools(s *server.MCPServer, client vmanomaly.API) {
+	snapshot := resources.ModelSchemas()
+
 	listModelsTool := mcp.NewTool(
 		"vmanomaly_list_models",
 		mcp.WithDescription("List all available anomaly detection model types supported by vmanomaly. Returns model names that can be used in model configurations. Use this as the first step when selecting a model, then call vmanomaly_get_model_schema to see parameters for your chosen model."),
@@ -41,7 +44,7 @@ func RegisterModelTools(s *server.MCPServer, client *vmanomaly.Client) {
 			OpenWorldHint:   ptr(false),
 		}),
 	)
-	s.AddTool(listModelsTool, handleListModels(client))
+	s.AddTool(withInstance(withNoCache(listModelsTool, handleListModels(client, snapshot))))
 
 	getServerModelsTool := mcp.NewTool(
 		"vmanomaly_get_server_models",
@@ -53,7 +56,7 @@ func RegisterModelTools(s *server.MCPServer, client *vmanomaly.Client) {
 			OpenWorldHint:   ptr(false),
 		}),
 	)
-	s.AddTool(getServerModelsTool, handleGetServerModels(client))
+	s.AddTool(withInstance(getServerModelsTool, handleGetServerModels(client)))
 
 	getModelSchemaTool := mcp.NewTool(
 		"vmanomaly_get_model_schema",
@@ -66,7 +69,7 @@ func RegisterModelTools(s *server.MCPServer, client *vmanomaly.Client) {
 		}),
 		mcp.WithInputSchema[GetModelSchemaArgs](),
 	)
-	s.AddTool(getModelSchemaTool, mcp.NewTypedToolHandler(handleGetModelSchema(client)))
+	s.AddTool(withInstance(withNoCache(getModelSchemaTool, mcp.NewTypedToolHandler(handleGetModelSchema(client, snapshot)))))
 
 	validateModelConfigTool := mcp.NewTool(
 		"vmanomaly_validate_model_config",
@@ -79,19 +82,33 @@ func RegisterModelTools(s *server.MCPServer, client *vmanomaly.Client) {
 		}),
 		mcp.WithInputSchema[ValidateModelConfigArgs](),
 	)
-	s.AddTool(validateModelConfigTool, mcp.NewTypedToolHandler(handleValidateModelConfig(client)))
+	s.AddTool(withInstance(validateModelConfigTool, mcp.NewTypedToolHandler(handleValidateModelConfig(client))))
 }
 
 // ============================================================================
 // Tool Handlers
 // ============================================================================
 
-func handleListModels(client *vmanomaly.Client) server.ToolHandlerFunc {
+// snapshotNotice marks responses served from the embedded model schema snapshot because vmanomaly is unreachable
+func snapshotNotice(snapshot *resources.ModelSchemaSnapshot, err error) string {
+	return fmt.Sprintf("⚠ vmanomaly is unreachable (%v).\n"+
+		"Returning the offline snapshot bundled with mcp-vmanomaly, taken from vmanomaly %s. "+
+		"The running vmanomaly version may support other models or parameters, verify the configuration once vmanomaly is reachable.\n\n",
+		err, snapshot.Version)
+}
+
+// handleListModels lists models of vmanomaly, falling back to snapshot if vmanomaly is unreachable
+func handleListModels(client vmanomaly.API, snapshot *resources.ModelSchemaSnapshot) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Call API
+		var notice string
 		models, err := client.ListModels(ctx)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to list models: %v", err)), nil
+			if snapshot == nil || !vmanomaly.IsUnavailable(err) {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list models: %v", err)), nil
+			}
+			notice = snapshotNotice(snapshot, err)
+			models = &vmanomaly.ModelsListResponse{Models: snapshot.Models}
 		}
 
 		// Format response
@@ -100,11 +117,11 @@ func handleListModels(client *vmanomaly.Client) server.ToolHandlerFunc {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to format response: %v", err)), nil
 		}
 
-		return mcp.NewToolResultText(string(responseJSON)), nil
+		return mcp.NewToolResultText(notice + string(responseJSON)), nil
 	}
 }
 
-func handleGetServerModels(client *vmanomaly.Client) server.ToolHandlerFunc {
+func handleGetServerModels(client vmanomaly.API) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		models, err := client.GetServerModels(ctx)
 		if err != nil {
@@ -120,12 +137,21 @@ func handleGetServerModels(client *vmanomaly.Client) server.ToolHandlerFunc {
 	}
 }
 
-func handleGetModelSchema(client *vmanomaly.Client) func(ctx context.Context, req mcp.CallToolRequest, args GetModelSchemaArgs) (*mcp.CallToolResult, error) {
+// handleGetModelSchema returns the model schema of vmanomaly, falling back to snapshot if vmanomaly is unreachable
+func handleGetModelSchema(client vmanomaly.API, snapshot *resources.ModelSchemaSnapshot) func(ctx context.Context, req mcp.CallToolRequest, args GetModelSchemaArgs) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest, args GetModelSchemaArgs) (*mcp.CallToolResult, error) {
 		// Call API
+		var notice string
 		schema, err := client.GetModelSchema(ctx, args.ModelClass)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to get model schema: %v", err)), nil
+			var ok bool
+			if snapshot != nil && vmanomaly.IsUnavailable(err) {
+				schema, ok = snapshot.Schemas[args.ModelClass]
+			}
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get model schema: %v", err)), nil
+			}
+			notice = snapshotNotice(snapshot, err)
 		}
 
 		// Format response
@@ -134,16 +160,16 @@ func handleGetModelSchema(client *vmanomaly.Client) func(ctx context.Context, re
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to format response: %v", err)), nil
 		}
 
-		return mcp.NewToolResultText(string(responseJSON)), nil
+		return mcp.NewToolResultText(notice + string(responseJSON)), nil
 	}
 }
 
-func handleValidateModelConfig(client *vmanomaly.Client) func(ctx context.Context, req mcp.CallToolRequest, args ValidateModelConfigArgs) (*mcp.CallToolResult, error) {
+func handleValidateModelConfig(client vmanomaly.API) func(ctx context.Context, req mcp.CallToolRequest, args ValidateModelConfigArgs) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest, args ValidateModelConfigArgs) (*mcp.CallToolResult, error) {
 		// Call API
 		validation, err := client.ValidateModel(ctx, args.ModelSpec)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Model validation failed: %v", err)), nil
+			return mcp.NewToolResultError(fmt.Sprintf("Model validation failed: %s", formatValidationError(err))), nil
 		}
 
 		// Format response
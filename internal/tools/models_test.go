@@ -3,9 +3,16 @@ package tools
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/resources"
 	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func TestListModels_Error(t *testing.T) {
@@ -15,24 +22,32 @@ func TestListModels_Error(t *testing.T) {
 		},
 	}
 
-	_, err := mock.ListModels(context.Background())
+	result := callTool(t, mock, "vmanomaly_list_models", nil)
 
-	if err == nil {
-		t.Error("expected error from API")
+	if !result.IsError {
+		t.Error("expected error result")
+	}
+	if !strings.Contains(resultText(result), "API error") {
+		t.Errorf("expected API error in result, got %q", resultText(result))
 	}
 }
 
 func TestGetModelSchema_Error(t *testing.T) {
+	var receivedClass string
 	mock := &MockClient{
 		GetModelSchemaFunc: func(ctx context.Context, modelClass string) (map[string]any, error) {
+			receivedClass = modelClass
 			return nil, errors.New("model not found")
 		},
 	}
 
-	_, err := mock.GetModelSchema(context.Background(), "invalid")
+	result := callTool(t, mock, "vmanomaly_get_model_schema", map[string]any{"model_class": "invalid"})
 
-	if err == nil {
-		t.Error("expected error from API")
+	if !result.IsError {
+		t.Error("expected error result")
+	}
+	if receivedClass != "invalid" {
+		t.Errorf("expected model_class=invalid, got %q", receivedClass)
 	}
 }
 
@@ -46,40 +61,44 @@ func TestValidateModel_InvalidModel(t *testing.T) {
 		},
 	}
 
-	result, err := mock.ValidateModel(context.Background(), map[string]any{"invalid": "config"})
+	result := callTool(t, mock, "vmanomaly_validate_model_config", map[string]any{
+		"model_spec": map[string]any{"invalid": "config"},
+	})
 
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", resultText(result))
 	}
-
-	if result.Valid {
-		t.Error("expected invalid model")
+	if !strings.Contains(resultText(result), "Model configuration is invalid") {
+		t.Errorf("expected invalid model message, got %q", resultText(result))
 	}
 }
 
 func TestGenerateConfig_Defaults(t *testing.T) {
-	var receivedReq *vmanomaly.ConfigGenerationRequest
+	var (
+		receivedReq    *vmanomaly.ConfigGenerationRequest
+		receivedConfig map[string]any
+	)
 
 	mock := &MockClient{
 		GenerateConfigFunc: func(ctx context.Context, req *vmanomaly.ConfigGenerationRequest) (string, error) {
 			receivedReq = req
-			return "schedulers:\n  fit_window: 1d", nil
+			return "schedulers:\n  s1:\n    fit_window: 1d\n", nil
+		},
+		ValidateConfigFunc: func(ctx context.Context, config map[string]any) (*vmanomaly.OkValidationResponse, error) {
+			receivedConfig = config
+			return &vmanomaly.OkValidationResponse{IsValid: true, Validated: config}, nil
 		},
 	}
 
-	configReq := &vmanomaly.ConfigGenerationRequest{
-		Query:         "up",
-		Step:          "1m",
-		DatasourceURL: "http://vm:8428",
-		ModelSpec:     map[string]any{"class": "zscore"},
-		FitWindow:     "1d",
-		FitEvery:      "1d",
-	}
-
-	_, err := mock.GenerateConfig(context.Background(), configReq)
+	result := callTool(t, mock, "vmanomaly_generate_config", map[string]any{
+		"query":          "up",
+		"step":           "1m",
+		"datasource_url": "http://vm:8428",
+		"model_spec":     map[string]any{"class": "zscore"},
+	})
 
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", resultText(result))
 	}
 
 	if receivedReq.FitWindow != "1d" {
@@ -89,6 +108,14 @@ func TestGenerateConfig_Defaults(t *testing.T) {
 	if receivedReq.FitEvery != "1d" {
 		t.Errorf("expected default fit_every=1d, got %s", receivedReq.FitEvery)
 	}
+
+	if _, ok := receivedConfig["schedulers"]; !ok {
+		t.Errorf("expected generated config to be validated, got %v", receivedConfig)
+	}
+
+	if !strings.Contains(resultText(result), "```yaml\nschedulers:") {
+		t.Errorf("expected YAML in result, got %q", resultText(result))
+	}
 }
 
 func TestGenerateConfig_OverrideDefaults(t *testing.T) {
@@ -97,25 +124,25 @@ func TestGenerateConfig_OverrideDefaults(t *testing.T) {
 	mock := &MockClient{
 		GenerateConfigFunc: func(ctx context.Context, req *vmanomaly.ConfigGenerationRequest) (string, error) {
 			receivedReq = req
-			return "config", nil
+			return "config: {}", nil
+		},
+		ValidateConfigFunc: func(ctx context.Context, config map[string]any) (*vmanomaly.OkValidationResponse, error) {
+			return &vmanomaly.OkValidationResponse{IsValid: true}, nil
 		},
 	}
 
-	tenantID := "tenant1"
-	configReq := &vmanomaly.ConfigGenerationRequest{
-		Query:         "up",
-		Step:          "1m",
-		DatasourceURL: "http://vm:8428",
-		ModelSpec:     map[string]any{"class": "zscore"},
-		FitWindow:     "2h",
-		FitEvery:      "30m",
-		TenantID:      &tenantID,
-	}
-
-	_, err := mock.GenerateConfig(context.Background(), configReq)
+	result := callTool(t, mock, "vmanomaly_generate_config", map[string]any{
+		"query":          "up",
+		"step":           "1m",
+		"datasource_url": "http://vm:8428",
+		"model_spec":     map[string]any{"class": "zscore"},
+		"fit_window":     "2h",
+		"fit_every":      "30m",
+		"tenant_id":      "tenant1",
+	})
 
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", resultText(result))
 	}
 
 	if receivedReq.FitWindow != "2h" {
@@ -130,3 +157,137 @@ func TestGenerateConfig_OverrideDefaults(t *testing.T) {
 		t.Error("expected tenant_id=tenant1")
 	}
 }
+
+func TestGenerateConfig_ValidationFailed(t *testing.T) {
+	mock := &MockClient{
+		GenerateConfigFunc: func(ctx context.Context, req *vmanomaly.ConfigGenerationRequest) (string, error) {
+			return "models:\n  m1:\n    class: zscore\n", nil
+		},
+		ValidateConfigFunc: func(ctx context.Context, config map[string]any) (*vmanomaly.OkValidationResponse, error) {
+			return nil, errors.New("API error (status 422): reader is required")
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_generate_config", map[string]any{
+		"query":          "up",
+		"step":           "1m",
+		"datasource_url": "http://vm:8428",
+		"model_spec":     map[string]any{"class": "zscore"},
+	})
+
+	if !result.IsError {
+		t.Fatal("expected error result")
+	}
+	if !strings.Contains(resultText(result), "reader is required") {
+		t.Errorf("expected validation details in result, got %q", resultText(result))
+	}
+}
+
+func TestGenerateConfig_Invalid(t *testing.T) {
+	mock := &MockClient{
+		GenerateConfigFunc: func(ctx context.Context, req *vmanomaly.ConfigGenerationRequest) (string, error) {
+			return "models:\n  m1:\n    class: zscore\n", nil
+		},
+		ValidateConfigFunc: func(ctx context.Context, config map[string]any) (*vmanomaly.OkValidationResponse, error) {
+			return &vmanomaly.OkValidationResponse{IsValid: false, Validated: map[string]any{"errors": []any{"reader is required"}}}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_generate_config", map[string]any{
+		"query":          "up",
+		"step":           "1m",
+		"datasource_url": "http://vm:8428",
+		"model_spec":     map[string]any{"class": "zscore"},
+	})
+
+	if !result.IsError {
+		t.Fatal("expected error result")
+	}
+	for _, want := range []string{`"is_valid": false`, `"reader is required"`, "```yaml\nmodels:"} {
+		if !strings.Contains(resultText(result), want) {
+			t.Errorf("expected %q in result, got %q", want, resultText(result))
+		}
+	}
+}
+
+func TestTools_NoCacheArgument(t *testing.T) {
+	var noCache []bool
+	mock := &MockClient{
+		ListModelsFunc: func(ctx context.Context) (*vmanomaly.ModelsListResponse, error) {
+			noCache = append(noCache, vmanomaly.NoCacheFromContext(ctx))
+			return &vmanomaly.ModelsListResponse{}, nil
+		},
+		GetModelSchemaFunc: func(ctx context.Context, modelClass string) (map[string]any, error) {
+			noCache = append(noCache, vmanomaly.NoCacheFromContext(ctx))
+			return map[string]any{}, nil
+		},
+		GetBuildInfoFunc: func(ctx context.Context) (map[string]any, error) {
+			noCache = append(noCache, vmanomaly.NoCacheFromContext(ctx))
+			return map[string]any{}, nil
+		},
+	}
+
+	callTool(t, mock, "vmanomaly_list_models", nil)
+	callTool(t, mock, "vmanomaly_list_models", map[string]any{"nocache": true})
+	callTool(t, mock, "vmanomaly_get_model_schema", map[string]any{"model_class": "zscore", "nocache": true})
+	callTool(t, mock, "vmanomaly_get_buildinfo", map[string]any{"nocache": true, "instance": "default"})
+
+	want := []bool{false, true, true, true}
+	if !slices.Equal(noCache, want) {
+		t.Errorf("got nocache %v, want %v", noCache, want)
+	}
+}
+
+func TestModelTools_SnapshotFallback(t *testing.T) {
+	snapshot := &resources.ModelSchemaSnapshot{
+		Version: "v1.28.0",
+		Models:  []string{"zscore"},
+		Schemas: map[string]map[string]any{"zscore": {"title": "ZScoreModel"}},
+	}
+	var listErr, schemaErr error
+	mock := &MockClient{
+		ListModelsFunc: func(ctx context.Context) (*vmanomaly.ModelsListResponse, error) {
+			return nil, listErr
+		},
+		GetModelSchemaFunc: func(ctx context.Context, modelClass string) (map[string]any, error) {
+			return nil, schemaErr
+		},
+	}
+	listModels := handleListModels(mock, snapshot)
+	getModelSchema := mcp.NewTypedToolHandler(handleGetModelSchema(mock, snapshot))
+	schemaRequest := func(class string) mcp.CallToolRequest {
+		var req mcp.CallToolRequest
+		req.Params.Arguments = map[string]any{"model_class": class}
+		return req
+	}
+
+	// Unreachable vmanomaly is replaced by the snapshot, marked with its version
+	listErr = fmt.Errorf("request failed: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")})
+	schemaErr = vmanomaly.ErrCircuitOpen
+	result, _ := listModels(context.Background(), mcp.CallToolRequest{})
+	if result.IsError || !strings.Contains(resultText(result), "offline snapshot") ||
+		!strings.Contains(resultText(result), "vmanomaly v1.28.0") || !strings.Contains(resultText(result), `"zscore"`) {
+		t.Errorf("expected models from snapshot, got %q", resultText(result))
+	}
+	result, _ = getModelSchema(context.Background(), schemaRequest("zscore"))
+	if result.IsError || !strings.Contains(resultText(result), "vmanomaly v1.28.0") || !strings.Contains(resultText(result), "ZScoreModel") {
+		t.Errorf("expected schema from snapshot, got %q", resultText(result))
+	}
+	result, _ = getModelSchema(context.Background(), schemaRequest("prophet"))
+	if !result.IsError {
+		t.Errorf("expected error for model missing in snapshot, got %q", resultText(result))
+	}
+
+	// Errors reported by vmanomaly itself aren't masked by the snapshot
+	schemaErr = &vmanomaly.APIError{StatusCode: 404, Detail: "unknown model class"}
+	result, _ = getModelSchema(context.Background(), schemaRequest("zscore"))
+	if !result.IsError || !strings.Contains(resultText(result), "unknown model class") {
+		t.Errorf("expected API error, got %q", resultText(result))
+	}
+
+	// Without snapshot errors are returned as is
+	result, _ = handleListModels(mock, nil)(context.Background(), mcp.CallToolRequest{})
+	if !result.IsError || !strings.Contains(resultText(result), "connection refused") {
+		t.Errorf("expected network error, got %q", resultText(result))
+	}
+}
@@ -0,0 +1,281 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultQueryStep      = "1m"
+	defaultQueryMaxSeries = 20
+	defaultQueryMaxPoints = 200
+	maxQueryMaxSeries     = 500
+	maxQueryMaxPoints     = 5000
+)
+
+// ============================================================================
+// Query Tool Arguments (Struct-based schemas)
+// ============================================================================
+
+// QueryArgs defines arguments for query tool
+type QueryArgs struct {
+	Query          string `json:"query" jsonschema:"required,description=MetricsQL or LogsQL query to execute against the datasource"`
+	Start          string `json:"start,omitempty" jsonschema_description:"Query start time as RFC3339 timestamp (e.g. '2025-01-01T00:00:00Z') or Unix seconds. Defaults to server-side behaviour."`
+	End            string `json:"end,omitempty" jsonschema_description:"Query end time as RFC3339 timestamp or Unix seconds. Defaults to server-side behaviour."`
+	Step           string `json:"step,omitempty" jsonschema:"description=Query step/resolution (default: '1m')"`
+	DatasourceType string `json:"datasource_type,omitempty" jsonschema:"enum=vm,enum=vmlogs,description=Datasource type: 'vm' for VictoriaMetrics or 'vmlogs' for VictoriaLogs (default: 'vm')"`
+	DatasourceURL  string `json:"datasource_url,omitempty" jsonschema_description:"Datasource URL (e.g. 'http://victoriametrics:8428'). Defaults to the datasource configured on the vmanomaly server."`
+	TenantID       string `json:"tenant_id,omitempty" jsonschema:"description=Optional tenant ID for multi-tenancy support"`
+	MaxSeries      int    `json:"max_series,omitempty" jsonschema:"description=Maximum number of series to return (default: 20)"`
+	MaxPoints      int    `json:"max_points,omitempty" jsonschema_description:"Maximum number of points to return per series (default: 200). Series are downsampled evenly; stats are always computed on all points."`
+}
+
+// ============================================================================
+// Query Tool Responses
+// ============================================================================
+
+// QueryResponse is the structured output of query tool
+type QueryResponse struct {
+	Summary        string        `json:"summary" jsonschema_description:"Human-readable summary of the query result"`
+	TotalSeries    int           `json:"total_series" jsonschema_description:"Number of series returned by the datasource"`
+	ReturnedSeries int           `json:"returned_series" jsonschema_description:"Number of series included in this response"`
+	Series         []QuerySeries `json:"series" jsonschema_description:"Returned series with stats and (possibly downsampled) points"`
+}
+
+// QuerySeries is a single series in query output
+type QuerySeries struct {
+	Labels      map[string]string `json:"labels" jsonschema_description:"Series labels"`
+	Stats       SeriesStats       `json:"stats" jsonschema_description:"Stats computed over all points of the series"`
+	Points      [][2]float64      `json:"points" jsonschema_description:"[unix_seconds, value] pairs; NaN values are omitted"`
+	Downsampled bool              `json:"downsampled,omitempty" jsonschema_description:"Whether points were downsampled to fit max_points"`
+}
+
+// SeriesStats contains basic stats of a series
+type SeriesStats struct {
+	Samples     int      `json:"samples" jsonschema_description:"Number of non-NaN samples"`
+	Min         *float64 `json:"min,omitempty" jsonschema_description:"Minimum value"`
+	Max         *float64 `json:"max,omitempty" jsonschema_description:"Maximum value"`
+	Mean        *float64 `json:"mean,omitempty" jsonschema_description:"Mean value"`
+	StepSeconds float64  `json:"step_seconds,omitempty" jsonschema_description:"Most common distance between samples in seconds"`
+	Gaps        int      `json:"gaps" jsonschema_description:"Number of gaps (missing data between consecutive samples)"`
+	Missing     int      `json:"missing_points" jsonschema_description:"Estimated number of missing points within gaps"`
+}
+
+// ============================================================================
+// Tool Registration Functions
+// ============================================================================
+
+// RegisterQueryTools registers datasource query tools
+func RegisterQueryTools(s *server.MCPServer, client vmanomaly.API) {
+	queryTool := mcp.NewTool(
+		"vmanomaly_query",
+		mcp.WithDescription("Execute a MetricsQL or LogsQL range query against the datasource through vmanomaly. Returns typed series with per-series stats (min, max, mean, samples and gaps) and downsampled points. Use this to look at the data before picking a model or tuning its parameters."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Query Datasource",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[QueryArgs](),
+		mcp.WithOutputSchema[QueryResponse](),
+	)
+	s.AddTool(withInstance(queryTool, mcp.NewStructuredToolHandler(handleQuery(client))))
+}
+
+// ============================================================================
+// Tool Handlers
+// ============================================================================
+
+func handleQuery(client vmanomaly.API) mcp.StructuredToolHandlerFunc[QueryArgs, QueryResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args QueryArgs) (QueryResponse, error) {
+		queryReq, err := buildQueryRequest(args)
+		if err != nil {
+			return QueryResponse{}, err
+		}
+		queryReq.PassAuthHeaders = passAuthHeaders(ctx)
+
+		data, err := client.Query(ctx, queryReq)
+		if err != nil {
+			return QueryResponse{}, fmt.Errorf("query failed: %w", err)
+		}
+
+		series, err := vmanomaly.ParseMatrix(data)
+		if err != nil {
+			return QueryResponse{}, fmt.Errorf("failed to parse query response: %w", err)
+		}
+
+		maxSeries := clampLimit(args.MaxSeries, defaultQueryMaxSeries, maxQueryMaxSeries)
+		maxPoints := clampLimit(args.MaxPoints, defaultQueryMaxPoints, maxQueryMaxPoints)
+
+		return newQueryResponse(series, maxSeries, maxPoints), nil
+	}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+func buildQueryRequest(args QueryArgs) (*vmanomaly.QueryRequest, error) {
+	if args.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	queryReq := &vmanomaly.QueryRequest{
+		Query:          args.Query,
+		Step:           args.Step,
+		DatasourceType: args.DatasourceType,
+	}
+	if queryReq.Step == "" {
+		queryReq.Step = defaultQueryStep
+	}
+	if queryReq.DatasourceType == "" {
+		queryReq.DatasourceType = defaultTaskDatasourceType
+	}
+	if args.DatasourceURL != "" {
+		queryReq.DatasourceURL = &args.DatasourceURL
+	}
+	if args.TenantID != "" {
+		queryReq.TenantID = &args.TenantID
+	}
+
+	if args.Start != "" {
+		ts, err := parseTimestamp(args.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start: %w", err)
+		}
+		queryReq.Start = &ts
+	}
+	if args.End != "" {
+		ts, err := parseTimestamp(args.End)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end: %w", err)
+		}
+		queryReq.End = &ts
+	}
+	if queryReq.Start != nil && queryReq.End != nil && *queryReq.End <= *queryReq.Start {
+		return nil, fmt.Errorf("end must be after start")
+	}
+
+	return queryReq, nil
+}
+
+func newQueryResponse(series []vmanomaly.Series, maxSeries, maxPoints int) QueryResponse {
+	resp := QueryResponse{
+		TotalSeries: len(series),
+		Series:      []QuerySeries{},
+	}
+
+	var downsampled int
+	for i, s := range series {
+		if i >= maxSeries {
+			break
+		}
+		qs := QuerySeries{
+			Labels: s.Labels,
+			Stats:  computeSeriesStats(s.Samples),
+			Points: [][2]float64{},
+		}
+
+		var points [][2]float64
+		for _, sample := range s.Samples {
+			if !math.IsNaN(sample.Value) && !math.IsInf(sample.Value, 0) {
+				points = append(points, [2]float64{sample.Timestamp, sample.Value})
+			}
+		}
+		if len(points) > maxPoints {
+			points = downsamplePoints(points, maxPoints)
+			qs.Downsampled = true
+			downsampled++
+		}
+		qs.Points = append(qs.Points, points...)
+
+		resp.Series = append(resp.Series, qs)
+	}
+	resp.ReturnedSeries = len(resp.Series)
+
+	var sb strings.Builder
+	switch {
+	case resp.TotalSeries == 0:
+		sb.WriteString("Query returned no series.")
+	case resp.ReturnedSeries < resp.TotalSeries:
+		sb.WriteString(fmt.Sprintf("Query returned %d series, showing first %d (increase max_series or narrow the query to see more).", resp.TotalSeries, resp.ReturnedSeries))
+	default:
+		sb.WriteString(fmt.Sprintf("Query returned %d series.", resp.TotalSeries))
+	}
+	if downsampled > 0 {
+		sb.WriteString(fmt.Sprintf(" %d series were downsampled to %d points.", downsampled, maxPoints))
+	}
+	resp.Summary = sb.String()
+
+	return resp
+}
+
+// computeSeriesStats computes stats over non-NaN samples. Gaps are detected when
+// the distance between consecutive samples exceeds one and a half inferred steps.
+func computeSeriesStats(samples []vmanomaly.Sample) SeriesStats {
+	var (
+		stats SeriesStats
+		valid []vmanomaly.Sample
+		sum   float64
+		lo    = math.Inf(1)
+		hi    = math.Inf(-1)
+	)
+	for _, s := range samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		valid = append(valid, s)
+		sum += s.Value
+		lo = math.Min(lo, s.Value)
+		hi = math.Max(hi, s.Value)
+	}
+
+	stats.Samples = len(valid)
+	if stats.Samples == 0 {
+		return stats
+	}
+	mean := sum / float64(stats.Samples)
+	stats.Min, stats.Max, stats.Mean = &lo, &hi, &mean
+
+	stats.StepSeconds = vmanomaly.InferStep(valid)
+	if stats.StepSeconds <= 0 {
+		return stats
+	}
+	for i := 1; i < len(valid); i++ {
+		d := valid[i].Timestamp - valid[i-1].Timestamp
+		if d > 1.5*stats.StepSeconds {
+			stats.Gaps++
+			stats.Missing += int(math.Round(d/stats.StepSeconds)) - 1
+		}
+	}
+
+	return stats
+}
+
+// downsamplePoints picks n evenly spaced points keeping the first and the last one
+func downsamplePoints(points [][2]float64, n int) [][2]float64 {
+	if n <= 1 {
+		return points[:n]
+	}
+	result := make([][2]float64, 0, n)
+	last := len(points) - 1
+	for i := 0; i < n; i++ {
+		result = append(result, points[i*last/(n-1)])
+	}
+	return result
+}
+
+// clampLimit applies a default to non-positive values and caps the value at maxValue
+func clampLimit(v, defaultValue, maxValue int) int {
+	if v <= 0 {
+		return defaultValue
+	}
+	return min(v, maxValue)
+}
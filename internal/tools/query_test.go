@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+)
+
+func TestComputeSeriesStats(t *testing.T) {
+	samples := []vmanomaly.Sample{
+		{Timestamp: 0, Value: 1},
+		{Timestamp: 60, Value: 3},
+		{Timestamp: 120, Value: math.NaN()},
+		// 2 points missing
+		{Timestamp: 240, Value: 5},
+		{Timestamp: 300, Value: 7},
+	}
+
+	stats := computeSeriesStats(samples)
+
+	if stats.Samples != 4 {
+		t.Errorf("samples = %d, want 4", stats.Samples)
+	}
+	if stats.Min == nil || *stats.Min != 1 || stats.Max == nil || *stats.Max != 7 || stats.Mean == nil || *stats.Mean != 4 {
+		t.Errorf("unexpected min/max/mean: %v %v %v", stats.Min, stats.Max, stats.Mean)
+	}
+	if stats.StepSeconds != 60 {
+		t.Errorf("step = %g, want 60", stats.StepSeconds)
+	}
+	if stats.Gaps != 1 || stats.Missing != 2 {
+		t.Errorf("gaps = %d, missing = %d, want 1 and 2", stats.Gaps, stats.Missing)
+	}
+
+	empty := computeSeriesStats([]vmanomaly.Sample{{Timestamp: 0, Value: math.NaN()}})
+	if empty.Samples != 0 || empty.Min != nil {
+		t.Errorf("unexpected stats for empty series: %+v", empty)
+	}
+}
+
+func TestNewQueryResponse_Caps(t *testing.T) {
+	var series []vmanomaly.Series
+	for i := 0; i < 5; i++ {
+		s := vmanomaly.Series{Labels: map[string]string{"i": string(rune('a' + i))}}
+		for j := 0; j < 100; j++ {
+			s.Samples = append(s.Samples, vmanomaly.Sample{Timestamp: float64(j * 60), Value: float64(j)})
+		}
+		series = append(series, s)
+	}
+
+	resp := newQueryResponse(series, 3, 10)
+
+	if resp.TotalSeries != 5 || resp.ReturnedSeries != 3 || len(resp.Series) != 3 {
+		t.Fatalf("unexpected series counts: total=%d returned=%d", resp.TotalSeries, resp.ReturnedSeries)
+	}
+	first := resp.Series[0]
+	if len(first.Points) != 10 || !first.Downsampled {
+		t.Errorf("expected 10 downsampled points, got %d", len(first.Points))
+	}
+	if first.Points[0][0] != 0 || first.Points[9][0] != 99*60 {
+		t.Errorf("expected first and last points to be kept, got %v and %v", first.Points[0], first.Points[9])
+	}
+	if first.Stats.Samples != 100 {
+		t.Errorf("expected stats over all samples, got %d", first.Stats.Samples)
+	}
+}
+
+func TestBuildQueryRequest(t *testing.T) {
+	queryReq, err := buildQueryRequest(QueryArgs{Query: "up", Start: "1700000000", End: "2023-11-14T23:13:20Z"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queryReq.Step != "1m" || queryReq.DatasourceType != "vm" {
+		t.Errorf("unexpected defaults: step=%s datasource_type=%s", queryReq.Step, queryReq.DatasourceType)
+	}
+	if *queryReq.Start != 1700000000 || *queryReq.End != 1700003600 {
+		t.Errorf("unexpected time range: %g-%g", *queryReq.Start, *queryReq.End)
+	}
+
+	if _, err := buildQueryRequest(QueryArgs{}); err == nil {
+		t.Error("expected error for empty query")
+	}
+	if _, err := buildQueryRequest(QueryArgs{Query: "up", Start: "100", End: "50"}); err == nil {
+		t.Error("expected error for inverted time range")
+	}
+}
+
+func TestQuery_Handler(t *testing.T) {
+	var receivedReq *vmanomaly.QueryRequest
+	mock := &MockClient{
+		QueryFunc: func(ctx context.Context, req *vmanomaly.QueryRequest) (map[string]any, error) {
+			receivedReq = req
+			return map[string]any{
+				"status": "success",
+				"data": map[string]any{
+					"resultType": "matrix",
+					"result": []any{
+						map[string]any{
+							"metric": map[string]any{"__name__": "up", "job": "vm"},
+							"values": []any{[]any{0.0, "1"}, []any{60.0, "1"}, []any{120.0, "0"}},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_query", map[string]any{"query": "up", "start": "0", "end": "120"})
+	resp := structuredResult[QueryResponse](t, result)
+
+	if receivedReq.Step != defaultQueryStep {
+		t.Errorf("expected default step=%s, got %s", defaultQueryStep, receivedReq.Step)
+	}
+	if resp.TotalSeries != 1 || len(resp.Series) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.Series[0].Stats.Samples != 3 || len(resp.Series[0].Points) != 3 {
+		t.Errorf("unexpected series: %+v", resp.Series[0])
+	}
+}
+
+func TestQuery_PassAuthHeaders(t *testing.T) {
+	var receivedReq *vmanomaly.QueryRequest
+	mock := &MockClient{
+		QueryFunc: func(ctx context.Context, req *vmanomaly.QueryRequest) (map[string]any, error) {
+			receivedReq = req
+			return map[string]any{"status": "success", "data": map[string]any{"resultType": "matrix", "result": []any{}}}, nil
+		},
+	}
+
+	callTool(t, mock, "vmanomaly_query", map[string]any{"query": "up"})
+	if receivedReq.PassAuthHeaders {
+		t.Error("expected pass_auth_headers=false without caller credentials")
+	}
+
+	ctx := vmanomaly.ContextWithAuthorization(context.Background(), "Bearer user-token")
+	callToolContext(t, ctx, mock, "vmanomaly_query", map[string]any{"query": "up"})
+	if !receivedReq.PassAuthHeaders {
+		t.Error("expected pass_auth_headers=true with caller credentials")
+	}
+}
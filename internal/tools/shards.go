@@ -0,0 +1,492 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Alias issue kinds reported by aggregate tools
+const (
+	aliasIssueMissing    = "missing"
+	aliasIssueDuplicated = "duplicated"
+	aliasIssueConflict   = "conflict"
+)
+
+// ============================================================================
+// Shard Tool Arguments (Struct-based schemas)
+// ============================================================================
+
+// ShardArgs defines arguments common to aggregate tools
+type ShardArgs struct {
+	Instances         []string `json:"instances,omitempty" jsonschema_description:"Instances (shards) to query, all configured instances by default"`
+	ReplicationFactor int      `json:"replication_factor,omitempty" jsonschema_description:"Expected number of shards every alias is assigned to, i.e. VMANOMALY_REPLICATION_FACTOR of the deployment (default: 1)"`
+}
+
+// AggregateMetricsArgs defines arguments for aggregate metrics tool
+type AggregateMetricsArgs struct {
+	Instances []string `json:"instances,omitempty" jsonschema_description:"Instances (shards) to query, all configured instances by default"`
+	Match     string   `json:"match,omitempty" jsonschema_description:"Regular expression metric names must match (e.g. '^vmanomaly_model_'). All metrics by default."`
+}
+
+// ============================================================================
+// Shard Tool Responses
+// ============================================================================
+
+// ShardError is an error of a single shard in aggregate tools
+type ShardError struct {
+	Instance string `json:"instance" jsonschema_description:"Instance name"`
+	Error    string `json:"error" jsonschema_description:"Error returned by the instance"`
+}
+
+// AliasIssue is an inconsistency of an alias across shards
+type AliasIssue struct {
+	Alias  string   `json:"alias" jsonschema_description:"Model or query alias"`
+	Issue  string   `json:"issue" jsonschema_description:"Issue kind: missing (on fewer shards than the replication factor), duplicated (on more shards than the replication factor) or conflict (configured differently across shards)"`
+	Shards []string `json:"shards" jsonschema_description:"Shards the alias is configured on"`
+}
+
+// AggregateServerModelsResponse is the structured output of aggregate server models tool
+type AggregateServerModelsResponse struct {
+	Summary string            `json:"summary" jsonschema_description:"Human-readable summary of the merged inventory"`
+	Shards  []string          `json:"shards" jsonschema_description:"Shards that responded"`
+	Errors  []ShardError      `json:"errors,omitempty" jsonschema_description:"Shards that failed, the inventory is incomplete without them"`
+	Models  []AggregatedModel `json:"models" jsonschema_description:"Models merged across shards"`
+	Issues  []AliasIssue      `json:"issues,omitempty" jsonschema_description:"Model aliases that are missing, duplicated or conflicting across shards"`
+}
+
+// AggregatedModel is a model alias merged across shards
+type AggregatedModel struct {
+	Alias   string   `json:"alias" jsonschema_description:"Model alias"`
+	Class   string   `json:"class,omitempty" jsonschema_description:"Model class"`
+	Shards  []string `json:"shards" jsonschema_description:"Shards the model is configured on"`
+	Queries []string `json:"queries" jsonschema_description:"Query aliases the model is attached to, across all shards"`
+}
+
+// AggregateServerQueriesResponse is the structured output of aggregate server queries tool
+type AggregateServerQueriesResponse struct {
+	Summary string            `json:"summary" jsonschema_description:"Human-readable summary of the merged inventory"`
+	Shards  []string          `json:"shards" jsonschema_description:"Shards that responded"`
+	Errors  []ShardError      `json:"errors,omitempty" jsonschema_description:"Shards that failed, the inventory is incomplete without them"`
+	Queries []AggregatedQuery `json:"queries" jsonschema_description:"Queries merged across shards"`
+	Issues  []AliasIssue      `json:"issues,omitempty" jsonschema_description:"Query aliases that are missing, duplicated or conflicting across shards"`
+}
+
+// AggregatedQuery is a query alias merged across shards
+type AggregatedQuery struct {
+	Alias  string   `json:"alias" jsonschema_description:"Query alias"`
+	Exprs  []string `json:"exprs" jsonschema_description:"Distinct expressions of the alias, more than one means a conflict"`
+	Shards []string `json:"shards" jsonschema_description:"Shards the query is configured on"`
+}
+
+// AggregateMetricsResponse is the structured output of aggregate metrics tool
+type AggregateMetricsResponse struct {
+	Summary string             `json:"summary" jsonschema_description:"Human-readable summary"`
+	Shards  []ShardMetrics     `json:"shards" jsonschema_description:"Metrics rolled up per shard"`
+	Totals  map[string]float64 `json:"totals" jsonschema_description:"Counters (_total, _count and _sum metrics) summed across all shards, keyed by metric name. Gauges are only reported per shard."`
+	Errors  []ShardError       `json:"errors,omitempty" jsonschema_description:"Shards that failed"`
+}
+
+// ShardMetrics is self-monitoring metrics of a single shard rolled up by metric name
+type ShardMetrics struct {
+	Instance string             `json:"instance" jsonschema_description:"Instance name"`
+	Series   int                `json:"series" jsonschema_description:"Number of series rolled up"`
+	Metrics  map[string]float64 `json:"metrics" jsonschema_description:"Values summed over all label sets, keyed by metric name"`
+}
+
+// ============================================================================
+// Tool Registration Functions
+// ============================================================================
+
+// RegisterShardTools registers tools aggregating data across all vmanomaly instances (shards)
+func RegisterShardTools(s *server.MCPServer, instances *vmanomaly.Instances) {
+	aggregateModelsTool := mcp.NewTool(
+		"vmanomaly_aggregate_server_models",
+		mcp.WithDescription("Query configured runtime models from every vmanomaly instance (shard) in parallel and merge them into one inventory. Flags model aliases that are missing on or duplicated across shards compared to the replication factor, or configured differently on different shards. Use this for sharded or HA deployments instead of calling vmanomaly_get_server_models per instance."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Aggregate Server Models Across Shards",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[ShardArgs](),
+		mcp.WithOutputSchema[AggregateServerModelsResponse](),
+	)
+	s.AddTool(aggregateModelsTool, mcp.NewStructuredToolHandler(handleAggregateServerModels(instances)))
+
+	aggregateQueriesTool := mcp.NewTool(
+		"vmanomaly_aggregate_server_queries",
+		mcp.WithDescription("Query configured reader queries from every vmanomaly instance (shard) in parallel and merge them into one inventory. Flags query aliases that are missing on or duplicated across shards compared to the replication factor, or have different expressions on different shards."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Aggregate Server Queries Across Shards",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[ShardArgs](),
+		mcp.WithOutputSchema[AggregateServerQueriesResponse](),
+	)
+	s.AddTool(aggregateQueriesTool, mcp.NewStructuredToolHandler(handleAggregateServerQueries(instances)))
+
+	aggregateMetricsTool := mcp.NewTool(
+		"vmanomaly_aggregate_metrics",
+		mcp.WithDescription("Collect self-monitoring metrics from every vmanomaly instance (shard) in parallel and roll them up per shard by metric name (values summed over label sets, histogram buckets skipped), with totals of counters (_total, _count and _sum metrics) across shards. Use 'match' to focus on specific metrics."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Aggregate Self-Monitoring Metrics Across Shards",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[AggregateMetricsArgs](),
+		mcp.WithOutputSchema[AggregateMetricsResponse](),
+	)
+	s.AddTool(aggregateMetricsTool, mcp.NewStructuredToolHandler(handleAggregateMetrics(instances)))
+}
+
+// ============================================================================
+// Tool Handlers
+// ============================================================================
+
+func handleAggregateServerModels(instances *vmanomaly.Instances) mcp.StructuredToolHandlerFunc[ShardArgs, AggregateServerModelsResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args ShardArgs) (AggregateServerModelsResponse, error) {
+		shards, err := selectShards(instances, args.Instances)
+		if err != nil {
+			return AggregateServerModelsResponse{}, err
+		}
+		results := fanOut(ctx, shards, func(ctx context.Context, client vmanomaly.API) (*vmanomaly.ServerModelsResponse, error) {
+			return client.GetServerModels(ctx)
+		})
+
+		resp := AggregateServerModelsResponse{Models: []AggregatedModel{}}
+		placement := make(map[string][]string)
+		configs := make(map[string][]map[string]any)
+		merged := make(map[string]*AggregatedModel)
+		for _, r := range results {
+			if r.err != nil {
+				resp.Errors = append(resp.Errors, ShardError{Instance: r.instance, Error: r.err.Error()})
+				continue
+			}
+			resp.Shards = append(resp.Shards, r.instance)
+			for alias, model := range r.value.Models {
+				m, ok := merged[alias]
+				if !ok {
+					m = &AggregatedModel{Alias: alias, Queries: []string{}}
+					m.Class, _ = model.ModelConfiguration["class"].(string)
+					merged[alias] = m
+				}
+				m.Shards = append(m.Shards, r.instance)
+				for queryAlias := range model.Queries {
+					if !slices.Contains(m.Queries, queryAlias) {
+						m.Queries = append(m.Queries, queryAlias)
+					}
+				}
+				placement[alias] = append(placement[alias], r.instance)
+				configs[alias] = append(configs[alias], model.ModelConfiguration)
+			}
+		}
+		if len(resp.Shards) == 0 {
+			return AggregateServerModelsResponse{}, fmt.Errorf("all shards failed: %s", formatShardErrors(resp.Errors))
+		}
+
+		for _, alias := range sortedKeys(merged) {
+			m := merged[alias]
+			sort.Strings(m.Queries)
+			resp.Models = append(resp.Models, *m)
+		}
+		resp.Issues = aliasIssues(placement, args.ReplicationFactor, func(alias string) bool {
+			return hasDistinct(configs[alias])
+		})
+
+		resp.Summary = fmt.Sprintf("%d models across %d of %d shards, %s.",
+			len(resp.Models), len(resp.Shards), len(shards), issuesSummary(resp.Issues))
+		return resp, nil
+	}
+}
+
+func handleAggregateServerQueries(instances *vmanomaly.Instances) mcp.StructuredToolHandlerFunc[ShardArgs, AggregateServerQueriesResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args ShardArgs) (AggregateServerQueriesResponse, error) {
+		shards, err := selectShards(instances, args.Instances)
+		if err != nil {
+			return AggregateServerQueriesResponse{}, err
+		}
+		results := fanOut(ctx, shards, func(ctx context.Context, client vmanomaly.API) (vmanomaly.ServerQueriesResponse, error) {
+			return client.GetServerQueries(ctx)
+		})
+
+		resp := AggregateServerQueriesResponse{Queries: []AggregatedQuery{}}
+		placement := make(map[string][]string)
+		merged := make(map[string]*AggregatedQuery)
+		for _, r := range results {
+			if r.err != nil {
+				resp.Errors = append(resp.Errors, ShardError{Instance: r.instance, Error: r.err.Error()})
+				continue
+			}
+			resp.Shards = append(resp.Shards, r.instance)
+			for alias, expr := range r.value {
+				q, ok := merged[alias]
+				if !ok {
+					q = &AggregatedQuery{Alias: alias}
+					merged[alias] = q
+				}
+				q.Shards = append(q.Shards, r.instance)
+				if !slices.Contains(q.Exprs, expr) {
+					q.Exprs = append(q.Exprs, expr)
+				}
+				placement[alias] = append(placement[alias], r.instance)
+			}
+		}
+		if len(resp.Shards) == 0 {
+			return AggregateServerQueriesResponse{}, fmt.Errorf("all shards failed: %s", formatShardErrors(resp.Errors))
+		}
+
+		for _, alias := range sortedKeys(merged) {
+			resp.Queries = append(resp.Queries, *merged[alias])
+		}
+		resp.Issues = aliasIssues(placement, args.ReplicationFactor, func(alias string) bool {
+			return len(merged[alias].Exprs) > 1
+		})
+
+		resp.Summary = fmt.Sprintf("%d queries across %d of %d shards, %s.",
+			len(resp.Queries), len(resp.Shards), len(shards), issuesSummary(resp.Issues))
+		return resp, nil
+	}
+}
+
+func handleAggregateMetrics(instances *vmanomaly.Instances) mcp.StructuredToolHandlerFunc[AggregateMetricsArgs, AggregateMetricsResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args AggregateMetricsArgs) (AggregateMetricsResponse, error) {
+		var match *regexp.Regexp
+		if args.Match != "" {
+			var err error
+			match, err = regexp.Compile(args.Match)
+			if err != nil {
+				return AggregateMetricsResponse{}, fmt.Errorf("invalid match: %w", err)
+			}
+		}
+		shards, err := selectShards(instances, args.Instances)
+		if err != nil {
+			return AggregateMetricsResponse{}, err
+		}
+		results := fanOut(ctx, shards, func(ctx context.Context, client vmanomaly.API) (string, error) {
+			return client.Metrics(ctx, nil)
+		})
+
+		resp := AggregateMetricsResponse{Shards: []ShardMetrics{}, Totals: make(map[string]float64)}
+		for _, r := range results {
+			if r.err != nil {
+				resp.Errors = append(resp.Errors, ShardError{Instance: r.instance, Error: r.err.Error()})
+				continue
+			}
+			metrics, series := rollUpMetrics(r.value, match)
+			for name, v := range metrics {
+				if isCounter(name) {
+					resp.Totals[name] += v
+				}
+			}
+			resp.Shards = append(resp.Shards, ShardMetrics{Instance: r.instance, Series: series, Metrics: metrics})
+		}
+		if len(resp.Shards) == 0 {
+			return AggregateMetricsResponse{}, fmt.Errorf("all shards failed: %s", formatShardErrors(resp.Errors))
+		}
+
+		resp.Summary = fmt.Sprintf("%d counters rolled up from %d of %d shards.", len(resp.Totals), len(resp.Shards), len(shards))
+		if len(resp.Errors) > 0 {
+			resp.Summary += fmt.Sprintf(" Failed shards: %s.", formatShardErrors(resp.Errors))
+		}
+		return resp, nil
+	}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// shardResult is the result of a single shard call in fanOut
+type shardResult[T any] struct {
+	instance string
+	value    T
+	err      error
+}
+
+// fanOut calls fn for every shard in parallel and returns results in shards order
+func fanOut[T any](ctx context.Context, shards []vmanomaly.Instance, fn func(ctx context.Context, client vmanomaly.API) (T, error)) []shardResult[T] {
+	results := make([]shardResult[T], len(shards))
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := fn(ctx, shard.Client)
+			results[i] = shardResult[T]{instance: shard.Name, value: value, err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// selectShards returns instances with the given names, or all instances if names is empty.
+// Duplicate names are queried once.
+func selectShards(instances *vmanomaly.Instances, names []string) ([]vmanomaly.Instance, error) {
+	if len(names) == 0 {
+		return instances.List(), nil
+	}
+	shards := make([]vmanomaly.Instance, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		inst, err := instances.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, inst)
+	}
+	return shards, nil
+}
+
+// aliasIssues checks alias placement across shards against the replication factor
+func aliasIssues(placement map[string][]string, replicationFactor int, conflicting func(alias string) bool) []AliasIssue {
+	if replicationFactor <= 0 {
+		replicationFactor = 1
+	}
+	var issues []AliasIssue
+	for _, alias := range sortedKeys(placement) {
+		shards := placement[alias]
+		switch {
+		case len(shards) < replicationFactor:
+			issues = append(issues, AliasIssue{Alias: alias, Issue: aliasIssueMissing, Shards: shards})
+		case len(shards) > replicationFactor:
+			issues = append(issues, AliasIssue{Alias: alias, Issue: aliasIssueDuplicated, Shards: shards})
+		}
+		if conflicting(alias) {
+			issues = append(issues, AliasIssue{Alias: alias, Issue: aliasIssueConflict, Shards: shards})
+		}
+	}
+	return issues
+}
+
+func issuesSummary(issues []AliasIssue) string {
+	if len(issues) == 0 {
+		return "no missing, duplicated or conflicting aliases"
+	}
+	counts := make(map[string]int)
+	for _, issue := range issues {
+		counts[issue.Issue]++
+	}
+	parts := make([]string, 0, len(counts))
+	for _, kind := range []string{aliasIssueMissing, aliasIssueDuplicated, aliasIssueConflict} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	return "issues: " + strings.Join(parts, ", ")
+}
+
+func formatShardErrors(errs []ShardError) string {
+	parts := make([]string, 0, len(errs))
+	for _, e := range errs {
+		parts = append(parts, fmt.Sprintf("%s (%s)", e.Instance, e.Error))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// hasDistinct reports whether configs are not all equal
+func hasDistinct(configs []map[string]any) bool {
+	for _, c := range configs[1:] {
+		if !reflect.DeepEqual(c, configs[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rollUpMetrics sums Prometheus text exposition values by metric name over all label sets.
+// Histogram buckets and non-finite values are skipped. It returns sums and the number of series used.
+func rollUpMetrics(text string, match *regexp.Regexp) (map[string]float64, int) {
+	sums := make(map[string]float64)
+	series := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := parseSample(line)
+		if !ok || strings.HasSuffix(name, "_bucket") || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		if match != nil && !match.MatchString(name) {
+			continue
+		}
+		sums[name] += value
+		series++
+	}
+	return sums, series
+}
+
+// isCounter reports whether the metric is a counter by its name, so its values can be summed across shards.
+// Sums of gauges such as process_start_time_seconds or *_info are meaningless.
+func isCounter(name string) bool {
+	return strings.HasSuffix(name, "_total") || strings.HasSuffix(name, "_count") || strings.HasSuffix(name, "_sum")
+}
+
+// parseSample parses name and value of a Prometheus text exposition sample line
+func parseSample(line string) (string, float64, bool) {
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return "", 0, false
+	}
+	name, rest := line[:end], line[end:]
+
+	if rest[0] == '{' {
+		// Skip labels, taking quoted values with escaped quotes into account
+		inQuotes, escaped, closed := false, false, -1
+		for i := 1; i < len(rest) && closed < 0; i++ {
+			switch c := rest[i]; {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inQuotes = !inQuotes
+			case c == '}' && !inQuotes:
+				closed = i
+			}
+		}
+		if closed < 0 {
+			return "", 0, false
+		}
+		rest = rest[closed+1:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return name, value, true
+}
@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// callShardTool registers instance tools against instances and invokes the named tool handler
+func callShardTool(t *testing.T, instances *vmanomaly.Instances, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	s := server.NewMCPServer("test", "0.0.0", server.WithToolCapabilities(true))
+	RegisterInstanceTools(s, instances)
+
+	var req mcp.CallToolRequest
+	req.Params.Name = name
+	req.Params.Arguments = args
+	result, err := s.GetTool(name).Handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("tool %q returned error: %v", name, err)
+	}
+	return result
+}
+
+func newShards(t *testing.T, clients map[string]*MockClient, names ...string) *vmanomaly.Instances {
+	t.Helper()
+
+	list := make([]vmanomaly.Instance, 0, len(names))
+	for _, name := range names {
+		list = append(list, vmanomaly.Instance{Name: name, Client: clients[name]})
+	}
+	instances, err := vmanomaly.NewInstances(list, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return instances
+}
+
+func TestAggregateServerModels(t *testing.T) {
+	shardModels := func(models map[string]vmanomaly.ServerModelResponse) *MockClient {
+		return &MockClient{
+			GetServerModelsFunc: func(ctx context.Context) (*vmanomaly.ServerModelsResponse, error) {
+				return &vmanomaly.ServerModelsResponse{Models: models}, nil
+			},
+		}
+	}
+	zscore := map[string]any{"class": "zscore", "z_threshold": 2.5}
+	instances := newShards(t, map[string]*MockClient{
+		"shard-0": shardModels(map[string]vmanomaly.ServerModelResponse{
+			"m_zscore":  {ModelConfiguration: zscore, Queries: map[string]vmanomaly.ServerQueryConfig{"cpu": {}}},
+			"m_prophet": {ModelConfiguration: map[string]any{"class": "prophet"}},
+		}),
+		"shard-1": shardModels(map[string]vmanomaly.ServerModelResponse{
+			"m_zscore": {ModelConfiguration: zscore, Queries: map[string]vmanomaly.ServerQueryConfig{"memory": {}}},
+			"m_mad":    {ModelConfiguration: map[string]any{"class": "mad", "threshold": 3}},
+		}),
+		"shard-2": shardModels(map[string]vmanomaly.ServerModelResponse{
+			"m_mad": {ModelConfiguration: map[string]any{"class": "mad", "threshold": 5}},
+		}),
+		"shard-3": {GetServerModelsFunc: func(ctx context.Context) (*vmanomaly.ServerModelsResponse, error) {
+			return nil, errors.New("connection refused")
+		}},
+	}, "shard-0", "shard-1", "shard-2", "shard-3")
+
+	t.Run("replication factor 1", func(t *testing.T) {
+		resp := structuredResult[AggregateServerModelsResponse](t, callShardTool(t, instances, "vmanomaly_aggregate_server_models", nil))
+
+		if len(resp.Shards) != 3 || len(resp.Errors) != 1 || resp.Errors[0].Instance != "shard-3" {
+			t.Fatalf("unexpected shards %v and errors %v", resp.Shards, resp.Errors)
+		}
+		if len(resp.Models) != 3 {
+			t.Fatalf("expected 3 models, got %+v", resp.Models)
+		}
+		zs := resp.Models[2]
+		if zs.Alias != "m_zscore" || zs.Class != "zscore" || strings.Join(zs.Queries, ",") != "cpu,memory" || len(zs.Shards) != 2 {
+			t.Errorf("unexpected merged model: %+v", zs)
+		}
+
+		want := []AliasIssue{
+			{Alias: "m_mad", Issue: aliasIssueDuplicated, Shards: []string{"shard-1", "shard-2"}},
+			{Alias: "m_mad", Issue: aliasIssueConflict, Shards: []string{"shard-1", "shard-2"}},
+			{Alias: "m_zscore", Issue: aliasIssueDuplicated, Shards: []string{"shard-0", "shard-1"}},
+		}
+		if len(resp.Issues) != len(want) {
+			t.Fatalf("expected issues %+v, got %+v", want, resp.Issues)
+		}
+		for i := range want {
+			if resp.Issues[i].Alias != want[i].Alias || resp.Issues[i].Issue != want[i].Issue ||
+				strings.Join(resp.Issues[i].Shards, ",") != strings.Join(want[i].Shards, ",") {
+				t.Errorf("issue %d: expected %+v, got %+v", i, want[i], resp.Issues[i])
+			}
+		}
+		if !strings.Contains(resp.Summary, "3 of 4 shards") {
+			t.Errorf("unexpected summary: %s", resp.Summary)
+		}
+	})
+
+	t.Run("replication factor 2", func(t *testing.T) {
+		args := map[string]any{"replication_factor": 2, "instances": []any{"shard-0", "shard-1"}}
+		resp := structuredResult[AggregateServerModelsResponse](t, callShardTool(t, instances, "vmanomaly_aggregate_server_models", args))
+
+		if len(resp.Errors) != 0 || len(resp.Issues) != 2 {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+		for _, issue := range resp.Issues {
+			if issue.Issue != aliasIssueMissing || issue.Alias == "m_zscore" {
+				t.Errorf("unexpected issue: %+v", issue)
+			}
+		}
+	})
+
+	t.Run("duplicate instances", func(t *testing.T) {
+		args := map[string]any{"instances": []any{"shard-0", "shard-0", "shard-1", "shard-0"}}
+		resp := structuredResult[AggregateServerModelsResponse](t, callShardTool(t, instances, "vmanomaly_aggregate_server_models", args))
+
+		if strings.Join(resp.Shards, ",") != "shard-0,shard-1" {
+			t.Fatalf("expected every shard queried once, got %v", resp.Shards)
+		}
+		if len(resp.Issues) != 1 || resp.Issues[0].Alias != "m_zscore" {
+			t.Errorf("expected only m_zscore duplicated across shard-0 and shard-1, got %+v", resp.Issues)
+		}
+	})
+
+	t.Run("unknown instance", func(t *testing.T) {
+		result := callShardTool(t, instances, "vmanomaly_aggregate_server_models", map[string]any{"instances": []any{"shard-9"}})
+		if !result.IsError || !strings.Contains(resultText(result), `unknown vmanomaly instance "shard-9"`) {
+			t.Errorf("unexpected result: %s", resultText(result))
+		}
+	})
+
+	t.Run("all shards failed", func(t *testing.T) {
+		result := callShardTool(t, instances, "vmanomaly_aggregate_server_models", map[string]any{"instances": []any{"shard-3"}})
+		if !result.IsError || !strings.Contains(resultText(result), "shard-3 (connection refused)") {
+			t.Errorf("unexpected result: %s", resultText(result))
+		}
+	})
+}
+
+func TestAggregateServerQueries(t *testing.T) {
+	shardQueries := func(queries vmanomaly.ServerQueriesResponse) *MockClient {
+		return &MockClient{
+			GetServerQueriesFunc: func(ctx context.Context) (vmanomaly.ServerQueriesResponse, error) {
+				return queries, nil
+			},
+		}
+	}
+	instances := newShards(t, map[string]*MockClient{
+		"a": shardQueries(vmanomaly.ServerQueriesResponse{"cpu": "rate(cpu[5m])", "disk": "disk_used"}),
+		"b": shardQueries(vmanomaly.ServerQueriesResponse{"cpu": "rate(cpu[1m])", "memory": "mem_used"}),
+	}, "a", "b")
+
+	resp := structuredResult[AggregateServerQueriesResponse](t, callShardTool(t, instances, "vmanomaly_aggregate_server_queries", nil))
+
+	if len(resp.Queries) != 3 || resp.Queries[0].Alias != "cpu" || len(resp.Queries[0].Exprs) != 2 {
+		t.Fatalf("unexpected queries: %+v", resp.Queries)
+	}
+	var kinds []string
+	for _, issue := range resp.Issues {
+		kinds = append(kinds, issue.Alias+":"+issue.Issue)
+	}
+	if got := strings.Join(kinds, ","); got != "cpu:duplicated,cpu:conflict" {
+		t.Errorf("unexpected issues: %s", got)
+	}
+	if !strings.Contains(resp.Summary, "1 duplicated, 1 conflict") {
+		t.Errorf("unexpected summary: %s", resp.Summary)
+	}
+}
+
+func TestAggregateMetrics(t *testing.T) {
+	shardMetrics := func(text string) *MockClient {
+		return &MockClient{
+			MetricsFunc: func(ctx context.Context, config map[string]any) (string, error) {
+				return text, nil
+			},
+		}
+	}
+	instances := newShards(t, map[string]*MockClient{
+		"a": shardMetrics(`# HELP vmanomaly_reader_responses_total Reader responses
+# TYPE vmanomaly_reader_responses_total counter
+vmanomaly_reader_responses_total{query_key="cpu",code="200"} 10
+vmanomaly_reader_responses_total{query_key="a}b \"x\"",code="500"} 2
+vmanomaly_model_runs_total 4 1700000000000
+vmanomaly_model_run_duration_seconds_bucket{le="+Inf"} 4
+vmanomaly_model_run_duration_seconds_sum NaN
+process_start_time_seconds 1700000000
+vmanomaly_build_info{version="v1.28.0"} 1
+`),
+		"b": shardMetrics("vmanomaly_reader_responses_total{query_key=\"cpu\",code=\"200\"} 5\n"),
+		"c": {MetricsFunc: func(ctx context.Context, config map[string]any) (string, error) {
+			return "", errors.New("timeout")
+		}},
+	}, "a", "b", "c")
+
+	resp := structuredResult[AggregateMetricsResponse](t, callShardTool(t, instances, "vmanomaly_aggregate_metrics", nil))
+
+	if len(resp.Shards) != 2 || len(resp.Errors) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	a := resp.Shards[0]
+	if a.Series != 5 || a.Metrics["vmanomaly_reader_responses_total"] != 12 || a.Metrics["vmanomaly_model_runs_total"] != 4 {
+		t.Errorf("unexpected shard metrics: %+v", a)
+	}
+	if _, ok := a.Metrics["vmanomaly_model_run_duration_seconds_bucket"]; ok {
+		t.Errorf("buckets should be skipped: %+v", a)
+	}
+	if resp.Totals["vmanomaly_reader_responses_total"] != 17 {
+		t.Errorf("unexpected totals: %+v", resp.Totals)
+	}
+	// Gauges are reported per shard, but not summed across shards
+	if a.Metrics["process_start_time_seconds"] != 1700000000 || a.Metrics["vmanomaly_build_info"] != 1 {
+		t.Errorf("expected gauges in shard metrics: %+v", a.Metrics)
+	}
+	for _, name := range []string{"process_start_time_seconds", "vmanomaly_build_info"} {
+		if _, ok := resp.Totals[name]; ok {
+			t.Errorf("expected no total of gauge %s: %+v", name, resp.Totals)
+		}
+	}
+
+	resp = structuredResult[AggregateMetricsResponse](t, callShardTool(t, instances, "vmanomaly_aggregate_metrics", map[string]any{"match": "^vmanomaly_model_"}))
+	if len(resp.Totals) != 1 || resp.Totals["vmanomaly_model_runs_total"] != 4 {
+		t.Errorf("unexpected filtered totals: %+v", resp.Totals)
+	}
+
+	result := callShardTool(t, instances, "vmanomaly_aggregate_metrics", map[string]any{"match": "("})
+	if !result.IsError || !strings.Contains(resultText(result), "invalid match") {
+		t.Errorf("unexpected result: %s", resultText(result))
+	}
+}
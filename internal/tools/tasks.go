@@ -0,0 +1,658 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultTaskFitWindow        = "1d"
+	defaultTaskFitEvery         = "1d"
+	defaultTaskAnomalyThreshold = 1.0
+	defaultTaskDatasourceType   = "vm"
+	defaultTaskListLimit        = 20
+
+	defaultRunPollInterval = 2 * time.Second
+	minRunPollInterval     = 500 * time.Millisecond
+	defaultRunTimeout      = 30 * time.Minute
+	maxRunStatusFailures   = 3
+	cancelTaskTimeout      = 10 * time.Second
+)
+
+// ============================================================================
+// Anomaly Detection Task Tool Arguments (Struct-based schemas)
+// ============================================================================
+
+// CreateDetectionTaskArgs defines arguments for create_detection_task tool
+type CreateDetectionTaskArgs struct {
+	Query            string         `json:"query" jsonschema:"required,description=MetricsQL or LogsQL query to run anomaly detection on"`
+	Step             string         `json:"step" jsonschema:"required,description=Query step/resolution (e.g. '1m' '5m' '1h')"`
+	ModelSpec        map[string]any `json:"model_spec,omitempty" jsonschema_description:"Model specification as JSON object (must include 'class' field). Validate it with vmanomaly_validate_model_config first. If omitted, the server default model is used."`
+	StartInfer       string         `json:"start_infer,omitempty" jsonschema_description:"Inference start time as RFC3339 timestamp (e.g. '2025-01-01T00:00:00Z') or Unix seconds. Defaults to server-side behaviour."`
+	EndInfer         string         `json:"end_infer,omitempty" jsonschema_description:"Inference end time as RFC3339 timestamp or Unix seconds. Defaults to server-side behaviour."`
+	FitWindow        string         `json:"fit_window,omitempty" jsonschema:"description=Time window for model fitting (default: '1d')"`
+	FitEvery         string         `json:"fit_every,omitempty" jsonschema:"description=Model retraining frequency (default: '1d')"`
+	InferEvery       string         `json:"infer_every,omitempty" jsonschema:"description=Optional inference cadence for exact-mode batches"`
+	Exact            bool           `json:"exact,omitempty" jsonschema:"description=Enable exact-mode inference for online models"`
+	AnomalyThreshold float64        `json:"anomaly_threshold,omitempty" jsonschema:"description=Anomaly score threshold (default: 1.0)"`
+	DatasourceURL    string         `json:"datasource_url,omitempty" jsonschema_description:"Datasource URL (e.g. 'http://victoriametrics:8428'). Defaults to the datasource configured on the vmanomaly server."`
+	DatasourceType   string         `json:"datasource_type,omitempty" jsonschema:"enum=vm,enum=vmlogs,description=Datasource type: 'vm' for VictoriaMetrics or 'vmlogs' for VictoriaLogs (default: 'vm')"`
+	TenantID         string         `json:"tenant_id,omitempty" jsonschema:"description=Optional tenant ID for multi-tenancy support"`
+}
+
+// GetTaskStatusArgs defines arguments for get_task_status tool
+type GetTaskStatusArgs struct {
+	TaskID           string  `json:"task_id" jsonschema:"required,description=Task identifier returned by vmanomaly_create_detection_task"`
+	AnomalyThreshold float64 `json:"anomaly_threshold,omitempty" jsonschema:"description=Anomaly score threshold used to summarize the result (default: 1.0)"`
+	TopN             int     `json:"top_n,omitempty" jsonschema:"description=Number of most anomalous series to include in the result summary (default: 10)"`
+	IncludeRaw       bool    `json:"include_raw,omitempty" jsonschema_description:"Include the raw task result payload. It can be very large, so only request it when the summary is not enough."`
+}
+
+// ListTasksArgs defines arguments for list_tasks tool
+type ListTasksArgs struct {
+	Limit  int    `json:"limit,omitempty" jsonschema:"description=Maximum number of tasks to return (default: 20)"`
+	Status string `json:"status,omitempty" jsonschema:"enum=running,enum=done,enum=error,enum=canceled,description=Optional task status filter"`
+}
+
+// CancelTaskArgs defines arguments for cancel_task tool
+type CancelTaskArgs struct {
+	TaskID string `json:"task_id" jsonschema:"required,description=Identifier of the running task to cancel"`
+}
+
+// RunDetectionArgs defines arguments for run_detection tool
+type RunDetectionArgs struct {
+	CreateDetectionTaskArgs
+	TopN         int    `json:"top_n,omitempty" jsonschema:"description=Number of most anomalous series to include in the result summary (default: 10)"`
+	IncludeRaw   bool   `json:"include_raw,omitempty" jsonschema_description:"Include the raw task result payload. It can be very large, so only request it when the summary is not enough."`
+	PollInterval string `json:"poll_interval,omitempty" jsonschema:"description=How often to poll task status (default: '2s')"`
+	Timeout      string `json:"timeout,omitempty" jsonschema_description:"Maximum time to wait for the task to finish (default: '30m'). The task keeps running on the server after the timeout and can be polled with vmanomaly_get_task_status."`
+}
+
+// ============================================================================
+// Anomaly Detection Task Tool Responses
+// ============================================================================
+
+// CreateDetectionTaskResponse is the structured output of create_detection_task tool
+type CreateDetectionTaskResponse struct {
+	Summary string `json:"summary" jsonschema_description:"Human-readable summary of the created task and next steps"`
+	TaskID  string `json:"task_id" jsonschema_description:"Unique task identifier"`
+	Status  string `json:"status" jsonschema_description:"Task status: running|done|error|canceled"`
+}
+
+// TaskStatusResponse is the structured output of get_task_status tool
+type TaskStatusResponse struct {
+	Summary   string         `json:"summary" jsonschema_description:"Human-readable summary of the task state"`
+	TaskID    string         `json:"task_id" jsonschema_description:"Unique task identifier"`
+	Status    string         `json:"status" jsonschema_description:"Task status: running|done|error|canceled"`
+	Progress  int            `json:"progress" jsonschema_description:"Progress percentage (0-100)"`
+	Message   string         `json:"message,omitempty" jsonschema_description:"Current status message"`
+	StartedAt *string        `json:"started_at,omitempty" jsonschema_description:"Task start time (ISO format)"`
+	UpdatedAt string         `json:"updated_at,omitempty" jsonschema_description:"Last update time (ISO format)"`
+	Metrics   map[string]any `json:"metrics,omitempty" jsonschema_description:"Task metrics and counters"`
+	Error     *string        `json:"error,omitempty" jsonschema_description:"Error message (when failed)"`
+
+	AnomalySummary *vmanomaly.DetectionSummary `json:"anomaly_summary,omitempty" jsonschema_description:"Top anomalous series with anomaly intervals and peak scores (when done)"`
+	ResultStats    map[string]any              `json:"result_stats,omitempty" jsonschema_description:"Task result statistics (when done)"`
+	Result         *vmanomaly.TaskResult       `json:"result,omitempty" jsonschema_description:"Raw task result (only when include_raw is set)"`
+}
+
+// taskResultOptions controls how a finished task result is presented
+type taskResultOptions struct {
+	threshold  float64
+	topN       int
+	includeRaw bool
+}
+
+// ListTasksResponse is the structured output of list_tasks tool
+type ListTasksResponse struct {
+	Summary string         `json:"summary" jsonschema_description:"Human-readable summary of listed tasks"`
+	Tasks   []TaskListItem `json:"tasks" jsonschema_description:"List of anomaly detection tasks"`
+}
+
+// TaskListItem is a single task in list_tasks output
+type TaskListItem struct {
+	TaskID    string         `json:"task_id" jsonschema_description:"Unique task identifier"`
+	Status    string         `json:"status" jsonschema_description:"Task status: running|done|error|canceled"`
+	Progress  int            `json:"progress" jsonschema_description:"Progress percentage (0-100)"`
+	Message   string         `json:"message,omitempty" jsonschema_description:"Current status message"`
+	StartedAt *string        `json:"started_at,omitempty" jsonschema_description:"Task start time (ISO format)"`
+	UpdatedAt string         `json:"updated_at,omitempty" jsonschema_description:"Last update time (ISO format)"`
+	Metrics   map[string]any `json:"metrics,omitempty" jsonschema_description:"Task metrics and counters"`
+}
+
+// CancelTaskResponse is the structured output of cancel_task tool
+type CancelTaskResponse struct {
+	Summary  string `json:"summary" jsonschema_description:"Human-readable summary of the cancellation result"`
+	TaskID   string `json:"task_id" jsonschema_description:"Identifier of the task"`
+	Canceled bool   `json:"canceled" jsonschema_description:"Whether the task was canceled"`
+}
+
+// DetectionLimitsResponse is the structured output of get_detection_limits tool
+type DetectionLimitsResponse struct {
+	Summary       string `json:"summary" jsonschema_description:"Human-readable summary of task capacity"`
+	MaxConcurrent int    `json:"max_concurrent" jsonschema_description:"Maximum concurrent tasks"`
+	Running       int    `json:"running" jsonschema_description:"Currently running tasks"`
+	Available     int    `json:"available" jsonschema_description:"Available task slots"`
+}
+
+// ============================================================================
+// Tool Registration Functions
+// ============================================================================
+
+// RegisterTaskTools registers all anomaly detection task lifecycle tools
+func RegisterTaskTools(s *server.MCPServer, client vmanomaly.API) {
+	createTaskTool := mcp.NewTool(
+		"vmanomaly_create_detection_task",
+		mcp.WithDescription("Create an anomaly detection task (backtest) that fits the given model on historical data and runs inference over the requested time range. Checks available task slots before starting. Returns the task ID: poll it with vmanomaly_get_task_status."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Create Anomaly Detection Task",
+			ReadOnlyHint:    ptr(false),
+			DestructiveHint: ptr(false),
+			IdempotentHint:  ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[CreateDetectionTaskArgs](),
+		mcp.WithOutputSchema[CreateDetectionTaskResponse](),
+	)
+	s.AddTool(withInstance(createTaskTool, mcp.NewStructuredToolHandler(handleCreateDetectionTask(client))))
+
+	runDetectionTool := mcp.NewTool(
+		"vmanomaly_run_detection",
+		mcp.WithDescription("Create an anomaly detection task and wait until it finishes, reporting progress via MCP progress notifications. Returns the final task status with a summary of detected anomalies (top anomalous series and anomaly intervals). Canceling the tool call cancels the task on the server. Prefer this over vmanomaly_create_detection_task when you need the results right away."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Run Anomaly Detection",
+			ReadOnlyHint:    ptr(false),
+			DestructiveHint: ptr(false),
+			IdempotentHint:  ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[RunDetectionArgs](),
+		mcp.WithOutputSchema[TaskStatusResponse](),
+	)
+	s.AddTool(withInstance(runDetectionTool, mcp.NewStructuredToolHandler(handleRunDetection(client))))
+
+	getTaskStatusTool := mcp.NewTool(
+		"vmanomaly_get_task_status",
+		mcp.WithDescription("Get status and progress of an anomaly detection task created with vmanomaly_create_detection_task. For finished tasks returns a result summary: top anomalous series, contiguous anomaly intervals above threshold, their peak scores and durations. Set include_raw to get the full result payload."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Get Anomaly Detection Task Status",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[GetTaskStatusArgs](),
+		mcp.WithOutputSchema[TaskStatusResponse](),
+	)
+	s.AddTool(withInstance(getTaskStatusTool, mcp.NewStructuredToolHandler(handleGetTaskStatus(client))))
+
+	listTasksTool := mcp.NewTool(
+		"vmanomaly_list_tasks",
+		mcp.WithDescription("List anomaly detection tasks known to the vmanomaly server with their status and progress. Optionally filter by status."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "List Anomaly Detection Tasks",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[ListTasksArgs](),
+		mcp.WithOutputSchema[ListTasksResponse](),
+	)
+	s.AddTool(withInstance(listTasksTool, mcp.NewStructuredToolHandler(handleListTasks(client))))
+
+	cancelTaskTool := mcp.NewTool(
+		"vmanomaly_cancel_task",
+		mcp.WithDescription("Cancel a running anomaly detection task. Partial results of the canceled task are discarded."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Cancel Anomaly Detection Task",
+			ReadOnlyHint:    ptr(false),
+			DestructiveHint: ptr(true),
+			IdempotentHint:  ptr(true),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithInputSchema[CancelTaskArgs](),
+		mcp.WithOutputSchema[CancelTaskResponse](),
+	)
+	s.AddTool(withInstance(cancelTaskTool, mcp.NewStructuredToolHandler(handleCancelTask(client))))
+
+	getLimitsTool := mcp.NewTool(
+		"vmanomaly_get_detection_limits",
+		mcp.WithDescription("Get anomaly detection task capacity of the vmanomaly server: maximum concurrent tasks, running tasks and available slots."),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{
+			Title:           "Get Anomaly Detection Limits",
+			ReadOnlyHint:    ptr(true),
+			DestructiveHint: ptr(false),
+			OpenWorldHint:   ptr(false),
+		}),
+		mcp.WithOutputSchema[DetectionLimitsResponse](),
+	)
+	s.AddTool(withInstance(getLimitsTool, mcp.NewStructuredToolHandler(handleGetDetectionLimits(client))))
+}
+
+// ============================================================================
+// Tool Handlers
+// ============================================================================
+
+func handleCreateDetectionTask(client vmanomaly.API) mcp.StructuredToolHandlerFunc[CreateDetectionTaskArgs, CreateDetectionTaskResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args CreateDetectionTaskArgs) (CreateDetectionTaskResponse, error) {
+		taskReq, err := buildDetectionTaskRequest(args)
+		if err != nil {
+			return CreateDetectionTaskResponse{}, err
+		}
+		taskReq.PassAuthHeaders = passAuthHeaders(ctx)
+
+		if err := checkDetectionSlots(ctx, client); err != nil {
+			return CreateDetectionTaskResponse{}, err
+		}
+
+		task, err := client.CreateDetectionTask(ctx, taskReq)
+		if err != nil {
+			return CreateDetectionTaskResponse{}, fmt.Errorf("failed to create detection task: %w", err)
+		}
+
+		return CreateDetectionTaskResponse{
+			Summary: fmt.Sprintf("Task %s created with status %q. Use vmanomaly_get_task_status to track progress and get results.", task.TaskID, task.Status),
+			TaskID:  task.TaskID,
+			Status:  task.Status,
+		}, nil
+	}
+}
+
+func handleRunDetection(client vmanomaly.API) mcp.StructuredToolHandlerFunc[RunDetectionArgs, TaskStatusResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args RunDetectionArgs) (TaskStatusResponse, error) {
+		pollInterval := defaultRunPollInterval
+		if args.PollInterval != "" {
+			d, err := time.ParseDuration(args.PollInterval)
+			if err != nil {
+				return TaskStatusResponse{}, fmt.Errorf("invalid poll_interval: %w", err)
+			}
+			pollInterval = max(d, minRunPollInterval)
+		}
+
+		timeout := defaultRunTimeout
+		if args.Timeout != "" {
+			d, err := time.ParseDuration(args.Timeout)
+			if err != nil {
+				return TaskStatusResponse{}, fmt.Errorf("invalid timeout: %w", err)
+			}
+			if d <= 0 {
+				return TaskStatusResponse{}, fmt.Errorf("timeout must be positive")
+			}
+			timeout = d
+		}
+
+		taskReq, err := buildDetectionTaskRequest(args.CreateDetectionTaskArgs)
+		if err != nil {
+			return TaskStatusResponse{}, err
+		}
+		taskReq.PassAuthHeaders = passAuthHeaders(ctx)
+
+		if err := checkDetectionSlots(ctx, client); err != nil {
+			return TaskStatusResponse{}, err
+		}
+
+		task, err := client.CreateDetectionTask(ctx, taskReq)
+		if err != nil {
+			return TaskStatusResponse{}, fmt.Errorf("failed to create detection task: %w", err)
+		}
+		notifyProgress(ctx, req, 0, fmt.Sprintf("Task %s created", task.TaskID))
+
+		opts := taskResultOptions{
+			threshold:  taskReq.AnomalyThreshold,
+			topN:       args.TopN,
+			includeRaw: args.IncludeRaw,
+		}
+		return waitForTask(ctx, client, req, task.TaskID, pollInterval, timeout, opts)
+	}
+}
+
+// waitForTask polls the task status until it reaches a final state, the timeout expires
+// or the MCP request is canceled. In the latter case the task is canceled on the server.
+func waitForTask(ctx context.Context, client vmanomaly.API, req mcp.CallToolRequest, taskID string, pollInterval, timeout time.Duration, opts taskResultOptions) (TaskStatusResponse, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	var (
+		last         *vmanomaly.AnomalyDetectionTaskStatus
+		failures     int
+		lastProgress = -1
+		lastMessage  string
+	)
+	for {
+		status, err := client.GetTaskStatus(ctx, taskID)
+		switch {
+		case ctx.Err() != nil:
+			// handled below
+		case err != nil:
+			failures++
+			if failures >= maxRunStatusFailures {
+				return TaskStatusResponse{}, fmt.Errorf("failed to get status of task %s: %w", taskID, err)
+			}
+		default:
+			failures = 0
+			last = status
+			if status.Progress != lastProgress || status.Message != lastMessage {
+				notifyProgress(ctx, req, float64(status.Progress), status.Message)
+				lastProgress, lastMessage = status.Progress, status.Message
+			}
+			if isTaskFinished(status.Status) {
+				return newTaskStatusResponse(status, opts), nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelTaskTimeout)
+			defer cancel()
+			if _, err := client.CancelTask(cancelCtx, taskID); err != nil {
+				slog.Warn("Failed to cancel detection task after request cancellation", "task_id", taskID, "error", err)
+			}
+			return TaskStatusResponse{}, fmt.Errorf("task %s canceled: %w", taskID, ctx.Err())
+		case <-deadline.C:
+			if last == nil {
+				return TaskStatusResponse{}, fmt.Errorf("timed out after %s waiting for task %s", timeout, taskID)
+			}
+			resp := newTaskStatusResponse(last, opts)
+			resp.Summary = fmt.Sprintf("Timed out after %s waiting for task %s, it is still %s (%d%%). Keep polling it with vmanomaly_get_task_status.", timeout, taskID, last.Status, last.Progress)
+			return resp, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func handleGetTaskStatus(client vmanomaly.API) mcp.StructuredToolHandlerFunc[GetTaskStatusArgs, TaskStatusResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args GetTaskStatusArgs) (TaskStatusResponse, error) {
+		if args.TaskID == "" {
+			return TaskStatusResponse{}, fmt.Errorf("task_id is required")
+		}
+
+		status, err := client.GetTaskStatus(ctx, args.TaskID)
+		if err != nil {
+			return TaskStatusResponse{}, fmt.Errorf("failed to get task status: %w", err)
+		}
+
+		threshold := args.AnomalyThreshold
+		if threshold <= 0 {
+			threshold = defaultTaskAnomalyThreshold
+		}
+
+		return newTaskStatusResponse(status, taskResultOptions{
+			threshold:  threshold,
+			topN:       args.TopN,
+			includeRaw: args.IncludeRaw,
+		}), nil
+	}
+}
+
+func handleListTasks(client vmanomaly.API) mcp.StructuredToolHandlerFunc[ListTasksArgs, ListTasksResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args ListTasksArgs) (ListTasksResponse, error) {
+		limit := args.Limit
+		if limit < 1 {
+			limit = defaultTaskListLimit
+		}
+
+		var status *string
+		if args.Status != "" {
+			status = &args.Status
+		}
+
+		result, err := client.ListTasks(ctx, limit, status)
+		if err != nil {
+			return ListTasksResponse{}, fmt.Errorf("failed to list tasks: %w", err)
+		}
+
+		tasks := make([]TaskListItem, 0, len(result.Tasks))
+		for _, task := range result.Tasks {
+			tasks = append(tasks, TaskListItem(task))
+		}
+
+		return ListTasksResponse{
+			Summary: buildTaskListSummary(tasks),
+			Tasks:   tasks,
+		}, nil
+	}
+}
+
+func handleCancelTask(client vmanomaly.API) mcp.StructuredToolHandlerFunc[CancelTaskArgs, CancelTaskResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args CancelTaskArgs) (CancelTaskResponse, error) {
+		if args.TaskID == "" {
+			return CancelTaskResponse{}, fmt.Errorf("task_id is required")
+		}
+
+		result, err := client.CancelTask(ctx, args.TaskID)
+		if err != nil {
+			return CancelTaskResponse{}, fmt.Errorf("failed to cancel task: %w", err)
+		}
+
+		resp := CancelTaskResponse{
+			TaskID:   args.TaskID,
+			Canceled: result["canceled"],
+		}
+		if resp.Canceled {
+			resp.Summary = fmt.Sprintf("Task %s has been canceled.", args.TaskID)
+		} else {
+			resp.Summary = fmt.Sprintf("Task %s was not canceled: it may have already finished. Check it with vmanomaly_get_task_status.", args.TaskID)
+		}
+
+		return resp, nil
+	}
+}
+
+func handleGetDetectionLimits(client vmanomaly.API) mcp.StructuredToolHandlerFunc[struct{}, DetectionLimitsResponse] {
+	return func(ctx context.Context, req mcp.CallToolRequest, args struct{}) (DetectionLimitsResponse, error) {
+		limits, err := client.GetDetectionLimits(ctx)
+		if err != nil {
+			return DetectionLimitsResponse{}, fmt.Errorf("failed to get detection limits: %w", err)
+		}
+
+		return DetectionLimitsResponse{
+			Summary:       fmt.Sprintf("%d of %d task slots are in use, %d available.", limits.Running, limits.MaxConcurrent, limits.Available),
+			MaxConcurrent: limits.MaxConcurrent,
+			Running:       limits.Running,
+			Available:     limits.Available,
+		}, nil
+	}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// buildDetectionTaskRequest converts tool arguments into an API request with defaults applied
+func buildDetectionTaskRequest(args CreateDetectionTaskArgs) (*vmanomaly.AnomalyDetectionTaskRequest, error) {
+	if args.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if args.Step == "" {
+		return nil, fmt.Errorf("step is required")
+	}
+
+	taskReq := &vmanomaly.AnomalyDetectionTaskRequest{
+		Query:            args.Query,
+		Step:             args.Step,
+		FitWindow:        args.FitWindow,
+		FitEvery:         args.FitEvery,
+		Exact:            args.Exact,
+		AnomalyThreshold: args.AnomalyThreshold,
+		ModelSpec:        args.ModelSpec,
+		DatasourceType:   args.DatasourceType,
+	}
+
+	if taskReq.FitWindow == "" {
+		taskReq.FitWindow = defaultTaskFitWindow
+	}
+	if taskReq.FitEvery == "" {
+		taskReq.FitEvery = defaultTaskFitEvery
+	}
+	if taskReq.AnomalyThreshold <= 0 {
+		taskReq.AnomalyThreshold = defaultTaskAnomalyThreshold
+	}
+	if taskReq.DatasourceType == "" {
+		taskReq.DatasourceType = defaultTaskDatasourceType
+	}
+	if args.InferEvery != "" {
+		taskReq.InferEvery = &args.InferEvery
+	}
+	if args.DatasourceURL != "" {
+		taskReq.DatasourceURL = &args.DatasourceURL
+	}
+	if args.TenantID != "" {
+		taskReq.TenantID = &args.TenantID
+	}
+
+	if args.StartInfer != "" {
+		ts, err := parseTimestamp(args.StartInfer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_infer: %w", err)
+		}
+		taskReq.StartInferS = &ts
+	}
+	if args.EndInfer != "" {
+		ts, err := parseTimestamp(args.EndInfer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_infer: %w", err)
+		}
+		taskReq.EndInferS = &ts
+	}
+	if taskReq.StartInferS != nil && taskReq.EndInferS != nil && *taskReq.EndInferS <= *taskReq.StartInferS {
+		return nil, fmt.Errorf("end_infer must be after start_infer")
+	}
+
+	return taskReq, nil
+}
+
+// checkDetectionSlots returns an error if the server has no free task slots
+func checkDetectionSlots(ctx context.Context, client vmanomaly.API) error {
+	limits, err := client.GetDetectionLimits(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check detection limits: %w", err)
+	}
+	if limits.Available <= 0 {
+		return fmt.Errorf("no anomaly detection task slots available (%d of %d running); wait for a running task to finish or cancel one with vmanomaly_cancel_task", limits.Running, limits.MaxConcurrent)
+	}
+	return nil
+}
+
+func isTaskFinished(status string) bool {
+	return status == "done" || status == "error" || status == "canceled"
+}
+
+func newTaskStatusResponse(status *vmanomaly.AnomalyDetectionTaskStatus, opts taskResultOptions) TaskStatusResponse {
+	resp := TaskStatusResponse{
+		TaskID:    status.TaskID,
+		Status:    status.Status,
+		Progress:  status.Progress,
+		Message:   status.Message,
+		StartedAt: status.StartedAt,
+		UpdatedAt: status.UpdatedAt,
+		Metrics:   status.Metrics,
+		Error:     status.Error,
+	}
+	resp.Summary = buildTaskStatusSummary(resp)
+
+	if status.ResultData == nil {
+		return resp
+	}
+	resp.ResultStats = status.ResultData.Stats
+	if opts.includeRaw {
+		resp.Result = status.ResultData
+	}
+	if status.ResultData.Error != nil {
+		resp.Summary += fmt.Sprintf(" Result error: %s", *status.ResultData.Error)
+		return resp
+	}
+
+	series, err := vmanomaly.ParseDetectionResult(status.ResultData)
+	if err != nil {
+		resp.Summary += fmt.Sprintf(" Failed to summarize result: %s. Request it with include_raw to inspect the payload.", err)
+		return resp
+	}
+	summary := vmanomaly.SummarizeDetection(series, opts.threshold, opts.topN)
+	resp.AnomalySummary = &summary
+	resp.Summary += " " + buildAnomalySummaryText(summary)
+
+	return resp
+}
+
+func buildTaskStatusSummary(r TaskStatusResponse) string {
+	var sb strings.Builder
+
+	switch r.Status {
+	case "running":
+		sb.WriteString(fmt.Sprintf("Task %s is running (%d%%).", r.TaskID, r.Progress))
+		if r.Message != "" {
+			sb.WriteString(fmt.Sprintf(" %s.", strings.TrimSuffix(r.Message, ".")))
+		}
+		sb.WriteString(" Check again later for results.")
+	case "done":
+		sb.WriteString(fmt.Sprintf("Task %s is done.", r.TaskID))
+	case "error":
+		sb.WriteString(fmt.Sprintf("Task %s failed.", r.TaskID))
+		if r.Error != nil {
+			sb.WriteString(fmt.Sprintf(" Error: %s", *r.Error))
+		}
+	case "canceled":
+		sb.WriteString(fmt.Sprintf("Task %s was canceled.", r.TaskID))
+	default:
+		sb.WriteString(fmt.Sprintf("Task %s has status %q (%d%%).", r.TaskID, r.Status, r.Progress))
+	}
+
+	return sb.String()
+}
+
+func buildAnomalySummaryText(s vmanomaly.DetectionSummary) string {
+	if s.AnomalousSeries == 0 {
+		return fmt.Sprintf("No anomalies above threshold %g found in %d series.", s.Threshold, s.TotalSeries)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d anomaly intervals in %d of %d series above threshold %g.",
+		s.TotalIntervals, s.AnomalousSeries, s.TotalSeries, s.Threshold))
+
+	top := s.TopSeries[0]
+	sb.WriteString(fmt.Sprintf(" Most anomalous series %s peaked at %.2f", vmanomaly.SeriesKey(top.Labels), top.PeakScore))
+	if len(top.Intervals) > 0 {
+		longest := top.Intervals[0]
+		for _, interval := range top.Intervals[1:] {
+			if interval.DurationSeconds > longest.DurationSeconds {
+				longest = interval
+			}
+		}
+		sb.WriteString(fmt.Sprintf(" with the longest anomaly of %s starting at %s",
+			time.Duration(longest.DurationSeconds*float64(time.Second)), longest.Start.Format(time.RFC3339)))
+	}
+	sb.WriteString(".")
+
+	return sb.String()
+}
+
+func buildTaskListSummary(tasks []TaskListItem) string {
+	if len(tasks) == 0 {
+		return "No anomaly detection tasks found."
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, task := range tasks {
+		if counts[task.Status] == 0 {
+			order = append(order, task.Status)
+		}
+		counts[task.Status]++
+	}
+
+	parts := make([]string, 0, len(order))
+	for _, status := range order {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+	}
+
+	return fmt.Sprintf("Found %d tasks: %s.", len(tasks), strings.Join(parts, ", "))
+}
@@ -0,0 +1,307 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestBuildDetectionTaskRequest_Defaults(t *testing.T) {
+	taskReq, err := buildDetectionTaskRequest(CreateDetectionTaskArgs{
+		Query: "up",
+		Step:  "1m",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if taskReq.FitWindow != "1d" {
+		t.Errorf("expected default fit_window=1d, got %s", taskReq.FitWindow)
+	}
+	if taskReq.FitEvery != "1d" {
+		t.Errorf("expected default fit_every=1d, got %s", taskReq.FitEvery)
+	}
+	if taskReq.AnomalyThreshold != 1.0 {
+		t.Errorf("expected default anomaly_threshold=1.0, got %g", taskReq.AnomalyThreshold)
+	}
+	if taskReq.DatasourceType != "vm" {
+		t.Errorf("expected default datasource_type=vm, got %s", taskReq.DatasourceType)
+	}
+	if taskReq.StartInferS != nil || taskReq.EndInferS != nil || taskReq.DatasourceURL != nil {
+		t.Error("expected optional fields to be unset")
+	}
+}
+
+func TestBuildDetectionTaskRequest_TimeRange(t *testing.T) {
+	taskReq, err := buildDetectionTaskRequest(CreateDetectionTaskArgs{
+		Query:      "up",
+		Step:       "1m",
+		StartInfer: "2025-01-01T00:00:00Z",
+		EndInfer:   "1735693200",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if taskReq.StartInferS == nil || *taskReq.StartInferS != 1735689600 {
+		t.Errorf("unexpected start_infer_s: %v", taskReq.StartInferS)
+	}
+	if taskReq.EndInferS == nil || *taskReq.EndInferS != 1735693200 {
+		t.Errorf("unexpected end_infer_s: %v", taskReq.EndInferS)
+	}
+}
+
+func TestBuildDetectionTaskRequest_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args CreateDetectionTaskArgs
+	}{
+		{name: "missing query", args: CreateDetectionTaskArgs{Step: "1m"}},
+		{name: "missing step", args: CreateDetectionTaskArgs{Query: "up"}},
+		{name: "bad start", args: CreateDetectionTaskArgs{Query: "up", Step: "1m", StartInfer: "yesterday"}},
+		{name: "end before start", args: CreateDetectionTaskArgs{Query: "up", Step: "1m", StartInfer: "200", EndInfer: "100"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildDetectionTaskRequest(tt.args); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestBuildTaskListSummary(t *testing.T) {
+	if got := buildTaskListSummary(nil); got != "No anomaly detection tasks found." {
+		t.Errorf("unexpected summary: %s", got)
+	}
+
+	got := buildTaskListSummary([]TaskListItem{
+		{TaskID: "a", Status: "running"},
+		{TaskID: "b", Status: "done"},
+		{TaskID: "c", Status: "running"},
+	})
+	if got != "Found 3 tasks: 2 running, 1 done." {
+		t.Errorf("unexpected summary: %s", got)
+	}
+}
+
+func TestWaitForTask_Done(t *testing.T) {
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) < 3 {
+			_, _ = w.Write([]byte(`{"task_id":"task-1","status":"running","progress":50,"message":"Fitting models"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"task_id":"task-1","status":"done","progress":100,"message":"Complete"}`))
+	}))
+	defer srv.Close()
+
+	client := vmanomaly.NewClient(srv.URL, "", nil)
+	resp, err := waitForTask(context.Background(), client, mcp.CallToolRequest{}, "task-1", time.Millisecond, time.Minute, taskResultOptions{threshold: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Status != "done" || resp.Progress != 100 {
+		t.Errorf("unexpected final status: %s (%d%%)", resp.Status, resp.Progress)
+	}
+	if polls.Load() != 3 {
+		t.Errorf("expected 3 polls, got %d", polls.Load())
+	}
+}
+
+func TestWaitForTask_CancelOnRequestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			canceled <- r.URL.Path
+			_, _ = w.Write([]byte(`{"canceled":true}`))
+			return
+		}
+		cancel()
+		_, _ = w.Write([]byte(`{"task_id":"task-1","status":"running","progress":10}`))
+	}))
+	defer srv.Close()
+
+	client := vmanomaly.NewClient(srv.URL, "", nil)
+	_, err := waitForTask(ctx, client, mcp.CallToolRequest{}, "task-1", time.Hour, time.Hour, taskResultOptions{threshold: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled error, got %v", err)
+	}
+
+	select {
+	case path := <-canceled:
+		if path != "/api/v1/anomaly_detection/tasks/task-1" {
+			t.Errorf("unexpected cancel path: %s", path)
+		}
+	default:
+		t.Error("expected task to be canceled on the server")
+	}
+}
+
+func TestNewTaskStatusResponse_Summary(t *testing.T) {
+	status := &vmanomaly.AnomalyDetectionTaskStatus{
+		TaskID:   "task-1",
+		Status:   "done",
+		Progress: 100,
+		ResultData: &vmanomaly.TaskResult{
+			Status: "success",
+			Data: map[string]any{
+				"resultType": "matrix",
+				"result": []any{
+					map[string]any{
+						"metric": map[string]any{"__name__": "anomaly_score", "instance": "a"},
+						"values": []any{[]any{1700000000, "0.5"}, []any{1700000060, "2.5"}},
+					},
+				},
+			},
+			Stats: map[string]any{"series": 1},
+		},
+	}
+
+	resp := newTaskStatusResponse(status, taskResultOptions{threshold: 1})
+	if resp.AnomalySummary == nil || resp.AnomalySummary.AnomalousSeries != 1 {
+		t.Fatalf("unexpected anomaly summary: %+v", resp.AnomalySummary)
+	}
+	if resp.Result != nil {
+		t.Error("expected raw result to be omitted by default")
+	}
+	if resp.ResultStats["series"] != 1 {
+		t.Errorf("unexpected result stats: %v", resp.ResultStats)
+	}
+
+	resp = newTaskStatusResponse(status, taskResultOptions{threshold: 1, includeRaw: true})
+	if resp.Result == nil {
+		t.Error("expected raw result with include_raw")
+	}
+}
+
+func TestCreateDetectionTask_NoSlots(t *testing.T) {
+	var created bool
+	mock := &MockClient{
+		GetDetectionLimitsFunc: func(ctx context.Context) (*vmanomaly.AnomalyDetectionLimitsResponse, error) {
+			return &vmanomaly.AnomalyDetectionLimitsResponse{MaxConcurrent: 2, Running: 2, Available: 0}, nil
+		},
+		CreateDetectionTaskFunc: func(ctx context.Context, req *vmanomaly.AnomalyDetectionTaskRequest) (*vmanomaly.AnomalyDetectionTaskResponse, error) {
+			created = true
+			return &vmanomaly.AnomalyDetectionTaskResponse{TaskID: "task-1", Status: "running"}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_create_detection_task", map[string]any{"query": "up", "step": "1m"})
+
+	if !result.IsError {
+		t.Fatal("expected error result")
+	}
+	if created {
+		t.Error("task must not be created when no slots are available")
+	}
+	if !strings.Contains(resultText(result), "2 of 2 running") {
+		t.Errorf("unexpected error message: %q", resultText(result))
+	}
+}
+
+func TestCreateDetectionTask(t *testing.T) {
+	var receivedReq *vmanomaly.AnomalyDetectionTaskRequest
+	mock := &MockClient{
+		GetDetectionLimitsFunc: func(ctx context.Context) (*vmanomaly.AnomalyDetectionLimitsResponse, error) {
+			return &vmanomaly.AnomalyDetectionLimitsResponse{MaxConcurrent: 2, Running: 1, Available: 1}, nil
+		},
+		CreateDetectionTaskFunc: func(ctx context.Context, req *vmanomaly.AnomalyDetectionTaskRequest) (*vmanomaly.AnomalyDetectionTaskResponse, error) {
+			receivedReq = req
+			return &vmanomaly.AnomalyDetectionTaskResponse{TaskID: "task-1", Status: "running"}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_create_detection_task", map[string]any{
+		"query":      "up",
+		"step":       "1m",
+		"model_spec": map[string]any{"class": "zscore"},
+	})
+	resp := structuredResult[CreateDetectionTaskResponse](t, result)
+
+	if resp.TaskID != "task-1" || resp.Status != "running" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if receivedReq.ModelSpec["class"] != "zscore" {
+		t.Errorf("expected model_spec to be passed through, got %v", receivedReq.ModelSpec)
+	}
+}
+
+func TestListTasks_Handler(t *testing.T) {
+	var (
+		receivedLimit  int
+		receivedStatus *string
+	)
+	mock := &MockClient{
+		ListTasksFunc: func(ctx context.Context, limit int, status *string) (*vmanomaly.AnomalyDetectionTaskListResponse, error) {
+			receivedLimit, receivedStatus = limit, status
+			return &vmanomaly.AnomalyDetectionTaskListResponse{Tasks: []vmanomaly.AnomalyDetectionTaskListItem{
+				{TaskID: "task-1", Status: "running", Progress: 40},
+			}}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_list_tasks", map[string]any{"status": "running"})
+	resp := structuredResult[ListTasksResponse](t, result)
+
+	if receivedLimit != defaultTaskListLimit {
+		t.Errorf("expected default limit=%d, got %d", defaultTaskListLimit, receivedLimit)
+	}
+	if receivedStatus == nil || *receivedStatus != "running" {
+		t.Errorf("expected status=running, got %v", receivedStatus)
+	}
+	if len(resp.Tasks) != 1 || resp.Tasks[0].TaskID != "task-1" {
+		t.Errorf("unexpected tasks: %+v", resp.Tasks)
+	}
+}
+
+func TestCancelTask_Handler(t *testing.T) {
+	mock := &MockClient{
+		CancelTaskFunc: func(ctx context.Context, taskID string) (map[string]bool, error) {
+			return map[string]bool{"canceled": taskID == "task-1"}, nil
+		},
+	}
+
+	resp := structuredResult[CancelTaskResponse](t, callTool(t, mock, "vmanomaly_cancel_task", map[string]any{"task_id": "task-1"}))
+	if !resp.Canceled {
+		t.Errorf("expected task to be canceled: %+v", resp)
+	}
+
+	resp = structuredResult[CancelTaskResponse](t, callTool(t, mock, "vmanomaly_cancel_task", map[string]any{"task_id": "task-2"}))
+	if resp.Canceled {
+		t.Errorf("expected task not to be canceled: %+v", resp)
+	}
+
+	if result := callTool(t, mock, "vmanomaly_cancel_task", map[string]any{}); !result.IsError {
+		t.Error("expected error result for missing task_id")
+	}
+}
+
+func TestGetDetectionLimits_Handler(t *testing.T) {
+	mock := &MockClient{
+		GetDetectionLimitsFunc: func(ctx context.Context) (*vmanomaly.AnomalyDetectionLimitsResponse, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_get_detection_limits", nil)
+
+	if !result.IsError {
+		t.Error("expected error result")
+	}
+	if !strings.Contains(resultText(result), "connection refused") {
+		t.Errorf("expected API error in result, got %q", resultText(result))
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
 
@@ -11,7 +13,7 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func RegisterTools(s *server.MCPServer, client *vmanomaly.Client) {
+func RegisterTools(s *server.MCPServer, client vmanomaly.API) {
 	healthTool := mcp.NewTool("vmanomaly_health_check",
 		mcp.WithDescription("Check the health status of the vmanomaly server"),
 		mcp.WithToolAnnotation(mcp.ToolAnnotation{
@@ -21,17 +23,36 @@ func RegisterTools(s *server.MCPServer, client *vmanomaly.Client) {
 			OpenWorldHint:   ptr(false),
 		}),
 	)
-	s.AddTool(healthTool, handleHealthCheck(client))
+	s.AddTool(withInstance(healthTool, handleHealthCheck(client)))
 
 	RegisterModelTools(s, client)
 	RegisterConfigTools(s, client)
 	RegisterInfoTools(s, client)
 	RegisterCompatibilityTools(s, client)
 	RegisterAlertTools(s, client)
+	RegisterTaskTools(s, client)
+	RegisterQueryTools(s, client)
 	RegisterDocsTool(s)
 }
 
-func handleHealthCheck(client *vmanomaly.Client) server.ToolHandlerFunc {
+// CheckAnnotations returns an error if any tool registered in s doesn't declare its title and read-only and destructive hints.
+// Tools created without mcp.WithToolAnnotation get permissive defaults, which would let them bypass read-only mode and roles.
+func CheckAnnotations(s *server.MCPServer) error {
+	var missing []string
+	for name, tool := range s.ListTools() {
+		a := tool.Tool.Annotations
+		if a.Title == "" || a.ReadOnlyHint == nil || a.DestructiveHint == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("tools must declare title, readOnlyHint and destructiveHint annotations: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func handleHealthCheck(client vmanomaly.API) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		health, err := client.GetHealth(ctx)
 		if err != nil {
@@ -3,7 +3,13 @@ package tools
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 )
 
 func TestHealthCheck_Error(t *testing.T) {
@@ -13,9 +19,51 @@ func TestHealthCheck_Error(t *testing.T) {
 		},
 	}
 
-	_, err := mock.GetHealth(context.Background())
+	result := callTool(t, mock, "vmanomaly_health_check", nil)
+
+	if !result.IsError {
+		t.Error("expected error result")
+	}
+	if !strings.Contains(resultText(result), "connection refused") {
+		t.Errorf("expected API error in result, got %q", resultText(result))
+	}
+}
+
+func TestHealthCheck_Success(t *testing.T) {
+	mock := &MockClient{
+		GetHealthFunc: func(ctx context.Context) (map[string]any, error) {
+			return map[string]any{"status": "ok"}, nil
+		},
+	}
+
+	result := callTool(t, mock, "vmanomaly_health_check", nil)
+
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", resultText(result))
+	}
+	if !strings.Contains(resultText(result), `"status": "ok"`) {
+		t.Errorf("unexpected result: %q", resultText(result))
+	}
+}
+
+func TestCheckAnnotations(t *testing.T) {
+	instances, err := vmanomaly.NewInstances([]vmanomaly.Instance{{Name: "default", Client: &MockClient{}}}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := server.NewMCPServer("test", "0.0.0", server.WithToolCapabilities(true))
+	RegisterTools(s, instances)
+	RegisterInstanceTools(s, instances)
+
+	if err := CheckAnnotations(s); err != nil {
+		t.Fatalf("all tools must be annotated: %v", err)
+	}
 
-	if err == nil {
-		t.Error("expected error from API")
+	s.AddTool(mcp.NewTool("vmanomaly_unannotated"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	err = CheckAnnotations(s)
+	if err == nil || !strings.Contains(err.Error(), "vmanomaly_unannotated") {
+		t.Fatalf("expected error naming the unannotated tool, got %v", err)
 	}
 }
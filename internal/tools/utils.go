@@ -1,5 +1,126 @@
 package tools
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
 func ptr[T any](v T) *T {
 	return &v
 }
+
+// parseTimestamp parses an RFC3339 timestamp or Unix seconds into Unix seconds
+func parseTimestamp(s string) (float64, error) {
+	if ts, err := strconv.ParseFloat(s, 64); err == nil {
+		return ts, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("expected RFC3339 timestamp or Unix seconds, got %q", s)
+	}
+	return float64(t.UnixNano()) / 1e9, nil
+}
+
+// notifyProgress sends an MCP progress notification (percent of 100) if the client asked for one
+func notifyProgress(ctx context.Context, req mcp.CallToolRequest, progress float64, message string) {
+	if req.Params.Meta == nil || req.Params.Meta.ProgressToken == nil {
+		return
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return
+	}
+
+	params := map[string]any{
+		"progressToken": req.Params.Meta.ProgressToken,
+		"progress":      progress,
+		"total":         100,
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	if err := srv.SendNotificationToClient(ctx, "notifications/progress", params); err != nil {
+		slog.Debug("Failed to send progress notification", "error", err)
+	}
+}
+
+// passAuthHeaders reports whether vmanomaly should forward the caller's credentials to the datasource.
+// It is the case when the caller's Authorization header is forwarded to vmanomaly.
+func passAuthHeaders(ctx context.Context) bool {
+	return vmanomaly.AuthorizationFromContext(ctx) != ""
+}
+
+// formatValidationError renders validation errors returned by vmanomaly field by field,
+// so the assistant can fix every invalid value at once. Other errors are rendered as is.
+func formatValidationError(err error) string {
+	var apiErr *vmanomaly.APIError
+	if !errors.As(err, &apiErr) || len(apiErr.ValidationErrors) == 0 {
+		return err.Error()
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "vmanomaly rejected %d invalid field(s) (status %d):\n", len(apiErr.ValidationErrors), apiErr.StatusCode)
+	for _, ve := range apiErr.ValidationErrors {
+		fmt.Fprintf(&sb, "- %s: %s", ve.Field(), ve.Msg)
+		if ve.Type != "" {
+			fmt.Fprintf(&sb, " (%s)", ve.Type)
+		}
+		sb.WriteByte('\n')
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// withArgument adds the optional argument with the given JSON schema to the tool input schema
+func withArgument(tool mcp.Tool, name string, argSchema map[string]any) mcp.Tool {
+	if tool.RawInputSchema != nil {
+		var schema map[string]any
+		if err := json.Unmarshal(tool.RawInputSchema, &schema); err == nil {
+			properties, _ := schema["properties"].(map[string]any)
+			if properties == nil {
+				properties = make(map[string]any)
+			}
+			properties[name] = argSchema
+			schema["properties"] = properties
+			if raw, err := json.Marshal(schema); err == nil {
+				tool.RawInputSchema = raw
+			}
+		}
+	} else {
+		if tool.InputSchema.Properties == nil {
+			tool.InputSchema.Properties = make(map[string]any)
+		}
+		tool.InputSchema.Properties[name] = argSchema
+	}
+	return tool
+}
+
+// noCacheArg is the optional argument of tools serving cached vmanomaly metadata, bypassing the cache
+const noCacheArg = "nocache"
+
+// noCacheArgSchema is the JSON schema of noCacheArg
+var noCacheArgSchema = map[string]any{
+	"type":        "boolean",
+	"description": "Fetch fresh data from vmanomaly instead of the cached response. Cached responses are refreshed automatically when vmanomaly is upgraded, so it's rarely needed.",
+}
+
+// withNoCache adds the optional nocache argument to the tool input schema,
+// and makes the handler bypass the vmanomaly response cache if it is set
+func withNoCache(tool mcp.Tool, handler server.ToolHandlerFunc) (mcp.Tool, server.ToolHandlerFunc) {
+	tool = withArgument(tool, noCacheArg, noCacheArgSchema)
+	return tool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if noCache, _ := req.GetArguments()[noCacheArg].(bool); noCache {
+			ctx = vmanomaly.ContextWithNoCache(ctx)
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,237 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+const (
+	// queueSize limits spans waiting for export, spans are dropped when the queue is full
+	queueSize = 2048
+	// maxBatchSize is the number of spans exported in a single request
+	maxBatchSize = 512
+	// exportInterval is how often queued spans are exported
+	exportInterval = 5 * time.Second
+	// exportTimeout limits a single export request
+	exportTimeout = 10 * time.Second
+)
+
+// exporter sends ended spans to the collector in OTLP/HTTP JSON encoding
+type exporter struct {
+	endpoint   string
+	httpClient *http.Client
+	resource   resource
+	scope      scope
+
+	queue    chan *Span
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	exported     *metrics.Counter
+	dropped      *metrics.Counter
+	exportErrors *metrics.Counter
+}
+
+func newExporter(cfg Config, ms *metrics.Set) *exporter {
+	return &exporter{
+		endpoint:   cfg.Endpoint,
+		httpClient: &http.Client{Timeout: exportTimeout},
+		resource: resource{Attributes: []Attr{
+			String("service.name", cfg.ServiceName),
+			String("service.version", cfg.ServiceVersion),
+		}},
+		scope: scope{Name: cfg.ServiceName, Version: cfg.ServiceVersion},
+
+		queue: make(chan *Span, queueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+
+		exported:     ms.GetOrCreateCounter(`mcp_vmanomaly_tracing_spans_exported_total`),
+		dropped:      ms.GetOrCreateCounter(`mcp_vmanomaly_tracing_spans_dropped_total`),
+		exportErrors: ms.GetOrCreateCounter(`mcp_vmanomaly_tracing_export_errors_total`),
+	}
+}
+
+// enqueue queues s for export without blocking, dropping it if the queue is full
+func (e *exporter) enqueue(s *Span) {
+	select {
+	case e.queue <- s:
+	default:
+		e.dropped.Inc()
+	}
+}
+
+// run exports queued spans every exportInterval or once a batch is full, until shutdown
+func (e *exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, maxBatchSize)
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) < maxBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-e.stop:
+			e.drain(batch)
+			return
+		}
+		e.export(batch)
+		batch = batch[:0]
+	}
+}
+
+// drain exports batch and spans ended before shutdown
+func (e *exporter) drain(batch []*Span) {
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) == maxBatchSize {
+				e.export(batch)
+				batch = batch[:0]
+			}
+		default:
+			e.export(batch)
+			return
+		}
+	}
+}
+
+func (e *exporter) shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() { close(e.stop) })
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to export remaining spans: %w", ctx.Err())
+	}
+}
+
+// export sends spans to the collector. Failed batches are dropped, since retrying would delay newer spans.
+func (e *exporter) export(spans []*Span) {
+	if len(spans) == 0 {
+		return
+	}
+	data, err := json.Marshal(e.request(spans))
+	if err != nil {
+		e.exportFailed(len(spans), fmt.Errorf("failed to encode spans: %w", err))
+		return
+	}
+	resp, err := e.httpClient.Post(e.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		e.exportFailed(len(spans), err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e.exportFailed(len(spans), fmt.Errorf("collector responded with status %d: %s", resp.StatusCode, body))
+		return
+	}
+	e.exported.Add(len(spans))
+}
+
+func (e *exporter) exportFailed(n int, err error) {
+	e.exportErrors.Inc()
+	e.dropped.Add(n)
+	slog.Warn("Failed to export spans", "endpoint", e.endpoint, "spans", n, "error", err)
+}
+
+// request returns the OTLP ExportTraceServiceRequest of spans,
+// see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+func (e *exporter) request(spans []*Span) *exportRequest {
+	encoded := make([]span, 0, len(spans))
+	for _, s := range spans {
+		encoded = append(encoded, s.encode())
+	}
+	return &exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   e.resource,
+		ScopeSpans: []scopeSpans{{Scope: e.scope, Spans: encoded}},
+	}}}
+}
+
+func (s *Span) encode() span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	encoded := span{
+		TraceID:           hexID(s.sc.TraceID[:]),
+		SpanID:            hexID(s.sc.SpanID[:]),
+		Name:              s.name,
+		Kind:              int(s.kind),
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		Attributes:        slices.Clone(s.attrs),
+		Status:            status{Code: statusUnset},
+	}
+	if s.parentID != [8]byte{} {
+		encoded.ParentSpanID = hexID(s.parentID[:])
+	}
+	if s.isError {
+		encoded.Status = status{Code: statusError, Message: s.statusMsg}
+	}
+	return encoded
+}
+
+// OTLP JSON messages. Trace and span IDs are hex-encoded and 64-bit integers are strings.
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []Attr `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type span struct {
+	TraceID           string `json:"traceId"`
+	SpanID            string `json:"spanId"`
+	ParentSpanID      string `json:"parentSpanId,omitempty"`
+	Name              string `json:"name"`
+	Kind              int    `json:"kind"`
+	StartTimeUnixNano string `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string `json:"endTimeUnixNano"`
+	Attributes        []Attr `json:"attributes,omitempty"`
+	Status            status `json:"status"`
+}
+
+type status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type anyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// traceparentHeader is the W3C trace context header, see https://www.w3.org/TR/trace-context/
+const traceparentHeader = "Traceparent"
+
+// Inject sets the traceparent header of the span in ctx, if any
+func Inject(ctx context.Context, h http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	h.Set(traceparentHeader, "00-"+hexID(sc.TraceID[:])+"-"+hexID(sc.SpanID[:])+"-"+flags)
+}
+
+// Extract returns ctx carrying the span context of the traceparent header as remote parent.
+// ctx is returned unchanged if the header is missing or invalid.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	sc, ok := parseTraceparent(h.Get(traceparentHeader))
+	if !ok {
+		return ctx
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Middleware continues traces of callers that send the traceparent header
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(traceparentHeader) != "" {
+			r = r.WithContext(Extract(r.Context(), r.Header))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseTraceparent parses version-format-trace_id-parent_id-trace_flags.
+// Versions other than 00 are parsed as 00 as required by the spec, ignoring additional fields.
+func parseTraceparent(v string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// decodeHex decodes lowercase hex s into dst of exactly len(s)/2 bytes
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
@@ -0,0 +1,252 @@
+// Package tracing records spans of tool calls and upstream requests and exports them
+// to an OpenTelemetry collector over OTLP/HTTP, propagating W3C trace context.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// SpanKind is the OTLP span kind
+type SpanKind int
+
+// Span kinds, values match OTLP
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// Status codes of spans, values match OTLP
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// SpanContext identifies a span within a trace
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether sc has non-zero trace and span IDs
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Attr is a span attribute
+type Attr struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+// String returns a string attribute
+func String(key, value string) Attr {
+	return Attr{Key: key, Value: anyValue{StringValue: &value}}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attr {
+	s := strconv.Itoa(value)
+	return Attr{Key: key, Value: anyValue{IntValue: &s}}
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attr {
+	return Attr{Key: key, Value: anyValue{BoolValue: &value}}
+}
+
+// Config configures a Tracer
+type Config struct {
+	// Endpoint is the OTLP/HTTP traces endpoint, e.g. http://localhost:4318/v1/traces
+	Endpoint string
+	// ServiceName and ServiceVersion are resource attributes of exported spans
+	ServiceName    string
+	ServiceVersion string
+	// SampleRatio is the fraction of traces started by the server that are recorded.
+	// Traces continued from a caller's traceparent follow its sampled flag.
+	SampleRatio float64
+}
+
+// Tracer starts spans and exports them in batches
+type Tracer struct {
+	sampleRatio float64
+	exp         *exporter
+}
+
+// NewTracer returns a tracer exporting spans to cfg.Endpoint. Its metrics are registered in ms.
+// Shutdown must be called to export the remaining spans.
+func NewTracer(cfg Config, ms *metrics.Set) *Tracer {
+	t := &Tracer{sampleRatio: cfg.SampleRatio, exp: newExporter(cfg, ms)}
+	go t.exp.run()
+	return t
+}
+
+// Shutdown exports the remaining spans and stops the exporter, waiting until ctx is done at most
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.exp.shutdown(ctx)
+}
+
+// Start starts a span that is a child of the span or the remote span context in ctx, if any.
+// It returns ctx carrying the new span. It does nothing and returns a nil span if t is nil, i.e. tracing is disabled.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	s := &Span{
+		tracer: t,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent := SpanContextFromContext(ctx); parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.sc.Sampled = parent.Sampled
+		s.parentID = parent.SpanID
+	} else {
+		s.sc.TraceID = newTraceID()
+		s.sc.Sampled = t.sample(s.sc.TraceID)
+	}
+	s.sc.SpanID = newSpanID()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// sample decides whether a new trace is recorded. The decision depends on random bits of the trace ID only,
+// so it is consistent for all spans of the trace.
+func (t *Tracer) sample(traceID [16]byte) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	return float64(binary.BigEndian.Uint64(traceID[8:])) < t.sampleRatio*math.MaxUint64
+}
+
+// Start starts a child span of the span in ctx with the same tracer.
+// It does nothing and returns a nil span if ctx has no span, so calls outside of traced operations aren't recorded.
+func Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, kind, attrs...)
+}
+
+type spanKey struct{}
+
+type remoteSpanContextKey struct{}
+
+// SpanFromContext returns the span started with ctx, nil if there is none
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// SpanContextFromContext returns the span context of the span in ctx, or the remote one set by ContextWithRemoteSpanContext
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc
+	}
+	sc, _ := ctx.Value(remoteSpanContextKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithRemoteSpanContext returns ctx carrying the span context of a caller, which becomes the parent of spans started with it
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanContextKey{}, sc)
+}
+
+// Span is an operation of a trace. Methods of a nil span do nothing.
+type Span struct {
+	tracer   *Tracer
+	name     string
+	kind     SpanKind
+	sc       SpanContext
+	parentID [8]byte
+	start    time.Time
+
+	mu        sync.Mutex
+	end       time.Time
+	attrs     []Attr
+	statusMsg string
+	isError   bool
+	ended     bool
+}
+
+// SpanContext returns the span context of s
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttributes adds attributes to s
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetError marks s as failed with msg
+func (s *Span) SetError(msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.isError = true
+	s.statusMsg = msg
+}
+
+// End ends s and queues it for export if it is sampled. Calls after the first one do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	if s.sc.Sampled {
+		s.tracer.exp.enqueue(s)
+	}
+}
+
+func newTraceID() [16]byte {
+	var id [16]byte
+	for id == [16]byte{} {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	for id == [8]byte{} {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func hexID(id []byte) string {
+	return hex.EncodeToString(id)
+}
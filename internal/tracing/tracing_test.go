@@ -0,0 +1,193 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// collector records spans exported to it
+type collector struct {
+	mu    sync.Mutex
+	spans []span
+	res   resource
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	t.Helper()
+	c := &collector{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req exportRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			c.res = rs.Resource
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+func attr(attrs []Attr, key string) string {
+	for _, a := range attrs {
+		if a.Key != key {
+			continue
+		}
+		switch {
+		case a.Value.StringValue != nil:
+			return *a.Value.StringValue
+		case a.Value.IntValue != nil:
+			return *a.Value.IntValue
+		}
+	}
+	return ""
+}
+
+func TestTracer_Export(t *testing.T) {
+	c, srv := newCollector(t)
+	ms := metrics.NewSet()
+	tracer := NewTracer(Config{Endpoint: srv.URL + "/v1/traces", ServiceName: "mcp-vmanomaly", ServiceVersion: "v1.0.0", SampleRatio: 1}, ms)
+
+	ctx, root := tracer.Start(context.Background(), "tools/call vmanomaly_list_models", SpanKindServer, String("gen_ai.tool.name", "vmanomaly_list_models"))
+	_, child := Start(ctx, "GET /api/v1/models", SpanKindClient)
+	child.SetAttributes(Int("http.response.status_code", 503))
+	child.SetError("API error (status 503)")
+	child.End()
+	root.End()
+	root.End()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(c.spans))
+	}
+	gotChild, gotRoot := c.spans[0], c.spans[1]
+	if gotRoot.Name != "tools/call vmanomaly_list_models" || gotRoot.Kind != int(SpanKindServer) || gotRoot.ParentSpanID != "" {
+		t.Errorf("unexpected root span: %+v", gotRoot)
+	}
+	if attr(gotRoot.Attributes, "gen_ai.tool.name") != "vmanomaly_list_models" || gotRoot.Status.Code != statusUnset {
+		t.Errorf("unexpected root span: %+v", gotRoot)
+	}
+	if gotChild.TraceID != gotRoot.TraceID || gotChild.ParentSpanID != gotRoot.SpanID || gotChild.Kind != int(SpanKindClient) {
+		t.Errorf("expected child span of root, got %+v", gotChild)
+	}
+	if attr(gotChild.Attributes, "http.response.status_code") != "503" || gotChild.Status.Code != statusError {
+		t.Errorf("unexpected child span: %+v", gotChild)
+	}
+	if gotChild.StartTimeUnixNano == "" || gotChild.EndTimeUnixNano < gotChild.StartTimeUnixNano {
+		t.Errorf("unexpected child span times: %s - %s", gotChild.StartTimeUnixNano, gotChild.EndTimeUnixNano)
+	}
+	if attr(c.res.Attributes, "service.name") != "mcp-vmanomaly" {
+		t.Errorf("unexpected resource: %+v", c.res)
+	}
+	if n := ms.GetOrCreateCounter(`mcp_vmanomaly_tracing_spans_exported_total`).Get(); n != 2 {
+		t.Errorf("expected 2 exported spans, got %d", n)
+	}
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	_, srv := newCollector(t)
+	tracer := NewTracer(Config{Endpoint: srv.URL + "/v1/traces", SampleRatio: 0}, metrics.NewSet())
+	defer func() { _ = tracer.Shutdown(context.Background()) }()
+
+	// New traces aren't sampled, but their context is propagated
+	_, s := tracer.Start(context.Background(), "op", SpanKindServer)
+	if sc := s.SpanContext(); !sc.IsValid() || sc.Sampled {
+		t.Errorf("expected valid unsampled span context, got %+v", sc)
+	}
+
+	// Traces of callers follow their sampled flag
+	remote := SpanContext{TraceID: [16]byte{1}, SpanID: [8]byte{2}, Sampled: true}
+	_, s = tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "op", SpanKindServer)
+	if sc := s.SpanContext(); !sc.Sampled || sc.TraceID != remote.TraceID || s.parentID != remote.SpanID {
+		t.Errorf("expected sampled child of remote span, got %+v", sc)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	var tracer *Tracer
+	ctx, s := tracer.Start(context.Background(), "op", SpanKindServer)
+	if s != nil || SpanFromContext(ctx) != nil {
+		t.Fatalf("expected no span with nil tracer")
+	}
+	// Methods of nil spans are no-ops
+	s.SetAttributes(String("k", "v"))
+	s.SetError("failed")
+	s.End()
+
+	if _, s := Start(context.Background(), "op", SpanKindClient); s != nil {
+		t.Errorf("expected no child span without parent")
+	}
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	h := http.Header{}
+	h.Set("traceparent", traceparent)
+	ctx := Extract(context.Background(), h)
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.Sampled || hexID(sc.TraceID[:]) != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("unexpected span context: %+v", sc)
+	}
+
+	out := http.Header{}
+	Inject(ctx, out)
+	if got := out.Get("traceparent"); got != traceparent {
+		t.Errorf("expected remote span context to be propagated, got %q", got)
+	}
+
+	tracer := &Tracer{sampleRatio: 1, exp: newExporter(Config{}, metrics.NewSet())}
+	ctx, s := tracer.Start(ctx, "op", SpanKindServer)
+	Inject(ctx, out)
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + hexID(s.sc.SpanID[:]) + "-01"
+	if got := out.Get("traceparent"); got != want {
+		t.Errorf("expected traceparent %q, got %q", want, got)
+	}
+
+	for _, v := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+	} {
+		if _, ok := parseTraceparent(v); ok {
+			t.Errorf("expected invalid traceparent %q", v)
+		}
+	}
+	// Future versions may have additional fields
+	if _, ok := parseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); !ok {
+		t.Errorf("expected traceparent of a future version to be accepted")
+	}
+}
@@ -0,0 +1,40 @@
+package vmanomaly
+
+import (
+	"context"
+)
+
+// API is the set of vmanomaly server operations used by MCP tools.
+// Client implements it; wrappers (caching, retries, auditing) and test fakes
+// can implement it as well and be passed to tool registration instead.
+type API interface {
+	// Health and Info
+	GetHealth(ctx context.Context) (map[string]any, error)
+	GetBuildInfo(ctx context.Context) (map[string]any, error)
+	GetServerQueries(ctx context.Context) (ServerQueriesResponse, error)
+	Metrics(ctx context.Context, config map[string]any) (string, error)
+
+	// Models
+	ListModels(ctx context.Context) (*ModelsListResponse, error)
+	GetServerModels(ctx context.Context) (*ServerModelsResponse, error)
+	GetModelSchema(ctx context.Context, modelClass string) (map[string]any, error)
+	ValidateModel(ctx context.Context, modelSpec map[string]any) (*ModelValidationResponse, error)
+
+	// Configuration
+	GenerateConfig(ctx context.Context, req *ConfigGenerationRequest) (string, error)
+	ValidateConfig(ctx context.Context, config map[string]any) (*OkValidationResponse, error)
+	Compatibility(ctx context.Context, versionTo *string) (*CompatibilityCheckResponse, error)
+	GenerateAlertRule(ctx context.Context, req *AlertRuleRequest) (string, error)
+
+	// Anomaly Detection Tasks
+	CreateDetectionTask(ctx context.Context, req *AnomalyDetectionTaskRequest) (*AnomalyDetectionTaskResponse, error)
+	GetTaskStatus(ctx context.Context, taskID string) (*AnomalyDetectionTaskStatus, error)
+	ListTasks(ctx context.Context, limit int, status *string) (*AnomalyDetectionTaskListResponse, error)
+	CancelTask(ctx context.Context, taskID string) (map[string]bool, error)
+	GetDetectionLimits(ctx context.Context) (*AnomalyDetectionLimitsResponse, error)
+
+	// Data
+	Query(ctx context.Context, req *QueryRequest) (map[string]any, error)
+}
+
+var _ API = (*Client)(nil)
@@ -0,0 +1,106 @@
+package vmanomaly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// maxErrorBodySize limits the raw response body kept in APIError messages
+const maxErrorBodySize = 4096
+
+// APIError is a non-2xx response of the vmanomaly API
+type APIError struct {
+	StatusCode int
+	// Detail is the "detail" string of FastAPI error responses, or the raw body if it has no such field
+	Detail string
+	// ValidationErrors are the items of the "detail" list of FastAPI/Pydantic validation error responses
+	ValidationErrors []ValidationError
+	// Retryable reports whether the failure is transient, so the same request may succeed later
+	Retryable bool
+}
+
+// ValidationError is a single FastAPI/Pydantic validation error item
+type ValidationError struct {
+	// Loc is the location of the invalid value, e.g. ["body", "models", "m1", "z_threshold"]
+	Loc  []any  `json:"loc"`
+	Msg  string `json:"msg"`
+	Type string `json:"type"`
+}
+
+// Field returns the dot-separated path of the invalid value within the request part it belongs to,
+// e.g. models.m1.z_threshold, or the request part itself if the whole part is invalid
+func (ve ValidationError) Field() string {
+	loc := ve.Loc
+	if len(loc) > 1 {
+		// The first element is the request part: body, query, path or header
+		loc = loc[1:]
+	}
+	parts := make([]string, 0, len(loc))
+	for _, l := range loc {
+		switch v := l.(type) {
+		case string:
+			parts = append(parts, v)
+		case float64:
+			// List indexes are decoded from JSON as numbers
+			parts = append(parts, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// newAPIError parses the response body of a failed request
+func newAPIError(status int, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: status,
+		Retryable:  isRetryableStatus(status),
+	}
+	var resp struct {
+		Detail json.RawMessage `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && len(resp.Detail) > 0 {
+		if json.Unmarshal(resp.Detail, &e.Detail) == nil {
+			return e
+		}
+		if json.Unmarshal(resp.Detail, &e.ValidationErrors) == nil && len(e.ValidationErrors) > 0 {
+			return e
+		}
+		e.ValidationErrors = nil
+	}
+	e.Detail = string(body)
+	if len(e.Detail) > maxErrorBodySize {
+		e.Detail = e.Detail[:maxErrorBodySize] + "..."
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	if len(e.ValidationErrors) == 0 {
+		return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Detail)
+	}
+	items := make([]string, 0, len(e.ValidationErrors))
+	for _, ve := range e.ValidationErrors {
+		items = append(items, fmt.Sprintf("%s: %s", ve.Field(), ve.Msg))
+	}
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, strings.Join(items, "; "))
+}
+
+// IsUnavailable reports whether err means vmanomaly couldn't serve the request at all:
+// network errors, timeouts, open circuit breaker or transient and 5xx responses
+func IsUnavailable(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
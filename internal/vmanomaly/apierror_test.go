@@ -0,0 +1,107 @@
+package vmanomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantDetail    string
+		wantFields    []string
+		wantRetryable bool
+		wantError     string
+	}{
+		{
+			name:       "string detail",
+			status:     http.StatusNotFound,
+			body:       `{"detail":"Task not found"}`,
+			wantDetail: "Task not found",
+			wantError:  "API error (status 404): Task not found",
+		},
+		{
+			name:       "validation errors",
+			status:     http.StatusUnprocessableEntity,
+			body:       `{"detail":[{"loc":["body","models","m1","z_threshold"],"msg":"Input should be greater than 0","type":"greater_than"},{"loc":["query","limit"],"msg":"Field required","type":"missing"},{"loc":["body","series",0],"msg":"Input should be a valid number","type":"float_type"}]}`,
+			wantFields: []string{"models.m1.z_threshold", "limit", "series.0"},
+			wantError:  "API error (status 422): models.m1.z_threshold: Input should be greater than 0; limit: Field required; series.0: Input should be a valid number",
+		},
+		{
+			name:          "non-JSON body",
+			status:        http.StatusBadGateway,
+			body:          "<html>Bad Gateway</html>",
+			wantDetail:    "<html>Bad Gateway</html>",
+			wantRetryable: true,
+			wantError:     "API error (status 502): <html>Bad Gateway</html>",
+		},
+		{
+			name:          "unexpected detail",
+			status:        http.StatusServiceUnavailable,
+			body:          `{"detail":{"reason":"overloaded"}}`,
+			wantDetail:    `{"detail":{"reason":"overloaded"}}`,
+			wantRetryable: true,
+			wantError:     `API error (status 503): {"detail":{"reason":"overloaded"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newAPIError(tt.status, []byte(tt.body))
+			assertEqual(t, e.StatusCode, tt.status)
+			assertEqual(t, e.Detail, tt.wantDetail)
+			assertEqual(t, e.Retryable, tt.wantRetryable)
+			assertEqual(t, e.Error(), tt.wantError)
+			if len(e.ValidationErrors) != len(tt.wantFields) {
+				t.Fatalf("expected %d validation errors, got %+v", len(tt.wantFields), e.ValidationErrors)
+			}
+			for i, ve := range e.ValidationErrors {
+				assertEqual(t, ve.Field(), tt.wantFields[i])
+			}
+		})
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"detail":[{"loc":["body","class"],"msg":"Field required","type":"missing"}]}`))
+	})
+	defer server.Close()
+
+	_, err := client.ValidateModel(context.Background(), map[string]any{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	assertEqual(t, apiErr.StatusCode, http.StatusUnprocessableEntity)
+	assertEqual(t, len(apiErr.ValidationErrors), 1)
+	assertEqual(t, apiErr.ValidationErrors[0].Type, "missing")
+}
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("request failed: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), true},
+		{fmt.Errorf("request failed: %w", context.DeadlineExceeded), true},
+		{ErrCircuitOpen, true},
+		{newAPIError(http.StatusServiceUnavailable, nil), true},
+		{newAPIError(http.StatusInternalServerError, nil), true},
+		{newAPIError(http.StatusNotFound, []byte(`{"detail":"unknown model class"}`)), false},
+		{newAPIError(http.StatusUnauthorized, nil), false},
+		{fmt.Errorf("request failed: %w", context.Canceled), false},
+		{errors.New("failed to parse response"), false},
+	}
+	for _, tt := range tests {
+		if got := IsUnavailable(tt.err); got != tt.want {
+			t.Errorf("IsUnavailable(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
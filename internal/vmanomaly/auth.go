@@ -0,0 +1,28 @@
+package vmanomaly
+
+import (
+	"context"
+	"strings"
+)
+
+type authorizationKey struct{}
+
+// ContextWithAuthorization returns ctx carrying the Authorization header value of the MCP caller.
+// Client sends it to vmanomaly instead of the configured bearer token, so vmanomaly applies
+// per-user access control. A bare token without auth scheme is sent as a Bearer token.
+func ContextWithAuthorization(ctx context.Context, value string) context.Context {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ctx
+	}
+	if !strings.Contains(value, " ") {
+		value = "Bearer " + value
+	}
+	return context.WithValue(ctx, authorizationKey{}, value)
+}
+
+// AuthorizationFromContext returns the caller's Authorization header value set by ContextWithAuthorization
+func AuthorizationFromContext(ctx context.Context) string {
+	value, _ := ctx.Value(authorizationKey{}).(string)
+	return value
+}
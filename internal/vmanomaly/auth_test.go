@@ -0,0 +1,47 @@
+package vmanomaly
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestContextWithAuthorization(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "empty", value: "", want: ""},
+		{name: "bearer", value: "Bearer abc", want: "Bearer abc"},
+		{name: "basic", value: "Basic dXNlcjpwYXNz", want: "Basic dXNlcjpwYXNz"},
+		{name: "bare token", value: " abc ", want: "Bearer abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ContextWithAuthorization(context.Background(), tt.value)
+			assertEqual(t, AuthorizationFromContext(ctx), tt.want)
+		})
+	}
+}
+
+func TestClient_ForwardedAuthorization(t *testing.T) {
+	var got string
+	client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	})
+	defer server.Close()
+	client.bearerToken = "shared-token"
+
+	if _, err := client.GetHealth(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, got, "Bearer shared-token")
+
+	ctx := ContextWithAuthorization(context.Background(), "Bearer user-token")
+	if _, err := client.GetHealth(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, got, "Bearer user-token")
+}
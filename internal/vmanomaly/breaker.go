@@ -0,0 +1,172 @@
+package vmanomaly
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// ErrCircuitOpen is returned without calling the backend while the circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenTimeout      = 30 * time.Second
+)
+
+// BreakerState is a circuit breaker state
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Requests pass through
+	BreakerOpen                         // Requests are rejected
+	BreakerHalfOpen                     // A single probe request is allowed
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures CircuitBreaker
+type CircuitBreakerConfig struct {
+	Instance         string        // Instance name used as metrics label, "default" if empty
+	FailureThreshold int           // Consecutive failed requests that open the breaker
+	OpenTimeout      time.Duration // Time the breaker stays open before allowing a probe request
+}
+
+// CircuitBreaker stops sending requests to a backend that keeps failing.
+// After FailureThreshold consecutive failures it opens and rejects requests with ErrCircuitOpen.
+// Once OpenTimeout passes a single probe request is let through: its success closes the breaker,
+// its failure opens it again.
+type CircuitBreaker struct {
+	cfg CircuitBreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	lastErr  error
+
+	stateGauge  *metrics.Gauge
+	rejected    *metrics.Counter
+	transitions map[BreakerState]*metrics.Counter
+}
+
+// NewCircuitBreaker returns a circuit breaker exporting its state to ms.
+// ms may be nil if metrics are not needed.
+func NewCircuitBreaker(cfg CircuitBreakerConfig, ms *metrics.Set) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultBreakerFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if cfg.Instance == "" {
+		cfg.Instance = DefaultInstanceName
+	}
+
+	cb := &CircuitBreaker{
+		cfg:         cfg,
+		now:         time.Now,
+		transitions: make(map[BreakerState]*metrics.Counter),
+	}
+	if ms == nil {
+		ms = metrics.NewSet()
+	}
+	// The gauge is set on transitions rather than computed from a callback,
+	// so a breaker recreated on config reload takes it over from the previous one
+	cb.stateGauge = ms.GetOrCreateGauge(fmt.Sprintf(`mcp_vmanomaly_circuit_breaker_state{instance=%q}`, cfg.Instance), nil)
+	cb.stateGauge.Set(float64(BreakerClosed))
+	cb.rejected = ms.GetOrCreateCounter(fmt.Sprintf(`mcp_vmanomaly_circuit_breaker_rejected_total{instance=%q}`, cfg.Instance))
+	for _, s := range []BreakerState{BreakerClosed, BreakerOpen, BreakerHalfOpen} {
+		cb.transitions[s] = ms.GetOrCreateCounter(fmt.Sprintf(`mcp_vmanomaly_circuit_breaker_transitions_total{instance=%q,state="%s"}`, cfg.Instance, s))
+	}
+	return cb
+}
+
+// State returns the current breaker state
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// Allow returns an error wrapping ErrCircuitOpen if the request must not be sent.
+// Every allowed request must be followed by Done or Cancel.
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case BreakerOpen:
+		wait := cb.cfg.OpenTimeout - cb.now().Sub(cb.openedAt)
+		if wait > 0 {
+			cb.rejected.Inc()
+			return fmt.Errorf("%w: vmanomaly instance %q is unavailable after %d consecutive failures, next attempt in %s (last error: %v)",
+				ErrCircuitOpen, cb.cfg.Instance, cb.failures, wait.Round(time.Second), cb.lastErr)
+		}
+		cb.setState(BreakerHalfOpen)
+		cb.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if cb.probing {
+			cb.rejected.Inc()
+			return fmt.Errorf("%w: waiting for a probe request to vmanomaly instance %q (last error: %v)", ErrCircuitOpen, cb.cfg.Instance, cb.lastErr)
+		}
+		cb.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Done records the outcome of an allowed request. err must be nil if the backend responded properly.
+func (cb *CircuitBreaker) Done(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+	if err == nil {
+		cb.failures = 0
+		if cb.state != BreakerClosed {
+			cb.setState(BreakerClosed)
+		}
+		return
+	}
+
+	cb.failures++
+	cb.lastErr = err
+	if cb.state == BreakerHalfOpen || cb.failures >= cb.cfg.FailureThreshold {
+		cb.openedAt = cb.now()
+		cb.setState(BreakerOpen)
+	}
+}
+
+// Cancel releases an allowed request without recording its outcome,
+// e.g. when the request was canceled by the caller.
+func (cb *CircuitBreaker) Cancel() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probing = false
+}
+
+// setState must be called with cb.mu held
+func (cb *CircuitBreaker) setState(s BreakerState) {
+	cb.state = s
+	cb.stateGauge.Set(float64(s))
+	cb.transitions[s].Inc()
+}
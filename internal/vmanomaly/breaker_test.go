@@ -0,0 +1,94 @@
+package vmanomaly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ms := metrics.NewSet()
+	cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}, ms)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cb.now = func() time.Time { return now }
+	state := ms.GetOrCreateGauge(`mcp_vmanomaly_circuit_breaker_state{instance="default"}`, nil)
+
+	fail := errors.New("connection refused")
+	for i := 0; i < 2; i++ {
+		if err := cb.Allow(); err != nil {
+			t.Fatalf("attempt %d: unexpected error: %v", i, err)
+		}
+		cb.Done(fail)
+	}
+	assertEqual(t, cb.State(), BreakerOpen)
+	assertEqual(t, state.Get(), float64(BreakerOpen))
+
+	err := cb.Allow()
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "connection refused") || !strings.Contains(err.Error(), "1m0s") {
+		t.Errorf("error %q should mention last error and time to retry", err)
+	}
+	assertEqual(t, ms.GetOrCreateCounter(`mcp_vmanomaly_circuit_breaker_rejected_total{instance="default"}`).Get(), uint64(1))
+
+	// A single probe is allowed after the open timeout
+	now = now.Add(time.Minute)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("probe: unexpected error: %v", err)
+	}
+	assertEqual(t, cb.State(), BreakerHalfOpen)
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("concurrent probe: expected ErrCircuitOpen, got %v", err)
+	}
+
+	// Failed probe opens the breaker again
+	cb.Done(fail)
+	assertEqual(t, cb.State(), BreakerOpen)
+
+	// Successful probe closes it
+	now = now.Add(time.Minute)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("probe: unexpected error: %v", err)
+	}
+	cb.Done(nil)
+	assertEqual(t, cb.State(), BreakerClosed)
+	assertEqual(t, state.Get(), float64(BreakerClosed))
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Hour}, nil)
+	client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte(`{}`))
+	}, WithCircuitBreaker(cb))
+
+	// Client errors don't count as backend failures
+	status.Store(http.StatusNotFound)
+	for i := 0; i < 3; i++ {
+		_, _ = client.GetHealth(context.Background())
+	}
+	assertEqual(t, cb.State(), BreakerClosed)
+
+	status.Store(http.StatusInternalServerError)
+	for i := 0; i < 2; i++ {
+		_, _ = client.GetHealth(context.Background())
+	}
+	assertEqual(t, cb.State(), BreakerOpen)
+
+	_, err := client.GetHealth(context.Background())
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	assertEqual(t, calls.Load(), int32(5))
+}
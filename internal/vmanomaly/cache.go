@@ -0,0 +1,163 @@
+package vmanomaly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+type noCacheKey struct{}
+
+// ContextWithNoCache returns ctx making cached requests bypass the response cache.
+// Fresh responses still replace cached ones.
+func ContextWithNoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// NoCacheFromContext reports whether ctx is made by ContextWithNoCache
+func NoCacheFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(noCacheKey{}).(bool)
+	return v
+}
+
+// responseCache keeps response bodies of metadata endpoints that only change on vmanomaly upgrade.
+// Concurrent requests of the same uncached response share a single upstream request.
+type responseCache struct {
+	ttl time.Duration
+	// timeout limits shared upstream requests, which aren't canceled with the caller that started them
+	timeout  time.Duration
+	instance string
+	ms       *metrics.Set
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	calls   map[string]*cacheCall
+	// generation is incremented on invalidation, responses of requests started before it aren't stored
+	generation uint64
+	version    string
+}
+
+type cacheEntry struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+// cacheCall is an upstream request in progress, shared by concurrent callers
+type cacheCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+func newResponseCache(ttl, timeout time.Duration, instance string, ms *metrics.Set) *responseCache {
+	if ms == nil {
+		ms = metrics.NewSet()
+	}
+	return &responseCache{
+		ttl:      ttl,
+		timeout:  timeout,
+		instance: instance,
+		ms:       ms,
+		now:      time.Now,
+		entries:  make(map[string]cacheEntry),
+		calls:    make(map[string]*cacheCall),
+	}
+}
+
+// get returns the cached response of the endpoint at path or fetches it.
+// Responses are cached per caller credentials, since forwarded credentials may grant different access.
+func (rc *responseCache) get(ctx context.Context, endpoint, path string, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	key := path
+	if auth := AuthorizationFromContext(ctx); auth != "" {
+		sum := sha256.Sum256([]byte(auth))
+		key = hex.EncodeToString(sum[:8]) + " " + path
+	}
+
+	rc.mu.Lock()
+	if e, ok := rc.entries[key]; ok && !NoCacheFromContext(ctx) && rc.now().Before(e.expiresAt) {
+		rc.mu.Unlock()
+		rc.hit(endpoint)
+		return e.body, nil
+	}
+	if call, ok := rc.calls[key]; ok {
+		rc.mu.Unlock()
+		rc.hit(endpoint)
+		return call.wait(ctx)
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	rc.calls[key] = call
+	generation := rc.generation
+	rc.mu.Unlock()
+	rc.ms.GetOrCreateCounter(fmt.Sprintf(`mcp_vmanomaly_cache_misses_total{instance=%q,endpoint="%s"}`, rc.instance, endpoint)).Inc()
+
+	// The shared request outlives the caller that started it, so canceling that caller doesn't fail the others
+	go rc.fetch(ctx, key, generation, call, fetch)
+	return call.wait(ctx)
+}
+
+// fetch performs the shared request of call with values of ctx, but without its cancellation, and stores the response
+func (rc *responseCache) fetch(ctx context.Context, key string, generation uint64, call *cacheCall, fetch func(ctx context.Context) ([]byte, error)) {
+	ctx = context.WithoutCancel(ctx)
+	if rc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.timeout)
+		defer cancel()
+	}
+	call.body, call.err = fetch(ctx)
+
+	rc.mu.Lock()
+	delete(rc.calls, key)
+	if call.err == nil && generation == rc.generation {
+		rc.evictExpired()
+		rc.entries[key] = cacheEntry{body: call.body, expiresAt: rc.now().Add(rc.ttl)}
+	}
+	rc.mu.Unlock()
+	close(call.done)
+}
+
+// wait returns the response of call unless ctx is done first
+func (call *cacheCall) wait(ctx context.Context) ([]byte, error) {
+	select {
+	case <-call.done:
+		return call.body, call.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("request failed: %w", ctx.Err())
+	}
+}
+
+func (rc *responseCache) hit(endpoint string) {
+	rc.ms.GetOrCreateCounter(fmt.Sprintf(`mcp_vmanomaly_cache_hits_total{instance=%q,endpoint="%s"}`, rc.instance, endpoint)).Inc()
+}
+
+// evictExpired removes expired entries, so entries of callers with other credentials don't pile up.
+// rc.mu must be held.
+func (rc *responseCache) evictExpired() {
+	now := rc.now()
+	for key, e := range rc.entries {
+		if !now.Before(e.expiresAt) {
+			delete(rc.entries, key)
+		}
+	}
+}
+
+// observeVersion invalidates all cached responses if version differs from the previously observed one,
+// i.e. vmanomaly was upgraded or downgraded
+func (rc *responseCache) observeVersion(version string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if version == "" || version == rc.version {
+		return
+	}
+	if rc.version != "" {
+		clear(rc.entries)
+		rc.generation++
+		rc.ms.GetOrCreateCounter(fmt.Sprintf(`mcp_vmanomaly_cache_invalidations_total{instance=%q}`, rc.instance)).Inc()
+	}
+	rc.version = version
+}
@@ -0,0 +1,177 @@
+package vmanomaly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+func TestClient_Cache(t *testing.T) {
+	var version atomic.Value
+	version.Store("v1.28.0")
+	var calls sync.Map
+	count := func(path string) int32 {
+		v, _ := calls.LoadOrStore(path, new(atomic.Int32))
+		return v.(*atomic.Int32).Load()
+	}
+	release := make(chan struct{})
+	close(release)
+	var block atomic.Pointer[chan struct{}]
+	block.Store(&release)
+	ms := metrics.NewSet()
+	client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		v, _ := calls.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		v.(*atomic.Int32).Add(1)
+		<-*block.Load()
+		switch r.URL.Path {
+		case "/api/v1/server/buildinfo":
+			_, _ = w.Write([]byte(`{"vmanomaly":"` + version.Load().(string) + `"}`))
+		case "/api/v1/models":
+			_, _ = w.Write([]byte(`{"models":["zscore"]}`))
+		case "/api/v1/model/schema":
+			_, _ = w.Write([]byte(`{"title":"` + r.URL.Query().Get("model_class") + `"}`))
+		}
+	}, WithCache(time.Minute), WithMetricsSet(ms), WithInstanceName("a"))
+	now := time.Now()
+	client.cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	mustGet := func(ctx context.Context) {
+		t.Helper()
+		if _, err := client.ListModels(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	mustGet(ctx)
+	mustGet(ctx)
+	assertEqual(t, count("/api/v1/models"), int32(1))
+
+	// Schemas are cached per model class
+	for _, class := range []string{"zscore", "prophet", "zscore"} {
+		schema, err := client.GetModelSchema(ctx, class)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertEqual(t, schema["title"], class)
+	}
+	assertEqual(t, count("/api/v1/model/schema"), int32(2))
+
+	// nocache bypasses the cache and refreshes the cached response
+	mustGet(ContextWithNoCache(ctx))
+	mustGet(ctx)
+	assertEqual(t, count("/api/v1/models"), int32(2))
+
+	// Responses fetched with forwarded credentials aren't shared with other callers
+	mustGet(ContextWithAuthorization(ctx, "Bearer other"))
+	assertEqual(t, count("/api/v1/models"), int32(3))
+
+	// Entries expire after TTL
+	now = now.Add(time.Minute)
+	mustGet(ctx)
+	assertEqual(t, count("/api/v1/models"), int32(4))
+
+	// Concurrent requests of uncached responses share a single upstream request
+	now = now.Add(time.Minute)
+	unblock := make(chan struct{})
+	block.Store(&unblock)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() { mustGet(ctx) })
+	}
+	for count("/api/v1/models") < 5 {
+		time.Sleep(time.Millisecond)
+	}
+	// Let the followers join the request in progress
+	time.Sleep(10 * time.Millisecond)
+	close(unblock)
+	wg.Wait()
+	assertEqual(t, count("/api/v1/models"), int32(5))
+
+	// Build info reporting a new version invalidates cached responses
+	if _, err := client.GetBuildInfo(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	version.Store("v1.29.0")
+	mustGet(ctx)
+	assertEqual(t, count("/api/v1/models"), int32(5))
+	info, err := client.GetBuildInfo(ContextWithNoCache(ctx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, info["vmanomaly"], "v1.29.0")
+	mustGet(ctx)
+	assertEqual(t, count("/api/v1/models"), int32(6))
+	if _, err := client.GetModelSchema(ctx, "zscore"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, count("/api/v1/model/schema"), int32(3))
+
+	assertEqual(t, ms.GetOrCreateCounter(`mcp_vmanomaly_cache_hits_total{instance="a",endpoint="/api/v1/models"}`).Get(), uint64(7))
+	assertEqual(t, ms.GetOrCreateCounter(`mcp_vmanomaly_cache_misses_total{instance="a",endpoint="/api/v1/models"}`).Get(), uint64(6))
+	assertEqual(t, ms.GetOrCreateCounter(`mcp_vmanomaly_cache_invalidations_total{instance="a"}`).Get(), uint64(1))
+}
+
+func TestClient_CacheDisabled(t *testing.T) {
+	var calls atomic.Int32
+	client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"models":["zscore"]}`))
+	})
+	for range 2 {
+		if _, err := client.ListModels(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assertEqual(t, calls.Load(), int32(2))
+}
+
+func TestClient_CacheCanceledCaller(t *testing.T) {
+	var calls atomic.Int32
+	unblock := make(chan struct{})
+	client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-unblock
+		_, _ = w.Write([]byte(`{"models":["zscore"]}`))
+	}, WithCache(time.Minute))
+
+	// The first caller starts the upstream request and gives up while another caller waits for it
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := client.ListModels(ctx)
+		firstErr <- err
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	secondErr := make(chan error, 1)
+	go func() {
+		_, err := client.ListModels(context.Background())
+		secondErr <- err
+	}()
+	// Let the second caller join the request in progress
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled for the first caller, got %v", err)
+	}
+
+	close(unblock)
+	if err := <-secondErr; err != nil {
+		t.Fatalf("unexpected error of the waiting caller: %v", err)
+	}
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, strings.Join(models.Models, ","), "zscore")
+	assertEqual(t, calls.Load(), int32(1))
+}
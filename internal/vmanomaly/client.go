@@ -3,12 +3,19 @@ package vmanomaly
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/tracing"
 )
 
 // Client represents a vmanomaly API client
@@ -17,10 +24,73 @@ type Client struct {
 	httpClient    *http.Client
 	bearerToken   string
 	customHeaders map[string]string
+
+	instance string
+	retry    RetryConfig
+	breaker  *CircuitBreaker
+	cacheTTL time.Duration
+	cache    *responseCache
+	ms       *metrics.Set
+	retries  *metrics.Counter
+}
+
+// ClientOption configures optional Client behavior
+type ClientOption func(*Client)
+
+// WithTimeout sets the timeout of a single HTTP request, 30s by default
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithTLSConfig sets TLS config of connections to vmanomaly, see NewTLSConfig
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *Client) {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = cfg
+		c.httpClient.Transport = transport
+	}
+}
+
+// WithRetry enables retries of idempotent requests failed with transient errors
+func WithRetry(rc RetryConfig) ClientOption {
+	return func(c *Client) {
+		c.retry = rc
+	}
+}
+
+// WithCircuitBreaker makes the client reject requests while cb is open
+func WithCircuitBreaker(cb *CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = cb
+	}
+}
+
+// WithCache enables caching of model list, model schema and build info responses for ttl.
+// Cached responses are invalidated when build info reports another vmanomaly version.
+func WithCache(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.cacheTTL = ttl
+	}
 }
 
-func NewClient(baseURL, bearerToken string, customHeaders map[string]string) *Client {
-	return &Client{
+// WithMetricsSet registers client metrics in ms
+func WithMetricsSet(ms *metrics.Set) ClientOption {
+	return func(c *Client) {
+		c.ms = ms
+	}
+}
+
+// WithInstanceName sets the instance name used as metrics label, "default" if not set
+func WithInstanceName(name string) ClientOption {
+	return func(c *Client) {
+		c.instance = name
+	}
+}
+
+func NewClient(baseURL, bearerToken string, customHeaders map[string]string, opts ...ClientOption) *Client {
+	c := &Client{
 		baseURL:       baseURL,
 		bearerToken:   bearerToken,
 		customHeaders: customHeaders,
@@ -28,22 +98,106 @@ func NewClient(baseURL, bearerToken string, customHeaders map[string]string) *Cl
 			Timeout: 30 * time.Second,
 		},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.instance == "" {
+		c.instance = DefaultInstanceName
+	}
+	if c.ms != nil {
+		c.retries = c.ms.GetOrCreateCounter(fmt.Sprintf(`mcp_vmanomaly_upstream_retries_total{instance=%q}`, c.instance))
+	}
+	if c.cacheTTL > 0 {
+		c.cache = newResponseCache(c.cacheTTL, c.httpClient.Timeout, c.instance, c.ms)
+	}
+	return c
 }
 
-func (c *Client) doRequest(ctx context.Context, method, path string, body any) ([]byte, error) {
-	var reqBody io.Reader
+func (c *Client) doRequest(ctx context.Context, method, path string, body any) (result []byte, err error) {
+	// Retries are part of the span, so it shows the time the caller waited for vmanomaly
+	urlPath, _, _ := strings.Cut(path, "?")
+	endpoint := endpointOf(urlPath)
+	ctx, span := tracing.Start(ctx, method+" "+endpoint, tracing.SpanKindClient,
+		tracing.String("http.request.method", method),
+		tracing.String("url.path", urlPath),
+		tracing.String("server.address", c.serverAddress()),
+		tracing.String("vmanomaly.instance", c.instance),
+	)
+	defer func() {
+		if err != nil {
+			span.SetError(err.Error())
+		}
+		span.End()
+	}()
+
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	if c.breaker != nil {
+		if err := c.breaker.Allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	maxRetries := 0
+	if isIdempotent(method, path) {
+		maxRetries = max(c.retry.MaxRetries, 0)
+	}
+
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		status, header, respBody, err := c.do(ctx, method, path, jsonData)
+		c.observeRequest(method, endpoint, status, start)
+		if attempt < maxRetries && isRetryable(ctx, status, err) {
+			if delay, ok := c.retry.backoff(attempt, header); ok {
+				if c.retries != nil {
+					c.retries.Inc()
+				}
+				span.SetAttributes(tracing.Int("http.request.resend_count", attempt+1))
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					c.breakerDone(ctx, 0, nil)
+					return nil, fmt.Errorf("request failed: %w", ctx.Err())
+				case <-timer.C:
+				}
+				continue
+			}
+		}
+
+		c.breakerDone(ctx, status, err)
+		recordStatus(ctx, status)
+		if status != 0 {
+			span.SetAttributes(tracing.Int("http.response.status_code", status))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if status < 200 || status >= 300 {
+			return nil, newAPIError(status, respBody)
+		}
+		return respBody, nil
+	}
+}
+
+// do performs a single HTTP request attempt
+func (c *Client) do(ctx context.Context, method, path string, jsonData []byte) (int, http.Header, []byte, error) {
+	var reqBody io.Reader
+	if jsonData != nil {
+		reqBody = bytes.NewReader(jsonData)
 	}
 
 	url := fmt.Sprintf("%s%s", c.baseURL, path)
 	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return 0, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if c.bearerToken != "" {
@@ -54,24 +208,95 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any) (
 		req.Header.Set(key, value)
 	}
 
+	// Credentials of the MCP caller take precedence over the configured ones
+	if auth := AuthorizationFromContext(ctx); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
 	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return 0, nil, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return 0, nil, nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+	return resp.StatusCode, resp.Header, respBody, nil
+}
+
+// observeRequest records the duration of a request attempt started at start.
+// Attempts failed without a response have the "error" status.
+func (c *Client) observeRequest(method, endpoint string, status int, start time.Time) {
+	if c.ms == nil {
+		return
 	}
+	statusLabel := "error"
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	c.ms.GetOrCreateHistogram(fmt.Sprintf(
+		`mcp_vmanomaly_upstream_request_duration_seconds{instance=%q,method="%s",endpoint="%s",status="%s"}`,
+		c.instance, method, endpoint, statusLabel,
+	)).UpdateDuration(start)
+}
 
-	return respBody, nil
+// endpointOf returns the API endpoint of urlPath, replacing path parameters with their names
+// so metric labels and span names don't depend on IDs
+func endpointOf(urlPath string) string {
+	if id, ok := strings.CutPrefix(urlPath, "/api/v1/anomaly_detection/tasks/"); ok && id != "" {
+		return "/api/v1/anomaly_detection/tasks/{task_id}"
+	}
+	return urlPath
+}
+
+// serverAddress returns the host of the vmanomaly endpoint
+func (c *Client) serverAddress() string {
+	if u, err := url.Parse(c.baseURL); err == nil {
+		return u.Hostname()
+	}
+	return ""
+}
+
+// doCachedRequest performs GET request of path unless its response is cached, see WithCache
+func (c *Client) doCachedRequest(ctx context.Context, endpoint, path string) ([]byte, error) {
+	if c.cache == nil {
+		return c.doRequest(ctx, http.MethodGet, path, nil)
+	}
+	return c.cache.get(ctx, endpoint, path, func(ctx context.Context) ([]byte, error) {
+		return c.doRequest(ctx, http.MethodGet, path, nil)
+	})
+}
+
+// breakerDone reports the request outcome to the circuit breaker.
+// Network errors and 5xx responses count as backend failures, requests canceled by the caller are not counted.
+func (c *Client) breakerDone(ctx context.Context, status int, err error) {
+	if c.breaker == nil {
+		return
+	}
+	switch {
+	case ctx.Err() != nil:
+		c.breaker.Cancel()
+	case err != nil:
+		c.breaker.Done(err)
+	case status >= 500:
+		c.breaker.Done(fmt.Errorf("API error (status %d)", status))
+	default:
+		c.breaker.Done(nil)
+	}
+}
+
+// buildPath appends URL-encoded query parameters to the API path
+func buildPath(path string, params url.Values) string {
+	if len(params) == 0 {
+		return path
+	}
+	return path + "?" + params.Encode()
 }
 
 func (c *Client) GetHealth(ctx context.Context) (map[string]any, error) {
@@ -94,7 +319,7 @@ func (c *Client) GetHealth(ctx context.Context) (map[string]any, error) {
 
 // ListModels returns a list of available anomaly detection model types
 func (c *Client) ListModels(ctx context.Context) (*ModelsListResponse, error) {
-	respBody, err := c.doRequest(ctx, http.MethodGet, "/api/v1/models", nil)
+	respBody, err := c.doCachedRequest(ctx, "/api/v1/models", "/api/v1/models")
 	if err != nil {
 		return nil, err
 	}
@@ -123,8 +348,8 @@ func (c *Client) GetServerModels(ctx context.Context) (*ServerModelsResponse, er
 }
 
 func (c *Client) GetModelSchema(ctx context.Context, modelClass string) (map[string]any, error) {
-	path := fmt.Sprintf("/api/v1/model/schema?model_class=%s", modelClass)
-	respBody, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	path := buildPath("/api/v1/model/schema", url.Values{"model_class": {modelClass}})
+	respBody, err := c.doCachedRequest(ctx, "/api/v1/model/schema", path)
 	if err != nil {
 		return nil, err
 	}
@@ -153,21 +378,27 @@ func (c *Client) ValidateModel(ctx context.Context, modelSpec map[string]any) (*
 
 func (c *Client) GenerateConfig(ctx context.Context, req *ConfigGenerationRequest) (string, error) {
 	// Build query parameters
-	path := fmt.Sprintf("/api/vmanomaly/config.yaml?step=%s&query=%s&datasource_url=%s&fit_window=%s&fit_every=%s",
-		req.Step, req.Query, req.DatasourceURL, req.FitWindow, req.FitEvery)
-
+	params := url.Values{
+		"step":           {req.Step},
+		"query":          {req.Query},
+		"datasource_url": {req.DatasourceURL},
+		"fit_window":     {req.FitWindow},
+		"fit_every":      {req.FitEvery},
+	}
 	if req.TenantID != nil {
-		path += fmt.Sprintf("&tenant_id=%s", *req.TenantID)
+		params.Set("tenant_id", *req.TenantID)
 	}
 	if req.InferEvery != nil {
-		path += fmt.Sprintf("&infer_every=%s", *req.InferEvery)
+		params.Set("infer_every", *req.InferEvery)
 	}
 
 	modelSpecJSON, err := json.Marshal(req.ModelSpec)
 	if err != nil {
 		return "", fmt.Errorf("failed to encode model_spec: %w", err)
 	}
-	path += fmt.Sprintf("&model_spec=%s", string(modelSpecJSON))
+	params.Set("model_spec", string(modelSpecJSON))
+
+	path := buildPath("/api/vmanomaly/config.yaml", params)
 
 	respBody, err := c.doRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
@@ -196,7 +427,7 @@ func (c *Client) CreateDetectionTask(ctx context.Context, req *AnomalyDetectionT
 }
 
 func (c *Client) GetTaskStatus(ctx context.Context, taskID string) (*AnomalyDetectionTaskStatus, error) {
-	path := fmt.Sprintf("/api/v1/anomaly_detection/tasks/%s", taskID)
+	path := "/api/v1/anomaly_detection/tasks/" + url.PathEscape(taskID)
 	respBody, err := c.doRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, err
@@ -211,10 +442,11 @@ func (c *Client) GetTaskStatus(ctx context.Context, taskID string) (*AnomalyDete
 }
 
 func (c *Client) ListTasks(ctx context.Context, limit int, status *string) (*AnomalyDetectionTaskListResponse, error) {
-	path := fmt.Sprintf("/api/v1/anomaly_detection/tasks?limit=%d", limit)
+	params := url.Values{"limit": {strconv.Itoa(limit)}}
 	if status != nil {
-		path += fmt.Sprintf("&status=%s", *status)
+		params.Set("status", *status)
 	}
+	path := buildPath("/api/v1/anomaly_detection/tasks", params)
 
 	respBody, err := c.doRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
@@ -230,7 +462,7 @@ func (c *Client) ListTasks(ctx context.Context, limit int, status *string) (*Ano
 }
 
 func (c *Client) CancelTask(ctx context.Context, taskID string) (map[string]bool, error) {
-	path := fmt.Sprintf("/api/v1/anomaly_detection/tasks/%s", taskID)
+	path := "/api/v1/anomaly_detection/tasks/" + url.PathEscape(taskID)
 	respBody, err := c.doRequest(ctx, http.MethodDelete, path, nil)
 	if err != nil {
 		return nil, err
@@ -278,7 +510,25 @@ func (c *Client) Query(ctx context.Context, req *QueryRequest) (map[string]any,
 }
 
 func (c *Client) GetBuildInfo(ctx context.Context) (map[string]any, error) {
-	respBody, err := c.doRequest(ctx, http.MethodGet, "/api/v1/server/buildinfo", nil)
+	const path = "/api/v1/server/buildinfo"
+	var respBody []byte
+	var err error
+	if c.cache == nil {
+		respBody, err = c.doRequest(ctx, http.MethodGet, path, nil)
+	} else {
+		respBody, err = c.cache.get(ctx, path, path, func(ctx context.Context) ([]byte, error) {
+			body, err := c.doRequest(ctx, http.MethodGet, path, nil)
+			if err == nil {
+				var info struct {
+					Version string `json:"vmanomaly"`
+				}
+				if json.Unmarshal(body, &info) == nil {
+					c.cache.observeVersion(info.Version)
+				}
+			}
+			return body, err
+		})
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -334,10 +584,11 @@ func (c *Client) Metrics(ctx context.Context, config map[string]any) (string, er
 
 // Compatibility checks if persisted state is compatible with the runtime version
 func (c *Client) Compatibility(ctx context.Context, versionTo *string) (*CompatibilityCheckResponse, error) {
-	path := "/api/v1/compatibility"
+	params := url.Values{}
 	if versionTo != nil {
-		path += fmt.Sprintf("?version_to=%s", *versionTo)
+		params.Set("version_to", *versionTo)
 	}
+	path := buildPath("/api/v1/compatibility", params)
 
 	respBody, err := c.doRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
@@ -359,24 +610,26 @@ func (c *Client) Compatibility(ctx context.Context, versionTo *string) (*Compati
 // GenerateAlertRule generates a VMAlert rule configuration for anomaly detection
 func (c *Client) GenerateAlertRule(ctx context.Context, req *AlertRuleRequest) (string, error) {
 	// Build query parameters
-	path := fmt.Sprintf("/api/vmanomaly/example-alert-rule.yaml?step=%s&query=%s",
-		url.QueryEscape(req.Step), url.QueryEscape(req.Query))
-
+	params := url.Values{
+		"step":  {req.Step},
+		"query": {req.Query},
+	}
 	if req.AnomalyThreshold != nil {
-		path += fmt.Sprintf("&anomaly_threshold=%g", *req.AnomalyThreshold)
+		params.Set("anomaly_threshold", strconv.FormatFloat(*req.AnomalyThreshold, 'g', -1, 64))
 	}
 	if req.RuleName != nil {
-		path += fmt.Sprintf("&rule_name=%s", url.QueryEscape(*req.RuleName))
+		params.Set("rule_name", *req.RuleName)
 	}
 	if req.GroupName != nil {
-		path += fmt.Sprintf("&group_name=%s", url.QueryEscape(*req.GroupName))
+		params.Set("group_name", *req.GroupName)
 	}
 	if req.RuleDescription != nil {
-		path += fmt.Sprintf("&rule_description=%s", url.QueryEscape(*req.RuleDescription))
+		params.Set("rule_description", *req.RuleDescription)
 	}
 	if req.InferEvery != nil {
-		path += fmt.Sprintf("&infer_every=%s", url.QueryEscape(*req.InferEvery))
+		params.Set("infer_every", *req.InferEvery)
 	}
+	path := buildPath("/api/vmanomaly/example-alert-rule.yaml", params)
 
 	respBody, err := c.doRequest(ctx, http.MethodGet, path, nil)
 	if err != nil {
@@ -1,12 +1,19 @@
 package vmanomaly
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/tracing"
+
+	"github.com/VictoriaMetrics/metrics"
 )
 
 func newTestServer(t *testing.T, handler http.HandlerFunc) (*Client, *httptest.Server) {
@@ -437,6 +444,20 @@ func TestClient_GenerateConfig(t *testing.T) {
 			response:   "schedulers:\n  model: prophet\n",
 			wantErr:    false,
 		},
+		{
+			name: "special characters are escaped",
+			request: &ConfigGenerationRequest{
+				Step:          "1m",
+				Query:         `sum(rate(http_requests_total{path="/a&b=c",code=~"5.."}[5m])) by (path)`,
+				DatasourceURL: "http://localhost:8428/select/0/prometheus?extra_filters[]={env=\"prod\"}",
+				FitWindow:     "1d",
+				FitEvery:      "1d",
+				ModelSpec:     map[string]any{"class": "zscore", "z_threshold": 2.5, "provide_series": []any{"anomaly_score", "yhat"}},
+			},
+			statusCode: 200,
+			response:   "schedulers:\n  model: zscore\n",
+			wantErr:    false,
+		},
 		{
 			name: "500 error",
 			request: &ConfigGenerationRequest{
@@ -471,6 +492,12 @@ func TestClient_GenerateConfig(t *testing.T) {
 					assertEqual(t, query.Get("infer_every"), *tt.request.InferEvery)
 				}
 
+				var modelSpec map[string]any
+				if err := json.Unmarshal([]byte(query.Get("model_spec")), &modelSpec); err != nil {
+					t.Errorf("failed to decode model_spec: %v", err)
+				}
+				assertEqual(t, fmt.Sprint(modelSpec), fmt.Sprint(tt.request.ModelSpec))
+
 				w.WriteHeader(tt.statusCode)
 				_, _ = w.Write([]byte(tt.response))
 			})
@@ -590,6 +617,14 @@ func TestClient_GetTaskStatus(t *testing.T) {
 			wantErr:      false,
 			wantProgress: 100,
 		},
+		{
+			name:         "task id with reserved characters",
+			taskID:       "task/1?x=y",
+			statusCode:   200,
+			response:     `{"task_id":"task/1?x=y","status":"running","progress":10,"message":"Processing","updated_at":"2025-01-01T00:00:00Z","metrics":{}}`,
+			wantErr:      false,
+			wantProgress: 10,
+		},
 		{
 			name:       "404 not found",
 			taskID:     "unknown",
@@ -1143,3 +1178,60 @@ func TestClient_Compatibility(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_TraceContext(t *testing.T) {
+	var got string
+	client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	})
+	defer server.Close()
+
+	// Requests outside of traced operations don't carry trace context
+	if _, err := client.GetHealth(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, got, "")
+
+	parent := tracing.SpanContext{TraceID: [16]byte{1}, SpanID: [8]byte{2}, Sampled: true}
+	ctx := tracing.ContextWithRemoteSpanContext(context.Background(), parent)
+	if _, err := client.GetHealth(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, got, "00-01000000000000000000000000000000-0200000000000000-01")
+}
+
+func TestClient_RequestMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/anomaly_detection/tasks/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	ms := metrics.NewSet()
+	client := NewClient(server.URL, "", nil, WithMetricsSet(ms), WithInstanceName("prod"))
+
+	if _, err := client.GetHealth(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetTaskStatus(context.Background(), "missing"); err == nil {
+		t.Fatalf("expected error")
+	}
+	server.Close()
+	if _, err := client.GetHealth(context.Background()); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	var buf bytes.Buffer
+	ms.WritePrometheus(&buf)
+	for _, want := range []string{
+		`mcp_vmanomaly_upstream_request_duration_seconds_count{instance="prod",method="GET",endpoint="/health",status="200"} 1`,
+		`mcp_vmanomaly_upstream_request_duration_seconds_count{instance="prod",method="GET",endpoint="/api/v1/anomaly_detection/tasks/{task_id}",status="404"} 1`,
+		`mcp_vmanomaly_upstream_request_duration_seconds_count{instance="prod",method="GET",endpoint="/health",status="error"} 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in metrics:\n%s", want, buf.String())
+		}
+	}
+}
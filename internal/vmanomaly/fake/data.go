@@ -0,0 +1,372 @@
+package fake
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Models
+// ============================================================================
+
+// modelParam describes a model parameter in the fake schema
+type modelParam struct {
+	Type        string // JSON schema type
+	Default     any    // Default value, nil if parameter is required
+	Description string
+}
+
+// commonModelParams are accepted by every model
+var commonModelParams = map[string]modelParam{
+	"queries":        {Type: "array", Default: []any{}, Description: "Query aliases the model is attached to"},
+	"schedulers":     {Type: "array", Default: []any{}, Description: "Scheduler aliases the model is attached to"},
+	"provide_series": {Type: "array", Default: []any{"anomaly_score"}, Description: "Output series to produce"},
+	"detection_direction": {
+		Type:        "string",
+		Default:     "both",
+		Description: "Direction of anomalies to detect: both, above_expected or below_expected",
+	},
+	"min_dev_from_expected": {Type: "number", Default: 0.0, Description: "Minimum deviation from expected value to count as anomaly"},
+}
+
+// modelParams lists model-specific parameters by model class
+var modelParams = map[string]map[string]modelParam{
+	"zscore": {
+		"z_threshold": {Type: "number", Default: 2.5, Description: "Z-score threshold"},
+	},
+	"zscore_online": {
+		"z_threshold": {Type: "number", Default: 2.5, Description: "Z-score threshold"},
+	},
+	"mad": {
+		"threshold": {Type: "number", Default: 2.5, Description: "MAD threshold"},
+	},
+	"mad_online": {
+		"threshold": {Type: "number", Default: 2.5, Description: "MAD threshold"},
+	},
+	"std": {
+		"period":          {Type: "integer", Default: 1, Description: "Number of seasonal periods"},
+		"z_threshold":     {Type: "number", Default: 2.5, Description: "Z-score threshold"},
+		"seasonal_window": {Type: "string", Default: "1d", Description: "Seasonal window"},
+	},
+	"rolling_quantile": {
+		"quantile":     {Type: "number", Description: "Quantile to use, in [0, 1]"},
+		"window_steps": {Type: "integer", Description: "Rolling window size in steps"},
+	},
+	"quantile_online": {
+		"quantiles": {Type: "array", Default: []any{0.01, 0.5, 0.99}, Description: "Lower, median and upper quantiles"},
+	},
+	"holtwinters": {
+		"seasonality": {Type: "string", Default: "1d", Description: "Seasonality period"},
+		"z_threshold": {Type: "number", Default: 2.5, Description: "Z-score threshold"},
+	},
+	"prophet": {
+		"seasonalities": {Type: "array", Default: []any{}, Description: "Additional seasonalities"},
+		"tz_aware":      {Type: "boolean", Default: false, Description: "Whether to use timezone-aware seasonality"},
+	},
+	"isolation_forest_univariate": {
+		"contamination": {Type: "number", Default: 0.01, Description: "Expected share of anomalies"},
+		"seasonal_features": {
+			Type:        "array",
+			Default:     []any{},
+			Description: "Seasonal features to add, e.g. hour or dow",
+		},
+	},
+	"auto": {
+		"tuned_class_name": {Type: "string", Description: "Model class to tune"},
+		"optimization_params": {
+			Type:        "object",
+			Default:     map[string]any{},
+			Description: "Optimization parameters",
+		},
+	},
+}
+
+// modelClasses returns supported model classes in a stable order
+func modelClasses() []string {
+	classes := make([]string, 0, len(modelParams))
+	for class := range modelParams {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// modelParamsFor returns common and model-specific parameters of class
+func modelParamsFor(class string) (map[string]modelParam, bool) {
+	specific, ok := modelParams[class]
+	if !ok {
+		return nil, false
+	}
+	params := make(map[string]modelParam, len(commonModelParams)+len(specific))
+	for name, p := range commonModelParams {
+		params[name] = p
+	}
+	for name, p := range specific {
+		params[name] = p
+	}
+	return params, true
+}
+
+// modelSchema builds a JSON schema for class
+func modelSchema(class string) (map[string]any, bool) {
+	params, ok := modelParamsFor(class)
+	if !ok {
+		return nil, false
+	}
+
+	properties := map[string]any{
+		"class": map[string]any{"const": class, "type": "string"},
+	}
+	required := []string{"class"}
+	for name, p := range params {
+		prop := map[string]any{"type": p.Type, "description": p.Description}
+		if p.Default != nil {
+			prop["default"] = p.Default
+		} else {
+			required = append(required, name)
+		}
+		properties[name] = prop
+	}
+	sort.Strings(required)
+
+	return map[string]any{
+		"title":      modelTitle(class),
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}, true
+}
+
+func modelTitle(class string) string {
+	parts := strings.Split(class, "_")
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "") + "Model"
+}
+
+// validateModelSpec checks spec against the fake schema and returns it with defaults applied
+func validateModelSpec(spec map[string]any, loc ...any) (map[string]any, []validationError) {
+	class, _ := spec["class"].(string)
+	if class == "" {
+		return nil, []validationError{missingField(append(loc, "class")...)}
+	}
+	params, ok := modelParamsFor(class)
+	if !ok {
+		return nil, []validationError{{
+			Loc:  append(loc, "class"),
+			Msg:  fmt.Sprintf("Input should be one of: %s", strings.Join(modelClasses(), ", ")),
+			Type: "literal_error",
+		}}
+	}
+
+	var errs []validationError
+	validated := map[string]any{"class": class}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := params[name]
+		v, ok := spec[name]
+		if !ok {
+			if p.Default == nil {
+				errs = append(errs, missingField(append(loc, name)...))
+				continue
+			}
+			validated[name] = p.Default
+			continue
+		}
+		if !matchesType(v, p.Type) {
+			errs = append(errs, validationError{
+				Loc:  append(loc, name),
+				Msg:  fmt.Sprintf("Input should be a valid %s", p.Type),
+				Type: p.Type + "_type",
+			})
+			continue
+		}
+		validated[name] = v
+	}
+	extra := make([]string, 0, len(spec))
+	for name := range spec {
+		extra = append(extra, name)
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		if _, ok := params[name]; !ok && name != "class" {
+			errs = append(errs, validationError{
+				Loc:  append(loc, name),
+				Msg:  "Extra inputs are not permitted",
+				Type: "extra_forbidden",
+			})
+		}
+	}
+	return validated, errs
+}
+
+func matchesType(v any, typ string) bool {
+	switch typ {
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	default:
+		return true
+	}
+}
+
+// ============================================================================
+// Synthetic Series
+// ============================================================================
+
+// seriesPerQuery is the number of series returned for every query
+const seriesPerQuery = 2
+
+// syntheticLabels returns labels of the i-th series of query
+func syntheticLabels(query string, i int) map[string]string {
+	return map[string]string{
+		"__name__": metricName(query),
+		"instance": fmt.Sprintf("fake-%d:9100", i),
+		"job":      "fake",
+	}
+}
+
+// metricName extracts a metric name from the query for nicer labels
+func metricName(query string) string {
+	name := strings.TrimSpace(query)
+	if i := strings.LastIndexAny(name, "("); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.IndexAny(name, "{[) "); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		return "fake_metric"
+	}
+	return name
+}
+
+// wave returns parameters of the daily wave of the i-th series of query
+func wave(query string, i int) (seed uint64, base, amplitude, phase float64) {
+	seed = hash(fmt.Sprintf("%s/%d", query, i))
+	base = 50 + float64(seed%50)
+	amplitude = 10 + float64(seed%7)
+	phase = float64(seed%360) * math.Pi / 180
+	return seed, base, amplitude, phase
+}
+
+// syntheticValue returns a deterministic value of the i-th series of query at ts.
+// Series follow a daily sine wave with noise, and have a 5 minute spike once every
+// 6 hours so that detection tasks always find anomalies.
+func syntheticValue(query string, i int, ts float64) float64 {
+	seed, _, amplitude, _ := wave(query, i)
+
+	v := expectedValue(query, i, ts)
+	v += noise(seed, ts) * amplitude / 10
+
+	spikeEvery := 6 * 3600.0
+	offset := float64(seed%3600) + float64(i)*900
+	if pos := math.Mod(ts-offset, spikeEvery); pos >= 0 && pos < 300 {
+		v += amplitude * 3
+	}
+	return v
+}
+
+// expectedValue is what a model would predict for the i-th series: the wave without noise and spikes
+func expectedValue(query string, i int, ts float64) float64 {
+	_, base, amplitude, phase := wave(query, i)
+	return base + amplitude*math.Sin(2*math.Pi*ts/86400+phase)
+}
+
+// expectedBand returns the half-width of the expected interval of the i-th series
+func expectedBand(query string, i int) float64 {
+	_, _, amplitude, _ := wave(query, i)
+	return amplitude / 2
+}
+
+// noise returns deterministic pseudo-random noise in [-1, 1)
+func noise(seed uint64, ts float64) float64 {
+	h := hash(strconv.FormatUint(seed, 10) + "@" + strconv.FormatFloat(ts, 'f', -1, 64))
+	return float64(h%2000)/1000 - 1
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// timestamps returns aligned timestamps in [start, end] with step
+func timestamps(start, end float64, step time.Duration) []float64 {
+	stepS := step.Seconds()
+	if stepS <= 0 || end < start {
+		return nil
+	}
+	first := math.Ceil(start/stepS) * stepS
+	var ts []float64
+	for t := first; t <= end; t += stepS {
+		ts = append(ts, t)
+	}
+	return ts
+}
+
+// matrix builds a Prometheus-style matrix result for query
+func matrix(query string, ts []float64, value func(i int, t float64) float64, name string) []any {
+	result := make([]any, 0, seriesPerQuery)
+	for i := 0; i < seriesPerQuery; i++ {
+		labels := syntheticLabels(query, i)
+		if name != "" {
+			labels["__name__"] = name
+		}
+		values := make([]any, 0, len(ts))
+		for _, t := range ts {
+			values = append(values, []any{t, strconv.FormatFloat(value(i, t), 'f', -1, 64)})
+		}
+		metric := make(map[string]any, len(labels))
+		for k, v := range labels {
+			metric[k] = v
+		}
+		result = append(result, map[string]any{"metric": metric, "values": values})
+	}
+	return result
+}
+
+// parseDuration parses Prometheus-style durations such as 30s, 5m, 1h, 1d or 1w
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	unit, ok := units[s[len(s)-1]]
+	if !ok {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return time.Duration(n * float64(unit)), nil
+}
@@ -0,0 +1,671 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultRange is the time range used when a request has no start or end
+	defaultRange = time.Hour
+	// maxPointsPerSeries limits the size of query and task results
+	maxPointsPerSeries = 11000
+	// errorQueryMarker makes detection tasks for queries containing it end with an error
+	errorQueryMarker = "fake_error"
+)
+
+// ============================================================================
+// Health and Info
+// ============================================================================
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func (s *Server) handleBuildInfo(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"vmanomaly": Version, "vmui": Version})
+}
+
+func (s *Server) handleServerQueries(w http.ResponseWriter, _ *http.Request) {
+	queries := make(map[string]string, len(serverQueries))
+	for _, q := range serverQueries {
+		queries[q.alias] = q.expr
+	}
+	writeJSON(w, http.StatusOK, queries)
+}
+
+func (s *Server) handleServerModels(w http.ResponseWriter, _ *http.Request) {
+	queries := make(map[string]any, len(serverQueries))
+	for _, q := range serverQueries {
+		queries[q.alias] = map[string]any{"expr": q.expr, "tz": "UTC", "offset": "0s", "step": q.step}
+	}
+	config, _ := validateModelSpec(map[string]any{"class": "zscore"})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"models": map[string]any{
+			"fake_zscore": map[string]any{
+				"model_configuration": config,
+				"queries":             queries,
+				"is_online":           false,
+				"is_multivariate":     false,
+				"is_ui_selectable":    true,
+			},
+		},
+	})
+}
+
+// serverQueries are reader queries of the fake server configuration, sorted by alias
+var serverQueries = []struct{ alias, expr, step string }{
+	{alias: "fake_cpu", expr: `sum(rate(node_cpu_seconds_total{mode!="idle"}[5m])) by (instance)`, step: "1m"},
+	{alias: "fake_memory", expr: `node_memory_MemAvailable_bytes / node_memory_MemTotal_bytes`, step: "1m"},
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	total, running := len(s.tasks), s.runningTasks()
+	s.mu.Unlock()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "vmanomaly_version_info{version=%q} 1\n", Version)
+	fmt.Fprintf(&sb, "vmanomaly_ui_tasks_total %d\n", total)
+	fmt.Fprintf(&sb, "vmanomaly_ui_tasks_running %d\n", running)
+	for _, q := range serverQueries {
+		fmt.Fprintf(&sb, "vmanomaly_reader_responses_total{query_key=%q,code=\"200\"} 1\n", q.alias)
+	}
+	writeText(w, "text/plain; version=0.0.4", sb.String())
+}
+
+func (s *Server) handleCompatibility(w http.ResponseWriter, r *http.Request) {
+	runtimeVersion := r.URL.Query().Get("version_to")
+	if runtimeVersion == "" {
+		runtimeVersion = Version
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"runtime_version": runtimeVersion,
+		"global_check": map[string]any{
+			"has_state":       false,
+			"is_compatible":   true,
+			"drop_everything": false,
+		},
+	})
+}
+
+// ============================================================================
+// Models and Configuration
+// ============================================================================
+
+func (s *Server) handleListModels(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"models": modelClasses()})
+}
+
+func (s *Server) handleModelSchema(w http.ResponseWriter, r *http.Request) {
+	class := r.URL.Query().Get("model_class")
+	if class == "" {
+		writeValidationErrors(w, []validationError{missingField("query", "model_class")})
+		return
+	}
+	schema, ok := modelSchema(class)
+	if !ok {
+		writeValidationErrors(w, []validationError{{
+			Loc:  []any{"query", "model_class"},
+			Msg:  fmt.Sprintf("Input should be one of: %s", strings.Join(modelClasses(), ", ")),
+			Type: "enum",
+		}})
+		return
+	}
+	writeJSON(w, http.StatusOK, schema)
+}
+
+func (s *Server) handleValidateModel(w http.ResponseWriter, r *http.Request) {
+	var spec map[string]any
+	if !decodeBody(w, r, &spec) {
+		return
+	}
+	validated, errs := validateModelSpec(spec, "body")
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"valid": true, "model_spec": validated})
+}
+
+func (s *Server) handleValidateConfig(w http.ResponseWriter, r *http.Request) {
+	var config map[string]any
+	if !decodeBody(w, r, &config) {
+		return
+	}
+
+	var errs []validationError
+	for _, section := range []string{"models", "reader", "schedulers", "writer"} {
+		if _, ok := config[section].(map[string]any); !ok {
+			errs = append(errs, missingField("body", section))
+		}
+	}
+	if models, ok := config["models"].(map[string]any); ok {
+		aliases := make([]string, 0, len(models))
+		for alias := range models {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		for _, alias := range aliases {
+			spec, _ := models[alias].(map[string]any)
+			validated, modelErrs := validateModelSpec(spec, "body", "models", alias)
+			errs = append(errs, modelErrs...)
+			if len(modelErrs) == 0 {
+				models[alias] = validated
+			}
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"is_valid": true, "validated": config})
+}
+
+func (s *Server) handleGenerateConfig(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var errs []validationError
+	for _, name := range []string{"step", "query", "datasource_url", "model_spec"} {
+		if q.Get(name) == "" {
+			errs = append(errs, missingField("query", name))
+		}
+	}
+	var spec map[string]any
+	if raw := q.Get("model_spec"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+			errs = append(errs, validationError{Loc: []any{"query", "model_spec"}, Msg: "Invalid JSON", Type: "json_invalid"})
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+	validated, errs := validateModelSpec(spec, "query", "model_spec")
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	fitWindow, fitEvery := q.Get("fit_window"), q.Get("fit_every")
+	if fitWindow == "" {
+		fitWindow = "1d"
+	}
+	if fitEvery == "" {
+		fitEvery = "1d"
+	}
+	inferEvery := q.Get("infer_every")
+	if inferEvery == "" {
+		inferEvery = q.Get("step")
+	}
+
+	reader := map[string]any{
+		"class":           "vm",
+		"datasource_url":  q.Get("datasource_url"),
+		"sampling_period": q.Get("step"),
+		"queries": map[string]any{
+			"q1": map[string]any{"expr": q.Get("query")},
+		},
+	}
+	writer := map[string]any{
+		"class":          "vm",
+		"datasource_url": q.Get("datasource_url"),
+	}
+	if tenantID := q.Get("tenant_id"); tenantID != "" {
+		reader["tenant_id"] = tenantID
+		writer["tenant_id"] = tenantID
+	}
+	validated["queries"] = []any{"q1"}
+	validated["schedulers"] = []any{"s1"}
+
+	config := map[string]any{
+		"reader": reader,
+		"schedulers": map[string]any{
+			"s1": map[string]any{
+				"class":       "periodic",
+				"fit_window":  fitWindow,
+				"fit_every":   fitEvery,
+				"infer_every": inferEvery,
+			},
+		},
+		"models": map[string]any{"m1": validated},
+		"writer": writer,
+	}
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		writeDetail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeText(w, "application/yaml", string(out))
+}
+
+func (s *Server) handleGenerateAlertRule(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var errs []validationError
+	for _, name := range []string{"step", "query"} {
+		if q.Get(name) == "" {
+			errs = append(errs, missingField("query", name))
+		}
+	}
+	threshold := 1.0
+	if v := q.Get("anomaly_threshold"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			errs = append(errs, validationError{Loc: []any{"query", "anomaly_threshold"}, Msg: "Input should be a valid number", Type: "float_parsing"})
+		}
+		threshold = f
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	groupName := valueOr(q.Get("group_name"), "VMAnomalyAlerts")
+	ruleName := valueOr(q.Get("rule_name"), "AnomalyScoreHigh")
+	description := valueOr(q.Get("rule_description"), fmt.Sprintf("Anomaly score is above %g for query %s", threshold, q.Get("query")))
+	evalInterval := valueOr(q.Get("infer_every"), q.Get("step"))
+
+	config := map[string]any{
+		"groups": []any{
+			map[string]any{
+				"name":     groupName,
+				"interval": evalInterval,
+				"rules": []any{
+					map[string]any{
+						"alert": ruleName,
+						"expr":  fmt.Sprintf("anomaly_score > %g", threshold),
+						"for":   evalInterval,
+						"labels": map[string]any{
+							"severity": "warning",
+						},
+						"annotations": map[string]any{
+							"summary":     description,
+							"description": "Anomaly score is {{ $value }} for {{ $labels.for }}",
+						},
+					},
+				},
+			},
+		},
+	}
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		writeDetail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeText(w, "application/yaml", string(out))
+}
+
+// ============================================================================
+// Query
+// ============================================================================
+
+// queryRequest mirrors vmanomaly.QueryRequest
+type queryRequest struct {
+	Query string   `json:"query"`
+	Start *float64 `json:"start"`
+	End   *float64 `json:"end"`
+	Step  string   `json:"step"`
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req queryRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Query == "" {
+		writeValidationErrors(w, []validationError{missingField("body", "query")})
+		return
+	}
+
+	start, end, step, errs := s.timeRange(req.Start, req.End, req.Step, "body", "start", "end")
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	ts := timestamps(start, end, step)
+	if len(ts) > maxPointsPerSeries {
+		writeDetail(w, http.StatusBadRequest, fmt.Sprintf("too many points per series: %d, maximum is %d; increase step or reduce the time range", len(ts), maxPointsPerSeries))
+		return
+	}
+	result := matrix(req.Query, ts, func(i int, t float64) float64 {
+		return syntheticValue(req.Query, i, t)
+	}, "")
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "success",
+		"data": map[string]any{
+			"resultType": "matrix",
+			"result":     result,
+		},
+	})
+}
+
+// timeRange validates step and applies the default time range ending now
+func (s *Server) timeRange(start, end *float64, stepStr string, loc, startName, endName string) (float64, float64, time.Duration, []validationError) {
+	var errs []validationError
+	step, err := parseDuration(valueOr(stepStr, "1s"))
+	if err != nil {
+		errs = append(errs, validationError{Loc: []any{loc, "step"}, Msg: err.Error(), Type: "value_error"})
+	}
+
+	e := float64(s.now().Unix())
+	if end != nil {
+		e = *end
+	}
+	b := e - defaultRange.Seconds()
+	if start != nil {
+		b = *start
+	}
+	if b >= e {
+		errs = append(errs, validationError{Loc: []any{loc, endName}, Msg: fmt.Sprintf("%s must be greater than %s", endName, startName), Type: "value_error"})
+	}
+	return b, e, step, errs
+}
+
+// ============================================================================
+// Anomaly Detection Tasks
+// ============================================================================
+
+// taskRequest mirrors vmanomaly.AnomalyDetectionTaskRequest
+type taskRequest struct {
+	Query            string         `json:"query"`
+	StartInferS      *float64       `json:"start_infer_s"`
+	EndInferS        *float64       `json:"end_infer_s"`
+	Step             string         `json:"step"`
+	FitWindow        string         `json:"fit_window"`
+	FitEvery         string         `json:"fit_every"`
+	AnomalyThreshold float64        `json:"anomaly_threshold"`
+	ModelSpec        map[string]any `json:"model_spec"`
+}
+
+type task struct {
+	id         string
+	req        taskRequest
+	start, end float64
+	step       time.Duration
+	createdAt  time.Time
+	canceledAt *time.Time
+	result     map[string]any
+}
+
+// taskState returns task status and progress at the current time. Must be called with s.mu held.
+func (s *Server) taskState(t *task) (string, int) {
+	now := s.now()
+	if t.canceledAt != nil {
+		now = *t.canceledAt
+	}
+
+	progress := 100
+	if s.taskDuration > 0 {
+		progress = int(100 * now.Sub(t.createdAt) / s.taskDuration)
+	}
+	switch {
+	case t.canceledAt != nil:
+		return "canceled", min(progress, 99)
+	case progress < 100:
+		return "running", max(progress, 0)
+	case strings.Contains(t.req.Query, errorQueryMarker):
+		return "error", 100
+	default:
+		return "done", 100
+	}
+}
+
+func taskMessage(status string, progress int) string {
+	switch status {
+	case "running":
+		if progress < 30 {
+			return "Fitting model"
+		}
+		return "Running inference"
+	case "done":
+		return "Completed"
+	case "canceled":
+		return "Canceled by user"
+	default:
+		return "Failed"
+	}
+}
+
+// taskStatus builds the task status response. Must be called with s.mu held.
+func (s *Server) taskStatus(t *task, withResult bool) map[string]any {
+	status, progress := s.taskState(t)
+	startedAt := t.createdAt.UTC().Format(time.RFC3339)
+	resp := map[string]any{
+		"task_id":    t.id,
+		"status":     status,
+		"progress":   progress,
+		"message":    taskMessage(status, progress),
+		"started_at": startedAt,
+		"updated_at": s.now().UTC().Format(time.RFC3339),
+		"metrics": map[string]any{
+			"series":            seriesPerQuery,
+			"points_per_series": len(timestamps(t.start, t.end, t.step)),
+		},
+	}
+	if status == "error" {
+		msg := fmt.Sprintf("query %q failed: simulated datasource error", t.req.Query)
+		resp["error"] = msg
+		if withResult {
+			resp["result_data"] = map[string]any{"status": "error", "error": msg}
+		}
+	}
+	if status == "done" && withResult {
+		if t.result == nil {
+			t.result = detectionResult(t)
+		}
+		resp["result_data"] = t.result
+	}
+	return resp
+}
+
+// detectionResult builds vmanomaly outputs for a finished task
+func detectionResult(t *task) map[string]any {
+	ts := timestamps(t.start, t.end, t.step)
+	query := t.req.Query
+
+	var result []any
+	outputs := map[string]func(i int, ts float64) float64{
+		"anomaly_score": func(i int, ts float64) float64 {
+			score := math.Abs(syntheticValue(query, i, ts)-expectedValue(query, i, ts)) / expectedBand(query, i)
+			return math.Round(score*1000) / 1000
+		},
+		"y": func(i int, ts float64) float64 { return syntheticValue(query, i, ts) },
+		"yhat": func(i int, ts float64) float64 {
+			return expectedValue(query, i, ts)
+		},
+		"yhat_lower": func(i int, ts float64) float64 {
+			return expectedValue(query, i, ts) - expectedBand(query, i)
+		},
+		"yhat_upper": func(i int, ts float64) float64 {
+			return expectedValue(query, i, ts) + expectedBand(query, i)
+		},
+	}
+	for _, name := range []string{"anomaly_score", "y", "yhat", "yhat_lower", "yhat_upper"} {
+		result = append(result, matrix(query, ts, outputs[name], name)...)
+	}
+
+	return map[string]any{
+		"status": "success",
+		"data": map[string]any{
+			"resultType": "matrix",
+			"result":     result,
+		},
+		"stats": map[string]any{
+			"series": seriesPerQuery,
+			"points": len(ts) * seriesPerQuery,
+		},
+	}
+}
+
+// runningTasks returns the number of running tasks. Must be called with s.mu held.
+func (s *Server) runningTasks() int {
+	var running int
+	for _, t := range s.tasks {
+		if status, _ := s.taskState(t); status == "running" {
+			running++
+		}
+	}
+	return running
+}
+
+func (s *Server) handleLimits(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	running := s.runningTasks()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"max_concurrent": s.maxConcurrent,
+		"running":        running,
+		"available":      max(s.maxConcurrent-running, 0),
+	})
+}
+
+func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
+	var req taskRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	var errs []validationError
+	if req.Query == "" {
+		errs = append(errs, missingField("body", "query"))
+	}
+	start, end, step, rangeErrs := s.timeRange(req.StartInferS, req.EndInferS, req.Step, "body", "start_infer_s", "end_infer_s")
+	errs = append(errs, rangeErrs...)
+	for _, d := range []struct{ name, value string }{{"fit_window", req.FitWindow}, {"fit_every", req.FitEvery}} {
+		if d.value == "" {
+			continue
+		}
+		if _, err := parseDuration(d.value); err != nil {
+			errs = append(errs, validationError{Loc: []any{"body", d.name}, Msg: err.Error(), Type: "value_error"})
+		}
+	}
+	if req.ModelSpec == nil {
+		req.ModelSpec = map[string]any{"class": "zscore"}
+	}
+	if _, modelErrs := validateModelSpec(req.ModelSpec, "body", "model_spec"); len(modelErrs) > 0 {
+		errs = append(errs, modelErrs...)
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+	if n := len(timestamps(start, end, step)); n > maxPointsPerSeries {
+		writeDetail(w, http.StatusBadRequest, fmt.Sprintf("too many points per series: %d, maximum is %d; increase step or reduce the time range", n, maxPointsPerSeries))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if running := s.runningTasks(); running >= s.maxConcurrent {
+		writeDetail(w, http.StatusTooManyRequests, fmt.Sprintf("too many running tasks: %d of %d", running, s.maxConcurrent))
+		return
+	}
+
+	s.nextID++
+	t := &task{
+		id:        fmt.Sprintf("fake-task-%d", s.nextID),
+		req:       req,
+		start:     start,
+		end:       end,
+		step:      step,
+		createdAt: s.now(),
+	}
+	s.tasks[t.id] = t
+	s.order = append(s.order, t.id)
+
+	status, _ := s.taskState(t)
+	writeJSON(w, http.StatusOK, map[string]any{"task_id": t.id, "status": status})
+}
+
+func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[r.PathValue("id")]
+	if !ok {
+		writeDetail(w, http.StatusNotFound, "Task not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.taskStatus(t, true))
+}
+
+func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeValidationErrors(w, []validationError{{Loc: []any{"query", "limit"}, Msg: "Input should be greater than or equal to 1", Type: "greater_than_equal"}})
+			return
+		}
+		limit = n
+	}
+	statusFilter := q.Get("status")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := []any{}
+	for i := len(s.order) - 1; i >= 0 && len(tasks) < limit; i-- {
+		t := s.tasks[s.order[i]]
+		item := s.taskStatus(t, false)
+		if statusFilter != "" && item["status"] != statusFilter {
+			continue
+		}
+		delete(item, "error")
+		tasks = append(tasks, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tasks": tasks})
+}
+
+func (s *Server) handleCancelTask(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[r.PathValue("id")]
+	if !ok {
+		writeDetail(w, http.StatusNotFound, "Task not found")
+		return
+	}
+	status, _ := s.taskState(t)
+	if status != "running" {
+		writeJSON(w, http.StatusOK, map[string]bool{"canceled": false})
+		return
+	}
+	now := s.now()
+	t.canceledAt = &now
+	writeJSON(w, http.StatusOK, map[string]bool{"canceled": true})
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// decodeBody decodes JSON request body into v and writes a 422 response on failure
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeValidationErrors(w, []validationError{{Loc: []any{"body"}, Msg: fmt.Sprintf("JSON decode error: %v", err), Type: "json_invalid"}})
+		return false
+	}
+	return true
+}
+
+func valueOr(v, defaultValue string) string {
+	if v == "" {
+		return defaultValue
+	}
+	return v
+}
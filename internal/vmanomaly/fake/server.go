@@ -0,0 +1,269 @@
+// Package fake provides an in-process fake of the vmanomaly HTTP API.
+//
+// Server implements http.Handler, so it can be started with httptest.NewServer
+// in tests or served on a regular listener for offline demos:
+//
+//	srv := httptest.NewServer(fake.New())
+//	defer srv.Close()
+//	client := vmanomaly.NewClient(srv.URL, "", nil)
+//
+// All responses are deterministic: series values depend only on the query and
+// timestamps, tasks progress linearly over the configured task duration.
+package fake
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Version is reported by the fake build info endpoint
+	Version = "v1.28.0-fake"
+
+	defaultTaskDuration  = 3 * time.Second
+	defaultMaxConcurrent = 2
+)
+
+// Failure describes an injected error response
+type Failure struct {
+	Status     int           // HTTP status code to respond with (default: 500)
+	Body       string        // Response body (default: FastAPI-style {"detail": ...})
+	RetryAfter string        // Optional Retry-After header value
+	Delay      time.Duration // Delay before responding, honors request cancellation
+	Times      int           // Number of requests to fail; 0 means until cleared
+}
+
+// Option configures Server
+type Option func(*Server)
+
+// WithTaskDuration sets how long simulated detection tasks run before they are done
+func WithTaskDuration(d time.Duration) Option {
+	return func(s *Server) {
+		s.taskDuration = d
+	}
+}
+
+// WithMaxConcurrent sets the maximum number of concurrently running tasks
+func WithMaxConcurrent(n int) Option {
+	return func(s *Server) {
+		s.maxConcurrent = n
+	}
+}
+
+// WithClock sets the time source used for task progress and default time ranges
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// Server is a fake vmanomaly server
+type Server struct {
+	taskDuration  time.Duration
+	maxConcurrent int
+	now           func() time.Time
+
+	mux *http.ServeMux
+
+	mu       sync.Mutex
+	tasks    map[string]*task
+	order    []string
+	nextID   int
+	failures map[string]*Failure
+	requests map[string]int
+}
+
+// New returns a fake vmanomaly server
+func New(opts ...Option) *Server {
+	s := &Server{
+		taskDuration:  defaultTaskDuration,
+		maxConcurrent: defaultMaxConcurrent,
+		now:           time.Now,
+		tasks:         make(map[string]*task),
+		failures:      make(map[string]*Failure),
+		requests:      make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /api/v1/models", s.handleListModels)
+	mux.HandleFunc("GET /api/v1/server/models", s.handleServerModels)
+	mux.HandleFunc("GET /api/v1/server/queries", s.handleServerQueries)
+	mux.HandleFunc("GET /api/v1/server/buildinfo", s.handleBuildInfo)
+	mux.HandleFunc("GET /api/v1/model/schema", s.handleModelSchema)
+	mux.HandleFunc("POST /api/v1/model/validate", s.handleValidateModel)
+	mux.HandleFunc("POST /api/v1/config/validate", s.handleValidateConfig)
+	mux.HandleFunc("GET /api/vmanomaly/config.yaml", s.handleGenerateConfig)
+	mux.HandleFunc("GET /api/vmanomaly/example-alert-rule.yaml", s.handleGenerateAlertRule)
+	mux.HandleFunc("GET /api/v1/compatibility", s.handleCompatibility)
+	mux.HandleFunc("POST /api/v1/query", s.handleQuery)
+	mux.HandleFunc("GET /api/v1/anomaly_detection/limits", s.handleLimits)
+	mux.HandleFunc("POST /api/v1/anomaly_detection/tasks", s.handleCreateTask)
+	mux.HandleFunc("GET /api/v1/anomaly_detection/tasks", s.handleListTasks)
+	mux.HandleFunc("GET /api/v1/anomaly_detection/tasks/{id}", s.handleGetTask)
+	mux.HandleFunc("DELETE /api/v1/anomaly_detection/tasks/{id}", s.handleCancelTask)
+	s.mux = mux
+
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[requestKey(r.Method, r.URL.Path)]++
+	failure := s.takeFailure(r.Method, r.URL.Path)
+	s.mu.Unlock()
+
+	if failure != nil {
+		s.writeFailure(w, r, failure)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Fail injects a failure for requests matching method and path.
+// An empty method matches any method, a path ending with "/" matches by prefix.
+func (s *Server) Fail(method, path string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[requestKey(method, path)] = &f
+}
+
+// ClearFailures removes all injected failures
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.failures)
+}
+
+// Requests returns the number of requests received for method and path
+func (s *Server) Requests(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[requestKey(method, path)]
+}
+
+// takeFailure returns the injected failure matching the request. Must be called with s.mu held.
+// The most specific failure wins: exact paths over prefixes, longer prefixes over shorter ones,
+// and failures of the request method over ones matching any method.
+func (s *Server) takeFailure(method, path string) *Failure {
+	keys := make([]string, 0, len(s.failures))
+	for key := range s.failures {
+		m, p, _ := strings.Cut(key, " ")
+		if m != "" && m != method {
+			continue
+		}
+		if p != path && !(strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	key := slices.MaxFunc(keys, func(a, b string) int {
+		ma, pa, _ := strings.Cut(a, " ")
+		mb, pb, _ := strings.Cut(b, " ")
+		return cmp.Or(
+			compareBool(pa == path, pb == path),
+			cmp.Compare(len(pa), len(pb)),
+			compareBool(ma != "", mb != ""),
+		)
+	})
+
+	f := s.failures[key]
+	if f.Times > 0 {
+		f.Times--
+		if f.Times == 0 {
+			delete(s.failures, key)
+		}
+	}
+	copied := *f
+	return &copied
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+func (s *Server) writeFailure(w http.ResponseWriter, r *http.Request, f *Failure) {
+	if f.Delay > 0 {
+		select {
+		case <-time.After(f.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	status := f.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	if f.RetryAfter != "" {
+		w.Header().Set("Retry-After", f.RetryAfter)
+	}
+	if f.Body != "" {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(f.Body))
+		return
+	}
+	writeDetail(w, status, fmt.Sprintf("injected failure: %s", http.StatusText(status)))
+}
+
+func requestKey(method, path string) string {
+	return method + " " + path
+}
+
+// ============================================================================
+// Response Helpers
+// ============================================================================
+
+// validationError is a single FastAPI validation error item
+type validationError struct {
+	Loc  []any  `json:"loc"`
+	Msg  string `json:"msg"`
+	Type string `json:"type"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeText(w http.ResponseWriter, contentType, text string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(text))
+}
+
+// writeDetail writes a FastAPI-style error with a string detail
+func writeDetail(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]any{"detail": detail})
+}
+
+// writeValidationErrors writes a FastAPI-style 422 response
+func writeValidationErrors(w http.ResponseWriter, errs []validationError) {
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"detail": errs})
+}
+
+func missingField(loc ...any) validationError {
+	return validationError{Loc: loc, Msg: "Field required", Type: "missing"}
+}
@@ -0,0 +1,381 @@
+package fake_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly"
+	"github.com/VictoriaMetrics/mcp-vmanomaly/internal/vmanomaly/fake"
+)
+
+// clock is a manually advanced time source
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newFake(t *testing.T, opts ...fake.Option) (*fake.Server, *vmanomaly.Client) {
+	t.Helper()
+	f := fake.New(opts...)
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, vmanomaly.NewClient(srv.URL, "", nil)
+}
+
+func TestServer_Info(t *testing.T) {
+	_, client := newFake(t)
+	ctx := context.Background()
+
+	health, err := client.GetHealth(ctx)
+	if err != nil || health["status"] != "ok" {
+		t.Fatalf("GetHealth() = %v, %v", health, err)
+	}
+
+	buildInfo, err := client.GetBuildInfo(ctx)
+	if err != nil || buildInfo["vmanomaly"] != fake.Version {
+		t.Fatalf("GetBuildInfo() = %v, %v", buildInfo, err)
+	}
+
+	queries, err := client.GetServerQueries(ctx)
+	if err != nil || queries["fake_cpu"] == "" {
+		t.Fatalf("GetServerQueries() = %v, %v", queries, err)
+	}
+
+	serverModels, err := client.GetServerModels(ctx)
+	if err != nil || len(serverModels.Models["fake_zscore"].Queries) != len(queries) {
+		t.Fatalf("GetServerModels() = %v, %v", serverModels, err)
+	}
+
+	metrics, err := client.Metrics(ctx, nil)
+	if err != nil || !strings.Contains(metrics, "vmanomaly_ui_tasks_total 0") {
+		t.Fatalf("Metrics() = %q, %v", metrics, err)
+	}
+
+	compat, err := client.Compatibility(ctx, nil)
+	if err != nil || !compat.GlobalCheck.IsCompatible || compat.RuntimeVersion != fake.Version {
+		t.Fatalf("Compatibility() = %v, %v", compat, err)
+	}
+}
+
+func TestServer_Models(t *testing.T) {
+	_, client := newFake(t)
+	ctx := context.Background()
+
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		t.Fatalf("ListModels() error: %v", err)
+	}
+	if !slices.Contains(models.Models, "zscore") || !slices.Contains(models.Models, "prophet") {
+		t.Errorf("unexpected models: %v", models.Models)
+	}
+
+	schema, err := client.GetModelSchema(ctx, "zscore")
+	if err != nil {
+		t.Fatalf("GetModelSchema() error: %v", err)
+	}
+	if _, ok := schema["properties"].(map[string]any)["z_threshold"]; !ok {
+		t.Errorf("expected z_threshold in schema: %v", schema)
+	}
+
+	if _, err := client.GetModelSchema(ctx, "unknown"); err == nil || !strings.Contains(err.Error(), "422") {
+		t.Errorf("expected 422 error for unknown model, got %v", err)
+	}
+
+	validation, err := client.ValidateModel(ctx, map[string]any{"class": "zscore", "z_threshold": 3.0})
+	if err != nil || !validation.Valid || validation.ModelSpec["detection_direction"] != "both" {
+		t.Errorf("ValidateModel() = %v, %v", validation, err)
+	}
+
+	_, err = client.ValidateModel(ctx, map[string]any{"class": "zscore", "z_threshold": "high"})
+	if err == nil || !strings.Contains(err.Error(), "z_threshold") {
+		t.Errorf("expected validation error for z_threshold, got %v", err)
+	}
+}
+
+func TestServer_GenerateAndValidateConfig(t *testing.T) {
+	_, client := newFake(t)
+	ctx := context.Background()
+
+	out, err := client.GenerateConfig(ctx, &vmanomaly.ConfigGenerationRequest{
+		Step:          "1m",
+		Query:         `sum(rate(http_requests_total{path="/a&b"}[5m]))`,
+		DatasourceURL: "http://vm:8428",
+		FitWindow:     "2d",
+		FitEvery:      "1h",
+		ModelSpec:     map[string]any{"class": "zscore"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateConfig() error: %v", err)
+	}
+	if !strings.Contains(out, `path="/a&b"`) {
+		t.Errorf("expected query to be preserved in config:\n%s", out)
+	}
+
+	var config map[string]any
+	if err := yaml.Unmarshal([]byte(out), &config); err != nil {
+		t.Fatalf("generated config is not valid YAML: %v", err)
+	}
+	validation, err := client.ValidateConfig(ctx, config)
+	if err != nil || !validation.IsValid {
+		t.Fatalf("ValidateConfig() = %v, %v", validation, err)
+	}
+
+	delete(config, "reader")
+	if _, err := client.ValidateConfig(ctx, config); err == nil || !strings.Contains(err.Error(), "reader") {
+		t.Errorf("expected missing reader error, got %v", err)
+	}
+
+	rule, err := client.GenerateAlertRule(ctx, &vmanomaly.AlertRuleRequest{Step: "1m", Query: "up"})
+	if err != nil || !strings.Contains(rule, "anomaly_score > 1") {
+		t.Errorf("GenerateAlertRule() = %q, %v", rule, err)
+	}
+}
+
+func TestServer_Query(t *testing.T) {
+	_, client := newFake(t)
+	start, end := 1735689600.0, 1735693200.0
+
+	req := &vmanomaly.QueryRequest{Query: "node_load1", Start: &start, End: &end, Step: "1m", DatasourceType: "vm"}
+	data, err := client.Query(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	series, err := vmanomaly.ParseMatrix(data)
+	if err != nil {
+		t.Fatalf("ParseMatrix() error: %v", err)
+	}
+	if len(series) != 2 || len(series[0].Samples) != 61 {
+		t.Fatalf("unexpected series: %d series, %d samples", len(series), len(series[0].Samples))
+	}
+	if series[0].Labels["__name__"] != "node_load1" {
+		t.Errorf("unexpected labels: %v", series[0].Labels)
+	}
+
+	// Responses are deterministic
+	again, _ := client.Query(context.Background(), req)
+	againSeries, _ := vmanomaly.ParseMatrix(again)
+	if againSeries[1].Samples[10] != series[1].Samples[10] {
+		t.Errorf("expected deterministic values, got %v and %v", againSeries[1].Samples[10], series[1].Samples[10])
+	}
+}
+
+func TestServer_TaskLifecycle(t *testing.T) {
+	c := &clock{now: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)}
+	_, client := newFake(t, fake.WithClock(c.Now), fake.WithTaskDuration(10*time.Second), fake.WithMaxConcurrent(1))
+	ctx := context.Background()
+
+	start, end := 1735689600.0, 1735711200.0
+	task, err := client.CreateDetectionTask(ctx, &vmanomaly.AnomalyDetectionTaskRequest{
+		Query:            "node_load1",
+		StartInferS:      &start,
+		EndInferS:        &end,
+		Step:             "1m",
+		AnomalyThreshold: 1,
+		ModelSpec:        map[string]any{"class": "zscore"},
+	})
+	if err != nil {
+		t.Fatalf("CreateDetectionTask() error: %v", err)
+	}
+	if task.Status != "running" {
+		t.Errorf("expected running task, got %s", task.Status)
+	}
+
+	limits, err := client.GetDetectionLimits(ctx)
+	if err != nil || limits.Available != 0 || limits.Running != 1 {
+		t.Fatalf("GetDetectionLimits() = %+v, %v", limits, err)
+	}
+	if _, err := client.CreateDetectionTask(ctx, &vmanomaly.AnomalyDetectionTaskRequest{Query: "up", Step: "1m"}); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("expected 429 when no slots are available, got %v", err)
+	}
+
+	c.Advance(5 * time.Second)
+	status, err := client.GetTaskStatus(ctx, task.TaskID)
+	if err != nil || status.Status != "running" || status.Progress != 50 {
+		t.Fatalf("GetTaskStatus() = %+v, %v", status, err)
+	}
+
+	c.Advance(5 * time.Second)
+	status, err = client.GetTaskStatus(ctx, task.TaskID)
+	if err != nil || status.Status != "done" || status.ResultData == nil {
+		t.Fatalf("GetTaskStatus() = %+v, %v", status, err)
+	}
+
+	detections, err := vmanomaly.ParseDetectionResult(status.ResultData)
+	if err != nil {
+		t.Fatalf("ParseDetectionResult() error: %v", err)
+	}
+	summary := vmanomaly.SummarizeDetection(detections, 1, 0)
+	if summary.TotalSeries != 2 || summary.AnomalousSeries == 0 {
+		t.Errorf("expected anomalies in synthetic data, got %+v", summary)
+	}
+
+	list, err := client.ListTasks(ctx, 10, nil)
+	if err != nil || len(list.Tasks) != 1 || list.Tasks[0].Status != "done" {
+		t.Fatalf("ListTasks() = %+v, %v", list, err)
+	}
+
+	canceled, err := client.CancelTask(ctx, task.TaskID)
+	if err != nil || canceled["canceled"] {
+		t.Errorf("CancelTask() on finished task = %v, %v", canceled, err)
+	}
+
+	if _, err := client.GetTaskStatus(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected 404 for unknown task, got %v", err)
+	}
+}
+
+func TestServer_CancelAndErrorTasks(t *testing.T) {
+	c := &clock{now: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)}
+	_, client := newFake(t, fake.WithClock(c.Now), fake.WithTaskDuration(10*time.Second))
+	ctx := context.Background()
+
+	running, err := client.CreateDetectionTask(ctx, &vmanomaly.AnomalyDetectionTaskRequest{Query: "up", Step: "1m"})
+	if err != nil {
+		t.Fatalf("CreateDetectionTask() error: %v", err)
+	}
+	failing, err := client.CreateDetectionTask(ctx, &vmanomaly.AnomalyDetectionTaskRequest{Query: "fake_error", Step: "1m"})
+	if err != nil {
+		t.Fatalf("CreateDetectionTask() error: %v", err)
+	}
+
+	c.Advance(3 * time.Second)
+	canceled, err := client.CancelTask(ctx, running.TaskID)
+	if err != nil || !canceled["canceled"] {
+		t.Fatalf("CancelTask() = %v, %v", canceled, err)
+	}
+
+	c.Advance(10 * time.Second)
+	status, err := client.GetTaskStatus(ctx, running.TaskID)
+	if err != nil || status.Status != "canceled" || status.Progress != 30 {
+		t.Errorf("GetTaskStatus() = %+v, %v", status, err)
+	}
+
+	status, err = client.GetTaskStatus(ctx, failing.TaskID)
+	if err != nil || status.Status != "error" || status.Error == nil {
+		t.Errorf("GetTaskStatus() = %+v, %v", status, err)
+	}
+
+	statusFilter := "canceled"
+	list, err := client.ListTasks(ctx, 10, &statusFilter)
+	if err != nil || len(list.Tasks) != 1 || list.Tasks[0].TaskID != running.TaskID {
+		t.Errorf("ListTasks(canceled) = %+v, %v", list, err)
+	}
+}
+
+func TestServer_Failures(t *testing.T) {
+	f, client := newFake(t)
+	ctx := context.Background()
+
+	f.Fail(http.MethodGet, "/health", fake.Failure{Status: http.StatusServiceUnavailable, RetryAfter: "1", Times: 2})
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetHealth(ctx); err == nil || !strings.Contains(err.Error(), "503") {
+			t.Fatalf("expected injected 503, got %v", err)
+		}
+	}
+	if _, err := client.GetHealth(ctx); err != nil {
+		t.Fatalf("expected failure to expire, got %v", err)
+	}
+	if n := f.Requests(http.MethodGet, "/health"); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+
+	f.Fail("", "/api/v1/anomaly_detection/tasks/", fake.Failure{Status: http.StatusBadGateway, Body: "bad gateway"})
+	if _, err := client.GetTaskStatus(ctx, "any"); err == nil || !strings.Contains(err.Error(), "bad gateway") {
+		t.Errorf("expected injected prefix failure, got %v", err)
+	}
+	f.ClearFailures()
+	if _, err := client.GetTaskStatus(ctx, "any"); err == nil || !strings.Contains(err.Error(), "Task not found") {
+		t.Errorf("expected regular 404 after clearing failures, got %v", err)
+	}
+
+	// The most specific of overlapping failures wins regardless of registration order
+	f.Fail("", "/api/v1/", fake.Failure{Status: http.StatusBadGateway, Body: "any"})
+	f.Fail(http.MethodGet, "/api/v1/anomaly_detection/", fake.Failure{Status: http.StatusBadGateway, Body: "longer prefix"})
+	f.Fail("", "/api/v1/anomaly_detection/", fake.Failure{Status: http.StatusBadGateway, Body: "any method"})
+	f.Fail("", "/api/v1/anomaly_detection/limits", fake.Failure{Status: http.StatusBadGateway, Body: "exact"})
+	for i := 0; i < 10; i++ {
+		if _, err := client.GetDetectionLimits(ctx); err == nil || !strings.Contains(err.Error(), "exact") {
+			t.Fatalf("expected exact path failure, got %v", err)
+		}
+		if _, err := client.GetTaskStatus(ctx, "any"); err == nil || !strings.Contains(err.Error(), "longer prefix") {
+			t.Fatalf("expected longer prefix failure of the request method, got %v", err)
+		}
+		if _, err := client.ListModels(ctx); err == nil || !strings.Contains(err.Error(), "any") {
+			t.Fatalf("expected shortest prefix failure, got %v", err)
+		}
+	}
+	f.ClearFailures()
+
+	f.Fail(http.MethodGet, "/api/v1/models", fake.Failure{Delay: time.Second})
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.ListModels(ctx); err == nil {
+		t.Error("expected delayed request to time out")
+	}
+}
+
+func TestServer_DeterministicOrder(t *testing.T) {
+	_, client := newFake(t)
+	ctx := context.Background()
+
+	// Validation errors and metrics are listed in the same order on every request
+	var firstErr, firstMetrics string
+	for i := 0; i < 20; i++ {
+		_, err := client.CreateDetectionTask(ctx, &vmanomaly.AnomalyDetectionTaskRequest{
+			Query:     "up",
+			Step:      "1m",
+			FitWindow: "bad",
+			FitEvery:  "bad",
+			ModelSpec: map[string]any{"class": "zscore", "b_extra": 1.0, "a_extra": 1.0, "c_extra": 1.0},
+		})
+		if err == nil {
+			t.Fatal("expected validation error")
+		}
+		metrics, mErr := client.Metrics(ctx, nil)
+		if mErr != nil {
+			t.Fatalf("Metrics() error: %v", mErr)
+		}
+		if i == 0 {
+			firstErr, firstMetrics = err.Error(), metrics
+			continue
+		}
+		if err.Error() != firstErr {
+			t.Fatalf("validation errors order changed:\n%s\n%s", firstErr, err)
+		}
+		if metrics != firstMetrics {
+			t.Fatalf("metrics order changed:\n%s\n%s", firstMetrics, metrics)
+		}
+	}
+	for _, want := range []string{"fit_window: ", "fit_every: ", "model_spec.a_extra", "model_spec.b_extra", "model_spec.c_extra"} {
+		if !strings.Contains(firstErr, want) {
+			t.Fatalf("expected %q in %s", want, firstErr)
+		}
+	}
+	if strings.Index(firstErr, "fit_window") > strings.Index(firstErr, "fit_every") ||
+		strings.Index(firstErr, "a_extra") > strings.Index(firstErr, "b_extra") {
+		t.Errorf("expected validation errors in field order, got %s", firstErr)
+	}
+	if strings.Index(firstMetrics, "fake_cpu") > strings.Index(firstMetrics, "fake_memory") {
+		t.Errorf("expected metrics sorted by query alias, got:\n%s", firstMetrics)
+	}
+}
@@ -0,0 +1,278 @@
+package vmanomaly
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultInstanceName is the name of the instance configured with VMANOMALY_ENDPOINT
+const DefaultInstanceName = "default"
+
+// Instance is a named vmanomaly backend
+type Instance struct {
+	Name     string
+	Endpoint string
+	Client   API
+}
+
+type instanceKey struct{}
+
+// ContextWithInstance returns ctx selecting the instance Instances routes API calls to.
+// An empty name selects the default instance.
+func ContextWithInstance(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, instanceKey{}, name)
+}
+
+// InstanceFromContext returns the instance name set by ContextWithInstance
+func InstanceFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(instanceKey{}).(string)
+	return name
+}
+
+// Instances is a set of named vmanomaly backends.
+// It implements API by routing every call to the instance selected in the request context,
+// or to the default instance if none is selected.
+// The set can be replaced at runtime with Replace, e.g. on config reload.
+type Instances struct {
+	state atomic.Pointer[instancesState]
+}
+
+type instancesState struct {
+	list        []Instance
+	byName      map[string]Instance
+	defaultName string
+}
+
+var _ API = (*Instances)(nil)
+
+// NewInstances returns Instances with defaultName as the default instance.
+// If defaultName is empty, the first instance is the default one.
+func NewInstances(instances []Instance, defaultName string) (*Instances, error) {
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("at least one vmanomaly instance is required")
+	}
+
+	st := &instancesState{
+		list:        instances,
+		byName:      make(map[string]Instance, len(instances)),
+		defaultName: defaultName,
+	}
+	for _, inst := range instances {
+		if inst.Name == "" {
+			return nil, fmt.Errorf("instance name must not be empty")
+		}
+		if _, ok := st.byName[inst.Name]; ok {
+			return nil, fmt.Errorf("duplicate instance name %q", inst.Name)
+		}
+		st.byName[inst.Name] = inst
+	}
+	if st.defaultName == "" {
+		st.defaultName = instances[0].Name
+	}
+	if _, ok := st.byName[st.defaultName]; !ok {
+		return nil, fmt.Errorf("default instance %q is not configured", st.defaultName)
+	}
+
+	is := &Instances{}
+	is.state.Store(st)
+	return is, nil
+}
+
+// Replace atomically replaces the set with instances of other.
+// Calls started before Replace complete against the previous instances.
+func (is *Instances) Replace(other *Instances) {
+	is.state.Store(other.state.Load())
+}
+
+// List returns all instances in configuration order
+func (is *Instances) List() []Instance {
+	return is.state.Load().list
+}
+
+// Default returns the name of the default instance
+func (is *Instances) Default() string {
+	return is.state.Load().defaultName
+}
+
+// Get returns the client of the named instance, or of the default one if name is empty
+func (is *Instances) Get(name string) (API, error) {
+	inst, err := is.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return inst.Client, nil
+}
+
+// Lookup returns the named instance, or the default one if name is empty
+func (is *Instances) Lookup(name string) (Instance, error) {
+	st := is.state.Load()
+	if name == "" {
+		name = st.defaultName
+	}
+	inst, ok := st.byName[name]
+	if !ok {
+		names := make([]string, 0, len(st.list))
+		for _, inst := range st.list {
+			names = append(names, inst.Name)
+		}
+		return Instance{}, fmt.Errorf("unknown vmanomaly instance %q, available instances: %s", name, strings.Join(names, ", "))
+	}
+	return inst, nil
+}
+
+func (is *Instances) client(ctx context.Context) (API, error) {
+	return is.Get(InstanceFromContext(ctx))
+}
+
+// ============================================================================
+// API implementation
+// ============================================================================
+
+func (is *Instances) GetHealth(ctx context.Context) (map[string]any, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetHealth(ctx)
+}
+
+func (is *Instances) GetBuildInfo(ctx context.Context) (map[string]any, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetBuildInfo(ctx)
+}
+
+func (is *Instances) GetServerQueries(ctx context.Context) (ServerQueriesResponse, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetServerQueries(ctx)
+}
+
+func (is *Instances) Metrics(ctx context.Context, config map[string]any) (string, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.Metrics(ctx, config)
+}
+
+func (is *Instances) ListModels(ctx context.Context) (*ModelsListResponse, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.ListModels(ctx)
+}
+
+func (is *Instances) GetServerModels(ctx context.Context) (*ServerModelsResponse, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetServerModels(ctx)
+}
+
+func (is *Instances) GetModelSchema(ctx context.Context, modelClass string) (map[string]any, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetModelSchema(ctx, modelClass)
+}
+
+func (is *Instances) ValidateModel(ctx context.Context, modelSpec map[string]any) (*ModelValidationResponse, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.ValidateModel(ctx, modelSpec)
+}
+
+func (is *Instances) GenerateConfig(ctx context.Context, req *ConfigGenerationRequest) (string, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.GenerateConfig(ctx, req)
+}
+
+func (is *Instances) ValidateConfig(ctx context.Context, config map[string]any) (*OkValidationResponse, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.ValidateConfig(ctx, config)
+}
+
+func (is *Instances) Compatibility(ctx context.Context, versionTo *string) (*CompatibilityCheckResponse, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Compatibility(ctx, versionTo)
+}
+
+func (is *Instances) GenerateAlertRule(ctx context.Context, req *AlertRuleRequest) (string, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.GenerateAlertRule(ctx, req)
+}
+
+func (is *Instances) CreateDetectionTask(ctx context.Context, req *AnomalyDetectionTaskRequest) (*AnomalyDetectionTaskResponse, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateDetectionTask(ctx, req)
+}
+
+func (is *Instances) GetTaskStatus(ctx context.Context, taskID string) (*AnomalyDetectionTaskStatus, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetTaskStatus(ctx, taskID)
+}
+
+func (is *Instances) ListTasks(ctx context.Context, limit int, status *string) (*AnomalyDetectionTaskListResponse, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.ListTasks(ctx, limit, status)
+}
+
+func (is *Instances) CancelTask(ctx context.Context, taskID string) (map[string]bool, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.CancelTask(ctx, taskID)
+}
+
+func (is *Instances) GetDetectionLimits(ctx context.Context) (*AnomalyDetectionLimitsResponse, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetDetectionLimits(ctx)
+}
+
+func (is *Instances) Query(ctx context.Context, req *QueryRequest) (map[string]any, error) {
+	c, err := is.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Query(ctx, req)
+}
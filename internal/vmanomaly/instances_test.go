@@ -0,0 +1,112 @@
+package vmanomaly
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewInstances(t *testing.T) {
+	a := NewClient("http://a", "", nil)
+	b := NewClient("http://b", "", nil)
+
+	tests := []struct {
+		name        string
+		instances   []Instance
+		defaultName string
+		wantDefault string
+		wantErr     string
+	}{
+		{name: "empty", wantErr: "at least one"},
+		{name: "first is default", instances: []Instance{{Name: "a", Client: a}, {Name: "b", Client: b}}, wantDefault: "a"},
+		{name: "explicit default", instances: []Instance{{Name: "a", Client: a}, {Name: "b", Client: b}}, defaultName: "b", wantDefault: "b"},
+		{name: "unknown default", instances: []Instance{{Name: "a", Client: a}}, defaultName: "c", wantErr: "not configured"},
+		{name: "duplicate", instances: []Instance{{Name: "a", Client: a}, {Name: "a", Client: b}}, wantErr: "duplicate"},
+		{name: "empty name", instances: []Instance{{Client: a}}, wantErr: "must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is, err := NewInstances(tt.instances, tt.defaultName)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertEqual(t, is.Default(), tt.wantDefault)
+		})
+	}
+}
+
+func TestInstances_Routing(t *testing.T) {
+	newServer := func(status string) *Client {
+		client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"` + status + `"}`))
+		})
+		t.Cleanup(server.Close)
+		return client
+	}
+	is, err := NewInstances([]Instance{
+		{Name: "prod", Client: newServer("prod")},
+		{Name: "staging", Client: newServer("staging")},
+	}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		instance string
+		want     string
+	}{
+		{instance: "", want: "prod"},
+		{instance: "prod", want: "prod"},
+		{instance: "staging", want: "staging"},
+	}
+	for _, tt := range tests {
+		health, err := is.GetHealth(ContextWithInstance(context.Background(), tt.instance))
+		if err != nil {
+			t.Fatalf("instance %q: unexpected error: %v", tt.instance, err)
+		}
+		assertEqual(t, health["status"], tt.want)
+	}
+
+	_, err = is.GetHealth(ContextWithInstance(context.Background(), "dev"))
+	if err == nil || !strings.Contains(err.Error(), "available instances: prod, staging") {
+		t.Errorf("expected unknown instance error listing instances, got %v", err)
+	}
+}
+
+func TestInstances_Replace(t *testing.T) {
+	newServer := func(status string) *Client {
+		client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"` + status + `"}`))
+		})
+		t.Cleanup(server.Close)
+		return client
+	}
+	is, err := NewInstances([]Instance{{Name: "prod", Client: newServer("old")}}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	next, err := NewInstances([]Instance{
+		{Name: "prod", Client: newServer("new")},
+		{Name: "staging", Client: newServer("staging")},
+	}, "staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	is.Replace(next)
+
+	assertEqual(t, is.Default(), "staging")
+	assertEqual(t, len(is.List()), 2)
+	health, err := is.GetHealth(ContextWithInstance(context.Background(), "prod"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, health["status"], "new")
+}
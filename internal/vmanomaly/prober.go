@@ -0,0 +1,263 @@
+package vmanomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// Readiness states reported by Prober
+const (
+	// ReadinessReady means all instances pass health probes
+	ReadinessReady = "ready"
+	// ReadinessDegraded means some instances failed FailureThreshold consecutive probes
+	ReadinessDegraded = "degraded"
+	// ReadinessUnavailable means all instances failed FailureThreshold consecutive probes
+	ReadinessUnavailable = "unavailable"
+)
+
+// ProberConfig configures a Prober
+type ProberConfig struct {
+	// Interval between probes of every instance
+	Interval time.Duration
+	// Timeout of a single probe, Interval if not set
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failed probes after which an instance is unhealthy
+	FailureThreshold int
+}
+
+// ProbeResult is the outcome of the last probe of an instance
+type ProbeResult struct {
+	Instance            string    `json:"instance"`
+	Healthy             bool      `json:"healthy"`
+	Up                  bool      `json:"up"`
+	Version             string    `json:"version,omitempty"`
+	Error               string    `json:"error,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	DurationSeconds     float64   `json:"duration_seconds"`
+	LastProbe           time.Time `json:"last_probe,omitzero"`
+	LastSuccess         time.Time `json:"last_success,omitzero"`
+}
+
+// Readiness is the state of all instances
+type Readiness struct {
+	State     string        `json:"state"`
+	Instances []ProbeResult `json:"instances"`
+}
+
+// Prober periodically checks health and build info of all instances.
+// Build info requires authorization, so rejected credentials are detected too.
+type Prober struct {
+	instances *Instances
+	cfg       ProberConfig
+	ms        *metrics.Set
+
+	mu      sync.Mutex
+	results map[string]*ProbeResult
+}
+
+// NewProber returns a prober of instances exporting probe results to ms
+func NewProber(instances *Instances, cfg ProberConfig, ms *metrics.Set) *Prober {
+	if cfg.Timeout <= 0 || cfg.Timeout > cfg.Interval {
+		cfg.Timeout = cfg.Interval
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 1
+	}
+	return &Prober{
+		instances: instances,
+		cfg:       cfg,
+		ms:        ms,
+		results:   make(map[string]*ProbeResult),
+	}
+}
+
+// Run probes all instances immediately and then every interval until ctx is done
+func (p *Prober) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		p.ProbeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeAll probes all current instances concurrently. Results of instances removed on config reload are dropped.
+func (p *Prober) ProbeAll(ctx context.Context) {
+	list := p.instances.List()
+	var wg sync.WaitGroup
+	for _, inst := range list {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.probe(ctx, inst)
+		}()
+	}
+	wg.Wait()
+
+	current := make(map[string]bool, len(list))
+	for _, inst := range list {
+		current[inst.Name] = true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name := range p.results {
+		if !current[name] {
+			delete(p.results, name)
+			for _, metric := range newProbeMetrics(name).names() {
+				p.ms.UnregisterMetric(metric)
+			}
+		}
+	}
+}
+
+func (p *Prober) probe(ctx context.Context, inst Instance) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	version, err := probeInstance(ctx, inst.Client)
+	duration := time.Since(start)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		// Stopped on shutdown, the result doesn't reflect the backend state
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r := p.results[inst.Name]
+	if r == nil {
+		r = &ProbeResult{Instance: inst.Name}
+		p.results[inst.Name] = r
+	}
+	wasHealthy := r.ConsecutiveFailures < p.cfg.FailureThreshold
+	r.LastProbe = start
+	r.DurationSeconds = duration.Seconds()
+	r.Up = err == nil
+	if err != nil {
+		r.ConsecutiveFailures++
+		r.Error = err.Error()
+	} else {
+		r.ConsecutiveFailures = 0
+		r.Error = ""
+		r.Version = version
+		r.LastSuccess = start
+	}
+	r.Healthy = r.ConsecutiveFailures < p.cfg.FailureThreshold
+	switch {
+	case wasHealthy && !r.Healthy:
+		slog.Warn("vmanomaly instance is unhealthy", "instance", inst.Name, "failures", r.ConsecutiveFailures, "error", r.Error)
+	case !wasHealthy && r.Healthy:
+		slog.Info("vmanomaly instance is healthy again", "instance", inst.Name)
+	}
+	p.updateMetrics(r)
+}
+
+// probeInstance checks health of the instance and returns its version
+func probeInstance(ctx context.Context, c API) (string, error) {
+	if _, err := c.GetHealth(ctx); err != nil {
+		return "", fmt.Errorf("health check failed: %s", probeError(err))
+	}
+	// Fresh build info also invalidates cached responses once vmanomaly is upgraded
+	info, err := c.GetBuildInfo(ContextWithNoCache(ctx))
+	if err != nil {
+		return "", fmt.Errorf("build info request failed: %s", probeError(err))
+	}
+	version, _ := info["vmanomaly"].(string)
+	return version, nil
+}
+
+// probeError returns the message of err without request URLs, since probe results are served without authentication
+func probeError(err error) string {
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		// The breaker error contains the last request error
+		return ErrCircuitOpen.Error()
+	case errors.As(err, &urlErr):
+		return fmt.Sprintf("request failed: %v", urlErr.Err)
+	default:
+		return err.Error()
+	}
+}
+
+// probeMetrics are names of metrics of an instance exported by Prober
+type probeMetrics struct {
+	up, healthy, consecutiveFailures, duration, lastSuccess, failures string
+}
+
+func newProbeMetrics(instance string) probeMetrics {
+	return probeMetrics{
+		up:                  fmt.Sprintf(`mcp_vmanomaly_backend_up{instance=%q}`, instance),
+		healthy:             fmt.Sprintf(`mcp_vmanomaly_backend_healthy{instance=%q}`, instance),
+		consecutiveFailures: fmt.Sprintf(`mcp_vmanomaly_backend_probe_consecutive_failures{instance=%q}`, instance),
+		duration:            fmt.Sprintf(`mcp_vmanomaly_backend_probe_duration_seconds{instance=%q}`, instance),
+		lastSuccess:         fmt.Sprintf(`mcp_vmanomaly_backend_last_success_timestamp_seconds{instance=%q}`, instance),
+		failures:            fmt.Sprintf(`mcp_vmanomaly_backend_probe_failures_total{instance=%q}`, instance),
+	}
+}
+
+func (pm probeMetrics) names() []string {
+	return []string{pm.up, pm.healthy, pm.consecutiveFailures, pm.duration, pm.lastSuccess, pm.failures}
+}
+
+func (p *Prober) updateMetrics(r *ProbeResult) {
+	pm := newProbeMetrics(r.Instance)
+	p.ms.GetOrCreateGauge(pm.up, nil).Set(boolToFloat(r.Up))
+	p.ms.GetOrCreateGauge(pm.healthy, nil).Set(boolToFloat(r.Healthy))
+	p.ms.GetOrCreateGauge(pm.consecutiveFailures, nil).Set(float64(r.ConsecutiveFailures))
+	p.ms.GetOrCreateGauge(pm.duration, nil).Set(r.DurationSeconds)
+	if !r.LastSuccess.IsZero() {
+		p.ms.GetOrCreateGauge(pm.lastSuccess, nil).Set(float64(r.LastSuccess.Unix()))
+	}
+	if !r.Up {
+		p.ms.GetOrCreateCounter(pm.failures).Inc()
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Readiness returns the state and the last probe results of all instances.
+// Instances that weren't probed yet are considered healthy.
+func (p *Prober) Readiness() Readiness {
+	list := p.instances.List()
+	rd := Readiness{State: ReadinessReady, Instances: make([]ProbeResult, 0, len(list))}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	unhealthy := 0
+	for _, inst := range list {
+		r := p.results[inst.Name]
+		if r == nil {
+			rd.Instances = append(rd.Instances, ProbeResult{Instance: inst.Name, Healthy: true})
+			continue
+		}
+		if !r.Healthy {
+			unhealthy++
+		}
+		rd.Instances = append(rd.Instances, *r)
+	}
+	switch {
+	case unhealthy == 0:
+	case unhealthy == len(list):
+		rd.State = ReadinessUnavailable
+	default:
+		rd.State = ReadinessDegraded
+	}
+	return rd
+}
@@ -0,0 +1,93 @@
+package vmanomaly
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+func TestProber(t *testing.T) {
+	newBackend := func(buildInfoStatus *atomic.Int32) API {
+		return newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/health":
+				_, _ = w.Write([]byte(`{"status":"ok"}`))
+			case "/api/v1/server/buildinfo":
+				w.WriteHeader(int(buildInfoStatus.Load()))
+				_, _ = w.Write([]byte(`{"vmanomaly":"v1.28.0"}`))
+			}
+		})
+	}
+	var statusA, statusB atomic.Int32
+	statusA.Store(http.StatusOK)
+	statusB.Store(http.StatusOK)
+	instances, err := NewInstances([]Instance{
+		{Name: "a", Client: newBackend(&statusA)},
+		{Name: "b", Client: newBackend(&statusB)},
+	}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ms := metrics.NewSet()
+	p := NewProber(instances, ProberConfig{Interval: time.Minute, FailureThreshold: 2}, ms)
+
+	// Instances aren't considered unhealthy before they are probed
+	assertEqual(t, p.Readiness().State, ReadinessReady)
+
+	p.ProbeAll(context.Background())
+	rd := p.Readiness()
+	assertEqual(t, rd.State, ReadinessReady)
+	assertEqual(t, rd.Instances[0].Version, "v1.28.0")
+	assertEqual(t, ms.GetOrCreateGauge(`mcp_vmanomaly_backend_up{instance="a"}`, nil).Get(), float64(1))
+
+	// Rejected credentials fail build info requests; instances are unhealthy after FailureThreshold failures
+	statusB.Store(http.StatusUnauthorized)
+	p.ProbeAll(context.Background())
+	rd = p.Readiness()
+	assertEqual(t, rd.State, ReadinessReady)
+	assertEqual(t, rd.Instances[1].Up, false)
+	assertEqual(t, rd.Instances[1].Healthy, true)
+	if !strings.Contains(rd.Instances[1].Error, "status 401") {
+		t.Errorf("expected probe error with status 401, got %q", rd.Instances[1].Error)
+	}
+	p.ProbeAll(context.Background())
+	rd = p.Readiness()
+	assertEqual(t, rd.State, ReadinessDegraded)
+	assertEqual(t, rd.Instances[1].ConsecutiveFailures, 2)
+	assertEqual(t, ms.GetOrCreateGauge(`mcp_vmanomaly_backend_healthy{instance="b"}`, nil).Get(), float64(0))
+	assertEqual(t, ms.GetOrCreateCounter(`mcp_vmanomaly_backend_probe_failures_total{instance="b"}`).Get(), uint64(2))
+
+	statusA.Store(http.StatusServiceUnavailable)
+	p.ProbeAll(context.Background())
+	p.ProbeAll(context.Background())
+	assertEqual(t, p.Readiness().State, ReadinessUnavailable)
+
+	// A single successful probe makes an instance healthy again
+	statusA.Store(http.StatusOK)
+	p.ProbeAll(context.Background())
+	rd = p.Readiness()
+	assertEqual(t, rd.State, ReadinessDegraded)
+	assertEqual(t, rd.Instances[0].Healthy, true)
+	assertEqual(t, rd.Instances[0].Error, "")
+
+	// Results of instances removed on config reload are dropped
+	other, err := NewInstances([]Instance{{Name: "a", Client: newBackend(&statusA)}}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	instances.Replace(other)
+	p.ProbeAll(context.Background())
+	rd = p.Readiness()
+	assertEqual(t, rd.State, ReadinessReady)
+	assertEqual(t, len(rd.Instances), 1)
+	var buf strings.Builder
+	ms.WritePrometheus(&buf)
+	if strings.Contains(buf.String(), `instance="b"`) {
+		t.Errorf("expected metrics of removed instance to be unregistered, got:\n%s", buf.String())
+	}
+}
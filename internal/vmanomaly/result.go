@@ -0,0 +1,251 @@
+package vmanomaly
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Time Series Types
+// ============================================================================
+
+// Sample represents a single time series point
+type Sample struct {
+	Timestamp float64 // Unix timestamp in seconds
+	Value     float64 // Sample value (may be NaN)
+}
+
+// Series represents a time series with its labels
+type Series struct {
+	Labels  map[string]string // Series labels including __name__
+	Samples []Sample          // Samples ordered by timestamp
+}
+
+// matrixResponse represents a Prometheus-style query response.
+// Fields are optional, so both full responses and bare data objects are accepted.
+type matrixResponse struct {
+	Status     string          `json:"status"`
+	ResultType string          `json:"resultType"`
+	Result     []matrixSeries  `json:"result"`
+	Data       *matrixResponse `json:"data"`
+	Error      string          `json:"error"`
+}
+
+type matrixSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]any          `json:"values"`
+	Value  *[2]any           `json:"value"`
+}
+
+// ParseMatrix parses a Prometheus-style range query response into typed series.
+// It accepts the full response ({"status":"success","data":{"resultType":"matrix","result":[...]}})
+// as well as the bare data object ({"resultType":"matrix","result":[...]}).
+// Instant vectors are converted to single-sample series.
+func ParseMatrix(data map[string]any) ([]Series, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode data: %w", err)
+	}
+
+	var resp matrixResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode data: %w", err)
+	}
+
+	if resp.Status == "error" {
+		return nil, fmt.Errorf("query failed: %s", resp.Error)
+	}
+	if resp.Data != nil {
+		resp = *resp.Data
+	}
+	if resp.Result == nil {
+		return nil, fmt.Errorf("unexpected response format: missing result")
+	}
+	if resp.ResultType != "" && resp.ResultType != "matrix" && resp.ResultType != "vector" {
+		return nil, fmt.Errorf("unsupported result type %q", resp.ResultType)
+	}
+
+	series := make([]Series, 0, len(resp.Result))
+	for i, rs := range resp.Result {
+		values := rs.Values
+		if rs.Value != nil {
+			values = append(values, *rs.Value)
+		}
+
+		s := Series{
+			Labels:  rs.Metric,
+			Samples: make([]Sample, 0, len(values)),
+		}
+		if s.Labels == nil {
+			s.Labels = map[string]string{}
+		}
+		for j, v := range values {
+			sample, err := parseSample(v)
+			if err != nil {
+				return nil, fmt.Errorf("series %d sample %d: %w", i, j, err)
+			}
+			s.Samples = append(s.Samples, sample)
+		}
+		sort.Slice(s.Samples, func(a, b int) bool {
+			return s.Samples[a].Timestamp < s.Samples[b].Timestamp
+		})
+		series = append(series, s)
+	}
+
+	return series, nil
+}
+
+func parseSample(v [2]any) (Sample, error) {
+	ts, err := parseNumber(v[0])
+	if err != nil {
+		return Sample{}, fmt.Errorf("invalid timestamp: %w", err)
+	}
+	value, err := parseNumber(v[1])
+	if err != nil {
+		return Sample{}, fmt.Errorf("invalid value: %w", err)
+	}
+	return Sample{Timestamp: ts, Value: value}, nil
+}
+
+func parseNumber(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	case nil:
+		return math.NaN(), nil
+	default:
+		return 0, fmt.Errorf("unexpected type %T", v)
+	}
+}
+
+// SeriesKey returns a stable string representation of labels, excluding __name__
+func SeriesKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// InferStep returns the most common distance between consecutive samples in seconds
+func InferStep(samples []Sample) float64 {
+	counts := make(map[float64]int)
+	var step float64
+	for i := 1; i < len(samples); i++ {
+		d := samples[i].Timestamp - samples[i-1].Timestamp
+		if d <= 0 {
+			continue
+		}
+		counts[d]++
+		if counts[d] > counts[step] || (counts[d] == counts[step] && d < step) {
+			step = d
+		}
+	}
+	return step
+}
+
+// ============================================================================
+// Detection Result Types
+// ============================================================================
+
+// DetectionSeries groups vmanomaly output series that belong to the same input series
+type DetectionSeries struct {
+	Labels       map[string]string // Series labels without __name__
+	AnomalyScore []Sample          // anomaly_score output
+	Y            []Sample          // y output (original values)
+	Yhat         []Sample          // yhat output (expected values)
+	YhatLower    []Sample          // yhat_lower output (lower boundary)
+	YhatUpper    []Sample          // yhat_upper output (upper boundary)
+}
+
+// detectionOutputs lists vmanomaly output names, longest first so that suffix matching is unambiguous
+var detectionOutputs = []string{"anomaly_score", "yhat_lower", "yhat_upper", "yhat", "y"}
+
+// ParseDetectionResult parses TaskResult.Data into per-series detection outputs.
+// Output metric names may carry a prefix configured in the writer (e.g. "PREFIX_anomaly_score").
+func ParseDetectionResult(result *TaskResult) ([]DetectionSeries, error) {
+	if result == nil {
+		return nil, fmt.Errorf("task has no result")
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("task result error: %s", *result.Error)
+	}
+	if result.Data == nil {
+		return nil, fmt.Errorf("task result has no data")
+	}
+
+	series, err := ParseMatrix(result.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*DetectionSeries)
+	var order []string
+	for _, s := range series {
+		output := detectionOutputName(s.Labels["__name__"])
+		if output == "" {
+			continue
+		}
+
+		key := SeriesKey(s.Labels)
+		ds, ok := byKey[key]
+		if !ok {
+			labels := make(map[string]string, len(s.Labels))
+			for k, v := range s.Labels {
+				if k != "__name__" {
+					labels[k] = v
+				}
+			}
+			ds = &DetectionSeries{Labels: labels}
+			byKey[key] = ds
+			order = append(order, key)
+		}
+
+		switch output {
+		case "anomaly_score":
+			ds.AnomalyScore = append(ds.AnomalyScore, s.Samples...)
+		case "y":
+			ds.Y = append(ds.Y, s.Samples...)
+		case "yhat":
+			ds.Yhat = append(ds.Yhat, s.Samples...)
+		case "yhat_lower":
+			ds.YhatLower = append(ds.YhatLower, s.Samples...)
+		case "yhat_upper":
+			ds.YhatUpper = append(ds.YhatUpper, s.Samples...)
+		}
+	}
+
+	detections := make([]DetectionSeries, 0, len(order))
+	for _, key := range order {
+		detections = append(detections, *byKey[key])
+	}
+	return detections, nil
+}
+
+func detectionOutputName(name string) string {
+	for _, output := range detectionOutputs {
+		if name == output || strings.HasSuffix(name, "_"+output) {
+			return output
+		}
+	}
+	return ""
+}
@@ -0,0 +1,241 @@
+package vmanomaly
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseMatrix(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        map[string]any
+		wantErr     bool
+		wantSeries  int
+		wantSamples int
+	}{
+		{
+			name: "full response",
+			data: map[string]any{
+				"status": "success",
+				"data": map[string]any{
+					"resultType": "matrix",
+					"result": []any{
+						map[string]any{
+							"metric": map[string]any{"__name__": "up", "job": "vm"},
+							"values": []any{[]any{1700000060, "1"}, []any{1700000000, "0"}},
+						},
+					},
+				},
+			},
+			wantSeries:  1,
+			wantSamples: 2,
+		},
+		{
+			name: "bare data with NaN",
+			data: map[string]any{
+				"resultType": "matrix",
+				"result": []any{
+					map[string]any{
+						"metric": map[string]any{"job": "vm"},
+						"values": []any{[]any{1700000000.5, "NaN"}},
+					},
+				},
+			},
+			wantSeries:  1,
+			wantSamples: 1,
+		},
+		{
+			name: "instant vector",
+			data: map[string]any{
+				"resultType": "vector",
+				"result": []any{
+					map[string]any{"metric": map[string]any{}, "value": []any{1700000000, "42"}},
+				},
+			},
+			wantSeries:  1,
+			wantSamples: 1,
+		},
+		{
+			name:    "error response",
+			data:    map[string]any{"status": "error", "error": "bad query"},
+			wantErr: true,
+		},
+		{
+			name:    "missing result",
+			data:    map[string]any{"foo": "bar"},
+			wantErr: true,
+		},
+		{
+			name: "invalid value",
+			data: map[string]any{
+				"result": []any{
+					map[string]any{"values": []any{[]any{1700000000, "abc"}}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := ParseMatrix(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMatrix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(series) != tt.wantSeries {
+				t.Fatalf("series count = %d, want %d", len(series), tt.wantSeries)
+			}
+			if len(series[0].Samples) != tt.wantSamples {
+				t.Errorf("samples count = %d, want %d", len(series[0].Samples), tt.wantSamples)
+			}
+			for i := 1; i < len(series[0].Samples); i++ {
+				if series[0].Samples[i].Timestamp < series[0].Samples[i-1].Timestamp {
+					t.Error("samples are not sorted by timestamp")
+				}
+			}
+		})
+	}
+}
+
+func detectionResult(series ...map[string]any) *TaskResult {
+	result := make([]any, 0, len(series))
+	for _, s := range series {
+		result = append(result, s)
+	}
+	return &TaskResult{
+		Status: "success",
+		Data:   map[string]any{"resultType": "matrix", "result": result},
+	}
+}
+
+func outputSeries(name, instance string, values ...float64) map[string]any {
+	points := make([]any, 0, len(values))
+	for i, v := range values {
+		points = append(points, []any{1700000000 + i*60, strconv.FormatFloat(v, 'f', -1, 64)})
+	}
+	return map[string]any{
+		"metric": map[string]any{"__name__": name, "for": "cpu", "instance": instance},
+		"values": points,
+	}
+}
+
+func TestParseDetectionResult(t *testing.T) {
+	result := detectionResult(
+		outputSeries("anomaly_score", "a", 0.1, 0.2),
+		outputSeries("yhat", "a", 10, 11),
+		outputSeries("PREFIX_yhat_lower", "a", 5, 6),
+		outputSeries("yhat_upper", "a", 15, 16),
+		outputSeries("y", "a", 10, 12),
+		outputSeries("anomaly_score", "b", 0.1, 0.2),
+		outputSeries("unrelated_metric", "c", 1),
+	)
+
+	series, err := ParseDetectionResult(result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("series count = %d, want 2", len(series))
+	}
+
+	a := series[0]
+	if a.Labels["instance"] != "a" || a.Labels["__name__"] != "" {
+		t.Errorf("unexpected labels: %v", a.Labels)
+	}
+	if len(a.AnomalyScore) != 2 || len(a.Yhat) != 2 || len(a.YhatLower) != 2 || len(a.YhatUpper) != 2 || len(a.Y) != 2 {
+		t.Errorf("unexpected outputs: %+v", a)
+	}
+
+	errMsg := "failed"
+	if _, err := ParseDetectionResult(&TaskResult{Status: "error", Error: &errMsg}); err == nil {
+		t.Error("expected error for failed result")
+	}
+	if _, err := ParseDetectionResult(nil); err == nil {
+		t.Error("expected error for nil result")
+	}
+}
+
+func TestSummarizeDetection(t *testing.T) {
+	nan := math.NaN()
+	result := detectionResult(
+		// two intervals: points 1-2 and point 5
+		outputSeries("anomaly_score", "a", 0.5, 1.5, 2.5, 0.1, 0.2, 1.2),
+		outputSeries("y", "a", 1, 2, 30, 4, 5, 6),
+		outputSeries("yhat", "a", 1, 2, 3, 4, 5, 6),
+		// higher peak, NaN gap splits the run into two intervals
+		outputSeries("anomaly_score", "b", 3.5, nan, 1.1, 0.1),
+		// no anomalies
+		outputSeries("anomaly_score", "c", 0.1, 0.2, 0.3),
+	)
+
+	series, err := ParseDetectionResult(result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary := SummarizeDetection(series, 1.0, 10)
+	if summary.TotalSeries != 3 || summary.AnomalousSeries != 2 || summary.TotalIntervals != 4 {
+		t.Fatalf("unexpected summary counters: %+v", summary)
+	}
+
+	top := summary.TopSeries[0]
+	if top.Labels["instance"] != "b" || top.PeakScore != 3.5 {
+		t.Errorf("unexpected top series: %+v", top)
+	}
+	if len(top.Intervals) != 2 {
+		t.Errorf("intervals for b = %d, want 2", len(top.Intervals))
+	}
+
+	second := summary.TopSeries[1]
+	if second.AnomalousPoints != 3 || len(second.Intervals) != 2 {
+		t.Fatalf("unexpected second series: %+v", second)
+	}
+	first := second.Intervals[0]
+	if first.Points != 2 || first.PeakScore != 2.5 || first.DurationSeconds != 120 {
+		t.Errorf("unexpected interval: %+v", first)
+	}
+	if !first.PeakAt.Equal(time.Unix(1700000120, 0)) {
+		t.Errorf("peak_at = %v, want %v", first.PeakAt, time.Unix(1700000120, 0))
+	}
+	if first.PeakValue == nil || *first.PeakValue != 30 || first.PeakExpected == nil || *first.PeakExpected != 3 {
+		t.Errorf("unexpected peak values: %+v", first)
+	}
+	if first.PeakLowerBound != nil {
+		t.Error("expected no lower bound without yhat_lower output")
+	}
+
+	limited := SummarizeDetection(series, 1.0, 1)
+	if len(limited.TopSeries) != 1 || limited.AnomalousSeries != 2 {
+		t.Errorf("unexpected limited summary: %+v", limited)
+	}
+}
+
+func TestSummarizeDetection_TruncatedIntervals(t *testing.T) {
+	// 12 single-point intervals separated by normal points, peaks grow with time
+	var scores []float64
+	for i := range 12 {
+		scores = append(scores, 2+float64(i), 0.1)
+	}
+	series, err := ParseDetectionResult(detectionResult(outputSeries("anomaly_score", "a", scores...)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary := SummarizeDetection(series, 1.0, 10)
+	if summary.TotalIntervals != 12 {
+		t.Errorf("total_intervals = %d, want 12", summary.TotalIntervals)
+	}
+	ss := summary.TopSeries[0]
+	if ss.TotalIntervals != 12 || len(ss.Intervals) != maxSummaryIntervals || !ss.IntervalsTruncated {
+		t.Fatalf("unexpected series summary: total=%d intervals=%d truncated=%v", ss.TotalIntervals, len(ss.Intervals), ss.IntervalsTruncated)
+	}
+	// Intervals with the lowest peaks are omitted, the rest stay in chronological order
+	if ss.Intervals[0].PeakScore != 4 || ss.Intervals[len(ss.Intervals)-1].PeakScore != 13 {
+		t.Errorf("unexpected kept intervals: first peak %g, last peak %g", ss.Intervals[0].PeakScore, ss.Intervals[len(ss.Intervals)-1].PeakScore)
+	}
+}
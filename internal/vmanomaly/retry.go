@@ -0,0 +1,104 @@
+package vmanomaly
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+)
+
+// RetryConfig configures retries of idempotent requests
+type RetryConfig struct {
+	MaxRetries     int           // Number of retries after the first attempt, 0 disables retries
+	InitialBackoff time.Duration // Backoff before the first retry, doubled on every next one
+	MaxBackoff     time.Duration // Upper bound of backoff and of accepted Retry-After delays
+}
+
+// readOnlyPostPaths are endpoints that use POST for request bodies but don't change server state
+var readOnlyPostPaths = map[string]bool{
+	"/api/v1/query":           true,
+	"/api/v1/model/validate":  true,
+	"/api/v1/config/validate": true,
+}
+
+// isIdempotent reports whether a request can be safely repeated
+func isIdempotent(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return readOnlyPostPaths[path]
+	default:
+		return false
+	}
+}
+
+// isRetryable reports whether the attempt failed with a transient error.
+// Errors caused by the caller's context are never retried.
+func isRetryable(ctx context.Context, status int, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled)
+	}
+	return isRetryableStatus(status)
+}
+
+// isRetryableStatus reports whether a response with the status is transient
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before retry number attempt (starting from 0).
+// Retry-After from the response is respected; ok is false if it exceeds MaxBackoff.
+func (rc RetryConfig) backoff(attempt int, header http.Header) (time.Duration, bool) {
+	initial, maxBackoff := rc.InitialBackoff, rc.MaxBackoff
+	if initial <= 0 {
+		initial = defaultRetryInitialBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+
+	delay := initial << min(attempt, 30)
+	if delay <= 0 || delay > maxBackoff {
+		delay = maxBackoff
+	}
+	// Equal jitter: keep at least half of the delay to avoid hammering the server
+	delay = delay/2 + rand.N(delay/2+1)
+
+	if retryAfter, ok := parseRetryAfter(header.Get("Retry-After"), time.Now()); ok {
+		if retryAfter > maxBackoff {
+			return 0, false
+		}
+		delay = max(delay, retryAfter)
+	}
+	return delay, true
+}
+
+// parseRetryAfter parses Retry-After header in delay-seconds or HTTP-date form
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
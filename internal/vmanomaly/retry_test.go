@@ -0,0 +1,160 @@
+package vmanomaly
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+func newRetryTestServer(t *testing.T, handler http.HandlerFunc, opts ...ClientOption) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, "", nil, opts...)
+}
+
+func TestClient_Retry(t *testing.T) {
+	retry := WithRetry(RetryConfig{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 50 * time.Millisecond})
+
+	t.Run("retries idempotent request on 503", func(t *testing.T) {
+		var calls atomic.Int32
+		ms := metrics.NewSet()
+		client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		}, retry, WithMetricsSet(ms))
+
+		result, err := client.GetHealth(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertEqual(t, result["status"], "ok")
+		assertEqual(t, calls.Load(), int32(3))
+		assertEqual(t, ms.GetOrCreateCounter(`mcp_vmanomaly_upstream_retries_total{instance="default"}`).Get(), uint64(2))
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}, retry)
+
+		if _, err := client.GetHealth(context.Background()); err == nil {
+			t.Fatal("expected error")
+		}
+		assertEqual(t, calls.Load(), int32(3))
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}, retry)
+
+		if _, err := client.GetHealth(context.Background()); err == nil {
+			t.Fatal("expected error")
+		}
+		assertEqual(t, calls.Load(), int32(1))
+	})
+
+	t.Run("does not retry task creation", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, retry)
+
+		if _, err := client.CreateDetectionTask(context.Background(), &AnomalyDetectionTaskRequest{}); err == nil {
+			t.Fatal("expected error")
+		}
+		assertEqual(t, calls.Load(), int32(1))
+	})
+
+	t.Run("retries read-only POST with body", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength <= 0 {
+				t.Errorf("attempt %d sent empty body", calls.Load()+1)
+			}
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"is_valid":true}`))
+		}, retry)
+
+		if _, err := client.ValidateConfig(context.Background(), map[string]any{"schedulers": map[string]any{}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertEqual(t, calls.Load(), int32(2))
+	})
+
+	t.Run("honors Retry-After", func(t *testing.T) {
+		var calls atomic.Int32
+		var first time.Time
+		client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				first = time.Now()
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			if elapsed := time.Since(first); elapsed < time.Second {
+				t.Errorf("retried after %s, want at least 1s", elapsed)
+			}
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		}, WithRetry(RetryConfig{MaxRetries: 1, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Second}))
+
+		if _, err := client.GetHealth(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertEqual(t, calls.Load(), int32(2))
+	})
+
+	t.Run("gives up when Retry-After exceeds max backoff", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}, retry)
+
+		if _, err := client.GetHealth(context.Background()); err == nil {
+			t.Fatal("expected error")
+		}
+		assertEqual(t, calls.Load(), int32(1))
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "5", want: 5 * time.Second, wantOK: true},
+		{value: "-1", wantOK: false},
+		{value: "Wed, 01 Jan 2025 12:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{value: "Wed, 01 Jan 2025 11:00:00 GMT", want: 0, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assertEqual(t, ok, tt.wantOK)
+			assertEqual(t, got, tt.want)
+		})
+	}
+}
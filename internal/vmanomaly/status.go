@@ -0,0 +1,45 @@
+package vmanomaly
+
+import (
+	"context"
+	"sync"
+)
+
+type statusRecorderKey struct{}
+
+// StatusRecorder records HTTP status codes of vmanomaly responses to requests made with its context
+type StatusRecorder struct {
+	mu     sync.Mutex
+	status int
+}
+
+// ContextWithStatusRecorder returns a context recording statuses of requests made with it into the returned recorder
+func ContextWithStatusRecorder(ctx context.Context) (context.Context, *StatusRecorder) {
+	r := &StatusRecorder{}
+	return context.WithValue(ctx, statusRecorderKey{}, r), r
+}
+
+// Status returns the status of the first failed request, or of the last request if all succeeded.
+// It returns 0 if no response was received.
+func (r *StatusRecorder) Status() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+func (r *StatusRecorder) record(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status != 0 && (r.status < 200 || r.status >= 300) {
+		// Keep the first failure, later requests of the call don't explain its outcome
+		return
+	}
+	r.status = status
+}
+
+// recordStatus records the final status of a request to the recorder of ctx, if any
+func recordStatus(ctx context.Context, status int) {
+	if r, ok := ctx.Value(statusRecorderKey{}).(*StatusRecorder); ok && status != 0 {
+		r.record(status)
+	}
+}
@@ -0,0 +1,36 @@
+package vmanomaly
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestStatusRecorder(t *testing.T) {
+	statuses := map[string]int{
+		"/health":      http.StatusOK,
+		"/api/v1/info": http.StatusNotFound,
+	}
+	client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[r.URL.Path])
+		_, _ = w.Write([]byte(`{}`))
+	})
+	defer server.Close()
+
+	ctx, rec := ContextWithStatusRecorder(context.Background())
+	assertEqual(t, rec.Status(), 0)
+
+	_, _ = client.doRequest(ctx, http.MethodGet, "/health", nil)
+	assertEqual(t, rec.Status(), http.StatusOK)
+
+	// The first failure is kept
+	_, _ = client.doRequest(ctx, http.MethodGet, "/api/v1/info", nil)
+	assertEqual(t, rec.Status(), http.StatusNotFound)
+	_, _ = client.doRequest(ctx, http.MethodGet, "/health", nil)
+	assertEqual(t, rec.Status(), http.StatusNotFound)
+
+	// Requests without a recorder are not affected
+	if _, err := client.doRequest(context.Background(), http.MethodGet, "/health", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package vmanomaly
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultSummaryTopN is the default number of series included in a detection summary
+	DefaultSummaryTopN = 10
+	// maxSummaryIntervals limits intervals reported per series, the ones with highest peaks are kept
+	maxSummaryIntervals = 10
+)
+
+// ============================================================================
+// Detection Summary Types
+// ============================================================================
+
+// AnomalyInterval is a contiguous run of points with anomaly score above the threshold
+type AnomalyInterval struct {
+	Start           time.Time `json:"start"`                      // First anomalous point
+	End             time.Time `json:"end"`                        // Last anomalous point
+	DurationSeconds float64   `json:"duration_seconds"`           // Interval duration including the last step
+	Points          int       `json:"points"`                     // Number of anomalous points
+	PeakScore       float64   `json:"peak_score"`                 // Maximum anomaly score within the interval
+	PeakAt          time.Time `json:"peak_at"`                    // Time of the peak anomaly score
+	PeakValue       *float64  `json:"peak_value,omitempty"`       // Original value (y) at peak
+	PeakExpected    *float64  `json:"peak_expected,omitempty"`    // Expected value (yhat) at peak
+	PeakLowerBound  *float64  `json:"peak_lower_bound,omitempty"` // Lower boundary (yhat_lower) at peak
+	PeakUpperBound  *float64  `json:"peak_upper_bound,omitempty"` // Upper boundary (yhat_upper) at peak
+}
+
+// SeriesSummary summarizes anomalies of a single series
+type SeriesSummary struct {
+	Labels             map[string]string `json:"labels"`                        // Series labels
+	PeakScore          float64           `json:"peak_score"`                    // Maximum anomaly score over the whole series
+	AnomalousPoints    int               `json:"anomalous_points"`              // Number of points above threshold
+	TotalPoints        int               `json:"total_points"`                  // Number of scored points
+	TotalIntervals     int               `json:"total_intervals"`               // Number of anomaly intervals, including omitted ones
+	Intervals          []AnomalyInterval `json:"intervals"`                     // Anomaly intervals in chronological order
+	IntervalsTruncated bool              `json:"intervals_truncated,omitempty"` // Whether some intervals were omitted
+}
+
+// DetectionSummary is a compact overview of a detection result suitable for LLM consumption
+type DetectionSummary struct {
+	Threshold       float64         `json:"threshold"`        // Anomaly score threshold used
+	TotalSeries     int             `json:"total_series"`     // Number of series in the result
+	AnomalousSeries int             `json:"anomalous_series"` // Number of series with at least one anomaly
+	TotalIntervals  int             `json:"total_intervals"`  // Number of anomaly intervals across all series
+	TopSeries       []SeriesSummary `json:"top_series"`       // Most anomalous series ordered by peak score
+}
+
+// SummarizeDetection returns the top-N anomalous series with their anomaly intervals.
+// Points with anomaly score strictly above threshold are considered anomalous.
+func SummarizeDetection(series []DetectionSeries, threshold float64, topN int) DetectionSummary {
+	if topN <= 0 {
+		topN = DefaultSummaryTopN
+	}
+
+	summary := DetectionSummary{
+		Threshold:   threshold,
+		TotalSeries: len(series),
+		TopSeries:   []SeriesSummary{},
+	}
+
+	var anomalous []SeriesSummary
+	for _, ds := range series {
+		ss := summarizeSeries(ds, threshold)
+		if ss.AnomalousPoints == 0 {
+			continue
+		}
+		summary.TotalIntervals += ss.TotalIntervals
+		anomalous = append(anomalous, ss)
+	}
+	summary.AnomalousSeries = len(anomalous)
+
+	sort.SliceStable(anomalous, func(i, j int) bool {
+		if anomalous[i].PeakScore != anomalous[j].PeakScore {
+			return anomalous[i].PeakScore > anomalous[j].PeakScore
+		}
+		return anomalous[i].AnomalousPoints > anomalous[j].AnomalousPoints
+	})
+	if len(anomalous) > topN {
+		anomalous = anomalous[:topN]
+	}
+	summary.TopSeries = append(summary.TopSeries, anomalous...)
+
+	return summary
+}
+
+func summarizeSeries(ds DetectionSeries, threshold float64) SeriesSummary {
+	ss := SeriesSummary{
+		Labels:    ds.Labels,
+		Intervals: []AnomalyInterval{},
+	}
+
+	step := InferStep(ds.AnomalyScore)
+	lookup := func(samples []Sample, ts float64) *float64 {
+		i := sort.Search(len(samples), func(i int) bool { return samples[i].Timestamp >= ts })
+		if i < len(samples) && samples[i].Timestamp == ts && !math.IsNaN(samples[i].Value) {
+			v := samples[i].Value
+			return &v
+		}
+		return nil
+	}
+
+	var (
+		current *AnomalyInterval
+		lastTs  float64
+	)
+	closeInterval := func() {
+		if current == nil {
+			return
+		}
+		current.DurationSeconds = current.End.Sub(current.Start).Seconds() + step
+		current.PeakValue = lookup(ds.Y, unixSeconds(current.PeakAt))
+		current.PeakExpected = lookup(ds.Yhat, unixSeconds(current.PeakAt))
+		current.PeakLowerBound = lookup(ds.YhatLower, unixSeconds(current.PeakAt))
+		current.PeakUpperBound = lookup(ds.YhatUpper, unixSeconds(current.PeakAt))
+		ss.Intervals = append(ss.Intervals, *current)
+		current = nil
+	}
+
+	for _, s := range ds.AnomalyScore {
+		if math.IsNaN(s.Value) {
+			continue
+		}
+		ss.TotalPoints++
+		if s.Value > ss.PeakScore {
+			ss.PeakScore = s.Value
+		}
+
+		if s.Value <= threshold {
+			closeInterval()
+			continue
+		}
+		ss.AnomalousPoints++
+
+		// A gap larger than one and a half steps breaks contiguity
+		if current != nil && step > 0 && s.Timestamp-lastTs > 1.5*step {
+			closeInterval()
+		}
+		ts := fromUnixSeconds(s.Timestamp)
+		if current == nil {
+			current = &AnomalyInterval{Start: ts, PeakScore: s.Value, PeakAt: ts}
+		}
+		current.End = ts
+		current.Points++
+		if s.Value > current.PeakScore {
+			current.PeakScore = s.Value
+			current.PeakAt = ts
+		}
+		lastTs = s.Timestamp
+	}
+	closeInterval()
+
+	ss.TotalIntervals = len(ss.Intervals)
+	if len(ss.Intervals) > maxSummaryIntervals {
+		kept := append([]AnomalyInterval(nil), ss.Intervals...)
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].PeakScore > kept[j].PeakScore
+		})
+		kept = kept[:maxSummaryIntervals]
+		sort.Slice(kept, func(i, j int) bool {
+			return kept[i].Start.Before(kept[j].Start)
+		})
+		ss.Intervals = kept
+		ss.IntervalsTruncated = true
+	}
+
+	return ss
+}
+
+func fromUnixSeconds(ts float64) time.Time {
+	sec, frac := math.Modf(ts)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
@@ -0,0 +1,166 @@
+package vmanomaly
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TLSOptions configures TLS connections to vmanomaly
+type TLSOptions struct {
+	CAFile             string // PEM bundle of CAs to verify the server certificate, system pool if empty
+	CertFile           string // PEM client certificate for mTLS
+	KeyFile            string // PEM client key for mTLS
+	ServerName         string // Overrides the server name used for verification and SNI
+	InsecureSkipVerify bool   // Disables server certificate verification
+}
+
+// IsSet reports whether any option differs from the default TLS behavior
+func (o TLSOptions) IsSet() bool {
+	return o != TLSOptions{}
+}
+
+// NewTLSConfig builds a TLS config of connections to the vmanomaly endpoint from opts.
+// CA bundle and client certificate files are re-read when they change on disk,
+// so rotated certificates are picked up without restart.
+func NewTLSConfig(endpoint string, opts TLSOptions) (*tls.Config, error) {
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errors.New("both client certificate and key files must be set for mTLS")
+	}
+	// The server certificate is verified against the endpoint host unless overridden, as crypto/tls does
+	serverName := opts.ServerName
+	if serverName == "" {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse endpoint: %w", err)
+		}
+		serverName = u.Hostname()
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CertFile != "" {
+		r := &fileReloader[tls.Certificate]{
+			files: []string{opts.CertFile, opts.KeyFile},
+			load: func() (*tls.Certificate, error) {
+				cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load client certificate: %w", err)
+				}
+				return &cert, nil
+			},
+		}
+		if _, err := r.get(); err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.get()
+		}
+	}
+
+	if opts.CAFile != "" && !opts.InsecureSkipVerify {
+		r := &fileReloader[x509.CertPool]{
+			files: []string{opts.CAFile},
+			load: func() (*x509.CertPool, error) {
+				data, err := os.ReadFile(opts.CAFile)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read CA file: %w", err)
+				}
+				pool := x509.NewCertPool()
+				if !pool.AppendCertsFromPEM(data) {
+					return nil, fmt.Errorf("no certificates found in CA file %q", opts.CAFile)
+				}
+				return pool, nil
+			},
+		}
+		if _, err := r.get(); err != nil {
+			return nil, err
+		}
+		// RootCAs can't be swapped in a live config, so the standard verification is replaced
+		// with an equivalent one that uses the current CA pool
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			pool, err := r.get()
+			if err != nil {
+				return err
+			}
+			return verifyPeer(cs, pool, serverName)
+		}
+	}
+
+	return cfg, nil
+}
+
+// verifyPeer verifies the server certificate chain against roots and serverName the same way crypto/tls does.
+// serverName may be an IP address, unlike the SNI in cs.ServerName, which is empty for IP endpoints.
+func verifyPeer(cs tls.ConnectionState, roots *x509.CertPool, serverName string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server didn't present a certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// fileReloader caches a value loaded from files and reloads it when any of the files changes.
+// If reload fails, the previously loaded value is kept.
+type fileReloader[T any] struct {
+	files []string
+	load  func() (*T, error)
+
+	mu       sync.Mutex
+	value    *T
+	modTimes []time.Time
+}
+
+func (r *fileReloader[T]) get() (*T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes := make([]time.Time, len(r.files))
+	for i, f := range r.files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			if r.value != nil {
+				return r.value, nil
+			}
+			return nil, err
+		}
+		modTimes[i] = fi.ModTime()
+	}
+	if r.value != nil && slices.EqualFunc(modTimes, r.modTimes, time.Time.Equal) {
+		return r.value, nil
+	}
+
+	value, err := r.load()
+	if err != nil {
+		if r.value != nil {
+			// Don't retry until the files change again
+			r.modTimes = modTimes
+			slog.Warn("Failed to reload TLS files, using previous version", "files", r.files, "error", err)
+			return r.value, nil
+		}
+		return nil, err
+	}
+	r.value = value
+	r.modTimes = modTimes
+	return value, nil
+}
@@ -0,0 +1,266 @@
+package vmanomaly
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM-encoded certificate and key signed by the CA for the given DNS names and IP addresses
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage, names ...string) ([]byte, []byte) {
+	t.Helper()
+	var dnsNames []string
+	var ips []net.IP
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			ips = append(ips, ip)
+		} else {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewTLSConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	writeFile(t, notPEM, []byte("not a certificate"), time.Now())
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr string
+	}{
+		{name: "cert without key", opts: TLSOptions{CertFile: "cert.pem"}, wantErr: "both client certificate and key"},
+		{name: "missing CA file", opts: TLSOptions{CAFile: filepath.Join(dir, "missing.pem")}, wantErr: "no such file"},
+		{name: "invalid CA file", opts: TLSOptions{CAFile: notPEM}, wantErr: "no certificates found"},
+		{name: "missing client cert", opts: TLSOptions{CertFile: notPEM, KeyFile: notPEM}, wantErr: "failed to load client certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTLSConfig("https://vmanomaly.internal:8490", tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_MutualTLS(t *testing.T) {
+	ca := newTestCA(t, "test-ca")
+	serverCert, serverKey := ca.issue(t, "vmanomaly", x509.ExtKeyUsageServerAuth, "vmanomaly.internal", "127.0.0.1")
+	serverPair, err := tls.X509KeyPair(serverCert, serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Force new handshakes so that rotated client certificates are used
+		w.Header().Set("Connection", "close")
+		_, _ = w.Write([]byte(`{"status":"` + r.TLS.PeerCertificates[0].Subject.CommonName + `"}`))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+	modTime := time.Now().Add(-time.Minute)
+	writeFile(t, caFile, ca.pem, modTime)
+	cert, key := ca.issue(t, "client-1", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, cert, modTime)
+	writeFile(t, keyFile, key, modTime)
+
+	tlsConfig, err := NewTLSConfig(server.URL, TLSOptions{
+		CAFile:     caFile,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		ServerName: "vmanomaly.internal",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := NewClient(server.URL, "", nil, WithTLSConfig(tlsConfig))
+
+	result, err := client.GetHealth(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, result["status"], "client-1")
+
+	// Rotated client certificate is picked up on the next handshake
+	cert, key = ca.issue(t, "client-2", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, cert, modTime.Add(time.Second))
+	writeFile(t, keyFile, key, modTime.Add(time.Second))
+	result, err = client.GetHealth(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error after rotation: %v", err)
+	}
+	assertEqual(t, result["status"], "client-2")
+
+	// Broken files on rotation keep the previous certificate
+	writeFile(t, certFile, []byte("garbage"), modTime.Add(2*time.Second))
+	if _, err := client.GetHealth(context.Background()); err != nil {
+		t.Fatalf("unexpected error with broken rotation: %v", err)
+	}
+
+	// Server certificate must match the server name
+	tlsConfig, err = NewTLSConfig(server.URL, TLSOptions{CAFile: caFile, ServerName: "other.internal"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client = NewClient(server.URL, "", nil, WithTLSConfig(tlsConfig))
+	if _, err := client.GetHealth(context.Background()); err == nil {
+		t.Fatal("expected error for mismatched server name")
+	}
+
+	// Server certificate must be signed by the CA
+	otherCA := filepath.Join(dir, "other-ca.pem")
+	writeFile(t, otherCA, newTestCA(t, "other-ca").pem, modTime)
+	tlsConfig, err = NewTLSConfig(server.URL, TLSOptions{CAFile: otherCA, ServerName: "vmanomaly.internal"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client = NewClient(server.URL, "", nil, WithTLSConfig(tlsConfig))
+	if _, err := client.GetHealth(context.Background()); err == nil {
+		t.Fatal("expected error for unknown CA")
+	}
+}
+
+func TestClient_TLSServerNameFromEndpoint(t *testing.T) {
+	ca := newTestCA(t, "test-ca")
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	newServer := func(names ...string) *httptest.Server {
+		cert, key := ca.issue(t, "vmanomaly", x509.ExtKeyUsageServerAuth, names...)
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		}))
+		server.TLS = &tls.Config{Certificates: []tls.Certificate{pair}}
+		server.StartTLS()
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	tests := []struct {
+		name       string
+		certNames  []string
+		serverName string
+		wantErr    string
+	}{
+		{name: "IP endpoint matching IP SAN", certNames: []string{"127.0.0.1"}},
+		{name: "IP endpoint without IP SAN", certNames: []string{"other.example"}, wantErr: "doesn't contain any IP SANs"},
+		{name: "IP endpoint with other IP SAN", certNames: []string{"127.0.0.2"}, wantErr: "valid for 127.0.0.2, not 127.0.0.1"},
+		{name: "server name overrides endpoint host", certNames: []string{"other.example"}, serverName: "other.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newServer(tt.certNames...)
+			tlsConfig, err := NewTLSConfig(server.URL, TLSOptions{CAFile: caFile, ServerName: tt.serverName})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			client := NewClient(server.URL, "", nil, WithTLSConfig(tlsConfig))
+			_, err = client.GetHealth(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
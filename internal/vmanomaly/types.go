@@ -129,6 +129,21 @@ const (
 	ModelClassAuto                ModelClassEnum = "auto"
 )
 
+// ModelClasses lists all ModelClassEnum values
+var ModelClasses = []ModelClassEnum{
+	ModelClassRollingQuantile,
+	ModelClassStd,
+	ModelClassQuantileOnline,
+	ModelClassZScoreOnline,
+	ModelClassHoltWinters,
+	ModelClassMADOnline,
+	ModelClassProphet,
+	ModelClassMAD,
+	ModelClassIsolationForestUniv,
+	ModelClassZScore,
+	ModelClassAuto,
+}
+
 // ============================================================================
 // Query Types
 // ============================================================================
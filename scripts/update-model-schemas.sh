@@ -0,0 +1,69 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+# Update the embedded snapshot of vmanomaly model schemas from a running vmanomaly instance.
+# The snapshot is served by model tools when vmanomaly is unreachable.
+#
+# Usage: VMANOMALY_ENDPOINT=http://localhost:8490 ./scripts/update-model-schemas.sh
+
+SCRIPT_DIR="$(cd -- "$(dirname -- "${BASH_SOURCE[0]}")" && pwd)"
+REPO_ROOT="$(cd -- "${SCRIPT_DIR}/.." && pwd)"
+SNAPSHOT_FILE="${REPO_ROOT}/internal/resources/model_schemas.json"
+TYPES_FILE="${REPO_ROOT}/internal/vmanomaly/types.go"
+ENDPOINT="${VMANOMALY_ENDPOINT:-http://localhost:8490}"
+TMP_DIR="$(mktemp -d /tmp/vmanomaly-schemas.XXXXXX)"
+
+cleanup() {
+  rm -rf "${TMP_DIR}"
+}
+trap cleanup EXIT
+
+for tool in curl jq; do
+  if ! command -v "${tool}" >/dev/null 2>&1; then
+    echo "error: ${tool} is required" >&2
+    exit 1
+  fi
+done
+
+curl_args=(--silent --show-error --fail --max-time 30)
+if [ -n "${VMANOMALY_BEARER_TOKEN:-}" ]; then
+  curl_args+=(--header "Authorization: Bearer ${VMANOMALY_BEARER_TOKEN}")
+fi
+
+fetch() {
+  curl "${curl_args[@]}" "${ENDPOINT%/}$1"
+}
+
+version="$(fetch /api/v1/server/buildinfo | jq -er '.vmanomaly')"
+fetch /api/v1/models | jq -e '.models' > "${TMP_DIR}/models.json"
+
+# Fetch schemas of every ModelClassEnum value and every model reported by vmanomaly
+{
+  sed -n 's/^[[:space:]]*ModelClass[A-Za-z]*[[:space:]]*ModelClassEnum = "\(.*\)"$/\1/p' "${TYPES_FILE}"
+  jq -r '.[]' "${TMP_DIR}/models.json"
+} | sort -u > "${TMP_DIR}/classes.txt"
+
+mkdir -p "${TMP_DIR}/schemas"
+while read -r class; do
+  fetch "/api/v1/model/schema?model_class=${class}" > "${TMP_DIR}/schemas/${class}.json"
+done < "${TMP_DIR}/classes.txt"
+
+jq -n -S \
+  --arg version "${version}" \
+  --arg generated_at "$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+  --slurpfile models "${TMP_DIR}/models.json" \
+  '{version: $version, generated_at: $generated_at, models: $models[0], schemas: (reduce inputs as $schema ({}; . + {(input_filename | split("/")[-1] | rtrimstr(".json")): $schema}))}' \
+  "${TMP_DIR}"/schemas/*.json > "${TMP_DIR}/snapshot.json"
+
+# Every model class must have an object schema, otherwise the fallback would miss it
+missing="$(jq -r --rawfile classes "${TMP_DIR}/classes.txt" \
+  '.schemas as $s | $classes | split("\n")[] | select(. != "" and ($s[.] | type) != "object")' \
+  "${TMP_DIR}/snapshot.json")"
+if [ -n "${missing}" ]; then
+  echo "error: vmanomaly ${version} returned no schema of model classes: ${missing//$'\n'/, }" >&2
+  exit 1
+fi
+mv "${TMP_DIR}/snapshot.json" "${SNAPSHOT_FILE}"
+
+echo "✅ Model schemas updated successfully!"
+echo "📁 Location: internal/resources/model_schemas.json (vmanomaly ${version}, $(wc -l < "${TMP_DIR}/classes.txt" | tr -d ' ') models)"